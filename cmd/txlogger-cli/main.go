@@ -0,0 +1,365 @@
+// txlogger-cli logs an ECU without the GUI, for a laptop in the car or a logger that runs unattended.
+//
+//	txlogger-cli -ecu T7 -adapter "CANUSB VCP" -port COM3 -preset "T7 Dash" -rate 25
+//
+// Settings can also be read from a JSON file with -config, flags given on the command line override it.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/canadapter"
+	"github.com/roffe/txlogger/pkg/cancapture"
+	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ecusim"
+	"github.com/roffe/txlogger/pkg/gps"
+	"github.com/roffe/txlogger/pkg/presets"
+)
+
+type config struct {
+	ECU      string `json:"ecu"`
+	Adapter  string `json:"adapter"`
+	Port     string `json:"port"`
+	Baudrate string `json:"baudrate"`
+	Debug    bool   `json:"debug"`
+	// Replay is the capture the ECU simulator adapter plays
+	Replay string `json:"replay"`
+
+	// Preset is the name of a system preset or a preset file, Symbols a comma separated list of symbol names
+	Preset  string `json:"preset"`
+	Symbols string `json:"symbols"`
+	// Bin is the binary the addresses of the symbols are read from
+	Bin string `json:"bin"`
+
+	Rate    int `json:"rate"`
+	RateMin int `json:"rateMin"`
+	RateMax int `json:"rateMax"`
+
+	// Format is comma separated to write more than one log at once
+	Format         string        `json:"format"`
+	Path           string        `json:"path"`
+	Prefix         string        `json:"prefix"`
+	Compression    string        `json:"compression"`
+	RotateSize     float64       `json:"rotateSize"`
+	RotateInterval time.Duration `json:"rotateInterval"`
+	CANCapture     bool          `json:"canCapture"`
+	// Metadata starts CSV and TXL logs with a metadata header
+	Metadata bool `json:"metadata"`
+
+	Wideband datalogger.WidebandConfig `json:"wideband"`
+	GPS      datalogger.GPSConfig      `json:"gps"`
+
+	// Status is how often the rates are printed, 0 turns it off
+	Status time.Duration `json:"status"`
+}
+
+func defaultConfig() config {
+	return config{
+		Baudrate: "115200",
+		Rate:     25,
+		Format:   "CSV",
+		Wideband: datalogger.WidebandConfig{
+			Type:                   "None",
+			MaximumVoltageWideband: 5,
+			Low:                    0.5,
+			High:                   1.5,
+		},
+		GPS:         datalogger.GPSConfig{Baudrate: gps.DefaultBaudrate},
+		Compression: "None",
+		Status:      time.Second,
+	}
+}
+
+func (c *config) flags(fs *flag.FlagSet) *string {
+	configFile := fs.String("config", "", "read settings from a JSON `file`, flags override it")
+	fs.StringVar(&c.ECU, "ecu", c.ECU, "ECU, T5, T7, T8, Z22SE or OBD2, taken from -bin when empty")
+	fs.StringVar(&c.Adapter, "adapter", c.Adapter, "CANbus adapter, see -list")
+	fs.StringVar(&c.Port, "port", c.Port, "serial port of the adapter")
+	fs.StringVar(&c.Baudrate, "baudrate", c.Baudrate, "serial port speed, like 115200 or 1mbit")
+	fs.BoolVar(&c.Debug, "debug", c.Debug, "adapter debug output")
+	fs.StringVar(&c.Replay, "replay", c.Replay, "capture played by the ECU simulator")
+	fs.StringVar(&c.Preset, "preset", c.Preset, "system preset `name` or preset file to log")
+	fs.StringVar(&c.Symbols, "symbols", c.Symbols, "comma separated symbol `names` to log, needs -bin")
+	fs.StringVar(&c.Bin, "bin", c.Bin, "binary to read the symbol addresses from")
+	fs.IntVar(&c.Rate, "rate", c.Rate, "samples per second")
+	fs.IntVar(&c.RateMin, "rate-min", c.RateMin, "lowest adaptive rate, 0 keeps -rate fixed")
+	fs.IntVar(&c.RateMax, "rate-max", c.RateMax, "highest adaptive rate")
+	fs.StringVar(&c.Format, "format", c.Format, "comma separated log formats, CSV, TXL, TXB or MLG")
+	fs.StringVar(&c.Path, "path", c.Path, "directory to log to, the txlogger log directory when empty")
+	fs.StringVar(&c.Prefix, "prefix", c.Prefix, "log filename prefix")
+	fs.StringVar(&c.Compression, "compression", c.Compression, "compression of CSV and TXL logs, None, gzip or zstd")
+	fs.Float64Var(&c.RotateSize, "rotate-size", c.RotateSize, "start a new log after this many MB, 0 disables")
+	fs.DurationVar(&c.RotateInterval, "rotate-interval", c.RotateInterval, "start a new log after this long, 0 disables")
+	fs.BoolVar(&c.CANCapture, "can-capture", c.CANCapture, "record every CAN frame to an ASC file next to the log")
+	fs.BoolVar(&c.Metadata, "metadata", c.Metadata, "start CSV and TXL logs with a metadata header, T7Suite can't read it")
+	fs.StringVar(&c.Wideband.Type, "wbl", c.Wideband.Type, "wideband type, None, ECU, CAN or the name of a serial wideband")
+	fs.StringVar(&c.Wideband.Port, "wbl-port", c.Wideband.Port, "serial port of the wideband")
+	fs.Float64Var(&c.Wideband.MinimumVoltageWideband, "wbl-min-voltage", c.Wideband.MinimumVoltageWideband, "wideband voltage at -wbl-low")
+	fs.Float64Var(&c.Wideband.MaximumVoltageWideband, "wbl-max-voltage", c.Wideband.MaximumVoltageWideband, "wideband voltage at -wbl-high")
+	fs.Float64Var(&c.Wideband.Low, "wbl-low", c.Wideband.Low, "lambda at the minimum voltage")
+	fs.Float64Var(&c.Wideband.High, "wbl-high", c.Wideband.High, "lambda at the maximum voltage")
+	fs.StringVar(&c.GPS.Port, "gps-port", c.GPS.Port, "serial port of an NMEA GPS receiver")
+	fs.IntVar(&c.GPS.Baudrate, "gps-baudrate", c.GPS.Baudrate, "GPS port speed")
+	fs.DurationVar(&c.Status, "status", c.Status, "how often the rates are printed, 0 turns it off")
+	return configFile
+}
+
+// parseConfig reads the flags, the config file is read first so the flags set override it
+func parseConfig(args []string) (config, error) {
+	cfg := defaultConfig()
+	fs := flag.NewFlagSet("txlogger-cli", flag.ExitOnError)
+	list := fs.Bool("list", false, "list the CANbus adapters and presets and exit")
+	configFile := cfg.flags(fs)
+	fs.Parse(args)
+	if *list {
+		fmt.Println("Adapters:")
+		for _, name := range gocan.ListAdapterNames() {
+			fmt.Println("  " + name)
+		}
+		fmt.Println("Presets:")
+		for _, name := range presets.Names() {
+			fmt.Println("  " + name)
+		}
+		os.Exit(0)
+	}
+	if *configFile != "" {
+		b, err := os.ReadFile(*configFile)
+		if err != nil {
+			return cfg, err
+		}
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return cfg, fmt.Errorf("failed to read %s: %w", *configFile, err)
+		}
+		fs.Parse(args)
+	}
+	return cfg, nil
+}
+
+func main() {
+	log.SetFlags(log.LstdFlags)
+	presets.SetDefaults()
+	cfg, err := parseConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cfg config) error {
+	symbols, rates, ecuType, err := loadSymbols(cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.ECU == "" {
+		cfg.ECU = ecuType
+	}
+	if cfg.ECU == "" {
+		return errors.New("no ECU selected, use -ecu")
+	}
+	if len(symbols) == 0 {
+		return errors.New("no symbols to log, use -preset or -symbols")
+	}
+	if cfg.Path == "" {
+		if cfg.Path, err = common.GetLogPath(); err != nil {
+			return err
+		}
+	}
+
+	baudrate, err := canadapter.ParseBaudrate(cfg.Baudrate)
+	if err != nil {
+		return fmt.Errorf("invalid baudrate: %w", err)
+	}
+	device, err := canadapter.New(canadapter.Config{
+		Name:        cfg.Adapter,
+		Port:        cfg.Port,
+		Baudrate:    baudrate,
+		ECU:         cfg.ECU,
+		WidebandCAN: cfg.Wideband.Type == "CAN",
+		Replay:      cfg.Replay,
+		Debug:       cfg.Debug,
+		OnMessage:   logMessage,
+	})
+	if err != nil {
+		return err
+	}
+
+	var formats []string
+	for f := range strings.SplitSeq(cfg.Format, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			formats = append(formats, f)
+		}
+	}
+	if len(formats) == 0 {
+		return errors.New("no log format")
+	}
+
+	var fps, captures, errs atomic.Int64
+	var meta *datalogger.Metadata
+	if cfg.Metadata {
+		meta = &datalogger.Metadata{}
+		if cfg.Bin != "" {
+			meta.Binary = filepath.Base(cfg.Bin)
+		}
+	}
+	dl, filename, err := datalogger.New(datalogger.Config{
+		FilenamePrefix: cfg.Prefix,
+		ECU:            cfg.ECU,
+		Device:         device,
+		Symbols:        symbols,
+		Rate:           cfg.Rate,
+		RateMin:        cfg.RateMin,
+		RateMax:        cfg.RateMax,
+		RateClasses:    rates,
+		OnMessage:      logMessage,
+		CaptureCounter: func(i int) { captures.Store(int64(i)) },
+		ErrorCounter:   func(i int) { errs.Store(int64(i)) },
+		FpsCounter:     func(i int) { fps.Store(int64(i)) },
+		LogFormat:      formats[0],
+		LogFormats:     formats,
+		LogPath:        cfg.Path,
+		WidebandConfig: cfg.Wideband,
+		GPS:            cfg.GPS,
+		Compression:    cfg.Compression,
+		RotateSize:     int64(cfg.RotateSize * 1024 * 1024),
+		RotateInterval: cfg.RotateInterval,
+		CANCapture:     cfg.CANCapture,
+		Metadata:       meta,
+	})
+	if err != nil {
+		return err
+	}
+	logMessage("Logging to " + filename)
+
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+	done := make(chan error, 1)
+	go func() {
+		logMessage("Connecting to " + device.Name())
+		done <- dl.Start()
+	}()
+
+	var ticker <-chan time.Time
+	if cfg.Status > 0 {
+		t := time.NewTicker(cfg.Status)
+		defer t.Stop()
+		ticker = t.C
+	}
+	var lastErrs int64
+	for {
+		select {
+		case s := <-sig:
+			logMessage("Caught " + s.String() + ", stopping")
+			dl.Close()
+			return finish(<-done)
+		case err := <-done:
+			return finish(err)
+		case <-ticker:
+			e := errs.Load()
+			status := fmt.Sprintf("Fps: %d Cap: %d Err: %d", fps.Load(), captures.Load(), e)
+			if d := e - lastErrs; d > 0 {
+				status += fmt.Sprintf(" (+%d)", d)
+			}
+			lastErrs = e
+			logMessage(status)
+		}
+	}
+}
+
+func finish(err error) error {
+	if errors.Is(err, cancapture.ErrEndOfCapture) {
+		logMessage("Reached the end of the capture")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logMessage("Stopped")
+	return nil
+}
+
+// loadSymbols returns the symbols to log with their rate classes and the ECU of the binary when one is given
+func loadSymbols(cfg config) ([]*symbol.Symbol, map[string]datalogger.RateClass, string, error) {
+	var (
+		symbols []*symbol.Symbol
+		rates   map[string]datalogger.RateClass
+		err     error
+	)
+	if cfg.Preset != "" {
+		if _, found := presets.Map[cfg.Preset]; found {
+			symbols, rates, err = presets.Get(cfg.Preset)
+		} else {
+			var b []byte
+			if b, err = os.ReadFile(cfg.Preset); err == nil {
+				symbols, rates, err = presets.Unmarshal(b)
+			}
+		}
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to load preset %s: %w", cfg.Preset, err)
+		}
+	}
+	for name := range strings.SplitSeq(cfg.Symbols, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			symbols = append(symbols, &symbol.Symbol{Name: name})
+		}
+	}
+	if cfg.Bin == "" {
+		if cfg.Symbols != "" {
+			return nil, nil, "", errors.New("-symbols needs -bin to find the symbols in")
+		}
+		return symbols, rates, "", nil
+	}
+
+	data, err := os.ReadFile(cfg.Bin)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error reading file: %w", err)
+	}
+	ecuType, fw, err := symbol.Load(cfg.Bin, data, logMessage)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("error loading symbols: %w", err)
+	}
+	ecusim.SetSymbols(fw)
+	cnt := 0
+	for _, v := range symbols {
+		sym := fw.GetByName(v.Name)
+		if sym == nil {
+			logMessage(v.Name + " not found in " + filepath.Base(cfg.Bin))
+			continue
+		}
+		v.Name = sym.Name
+		v.Number = sym.Number
+		v.Address = sym.Address
+		v.SramOffset = sym.SramOffset
+		v.Length = sym.Length
+		v.Mask = sym.Mask
+		v.Type = sym.Type
+		v.Unit = sym.Unit
+		v.Correctionfactor = sym.Correctionfactor
+		cnt++
+	}
+	logMessage(fmt.Sprintf("Synced %d / %d symbols", cnt, len(symbols)))
+	if cnt == 0 && len(symbols) > 0 {
+		return nil, nil, "", fmt.Errorf("none of the symbols were found in %s", filepath.Base(cfg.Bin))
+	}
+	return symbols, rates, ecuType.String(), nil
+}
+
+func logMessage(msg string) {
+	log.Println(msg)
+}
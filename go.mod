@@ -13,6 +13,7 @@ require (
 	fyne.io/fyne/v2 v2.7.4-0.20260504083939-7c2981130815
 	fyne.io/x/fyne v0.0.0-20260128204654-7fd4ce591d29
 	github.com/avast/retry-go/v4 v4.7.0
+	github.com/klauspost/compress v1.18.0
 	github.com/lusingander/colorpicker v0.7.5
 	github.com/nfnt/resize v0.0.0-20180221191011-83c6a9932646
 	github.com/pion/mdns/v2 v2.1.0
@@ -148,7 +148,7 @@ func handleArgs(mw *windows.MainWindow, tx fyne.App) {
 			} else {
 				loadedSymbols = true
 			}
-		case ".t5l", ".t7l", ".t8l", ".csv":
+		case ".t5l", ".t7l", ".t8l", ".csv", ".txb", ".mlg", ".msl":
 			f, err := os.Open(filename)
 			if err != nil {
 				mw.Error(err)
@@ -0,0 +1,221 @@
+// Package alarms watches live values on the event bus and fires alarms from threshold rules
+package alarms
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/eventbus"
+	"github.com/roffe/txlogger/pkg/events"
+)
+
+const prefsAlarmRules = "alarmRules"
+
+// Rule is an event rule watched live. Hysteresis is how far past the threshold the channel has to go back
+// before the alarm clears, Duration is how long in seconds the condition must hold before it fires.
+// Neither applies to Increases, which fires on every increase.
+type Rule struct {
+	events.Rule
+	Hysteresis float64 `json:"hysteresis,omitempty"`
+	Duration   float64 `json:"duration,omitempty"`
+	// Silent alarms don't play a tone
+	Silent bool `json:"silent,omitempty"`
+}
+
+func (r Rule) Validate() error {
+	if err := r.Rule.Validate(); err != nil {
+		return err
+	}
+	if r.Hysteresis < 0 {
+		return fmt.Errorf("alarm %s has negative hysteresis", r.Name)
+	}
+	if r.Duration < 0 {
+		return fmt.Errorf("alarm %s has negative duration", r.Name)
+	}
+	return nil
+}
+
+// Alarm is a rule that fired
+type Alarm struct {
+	Time   time.Time
+	Rule   string
+	Value  float64
+	Silent bool
+}
+
+// Engine fires alarms and counts them, the count is published as datalogger.ALARMSYM
+type Engine struct {
+	count   atomic.Int64
+	onAlarm func(Alarm)
+}
+
+// New creates an engine calling onAlarm from the event bus, onAlarm must not block
+func New(onAlarm func(Alarm)) *Engine {
+	return &Engine{onAlarm: onAlarm}
+}
+
+// Count is how many alarms have fired
+func (e *Engine) Count() float64 {
+	return float64(e.count.Load())
+}
+
+type state struct {
+	holding bool
+	fired   bool
+	since   time.Time
+	last    float64
+	seen    bool
+}
+
+type watcher struct {
+	rules  []Rule
+	states []state
+	values map[string]float64
+}
+
+// Aggregator returns an event bus aggregator watching rules, each call starts with cleared alarms
+func (e *Engine) Aggregator(rules []Rule) *eventbus.EventAggregator {
+	w := &watcher{values: make(map[string]float64)}
+	seen := make(map[string]bool)
+	var topics []string
+	addTopic := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			topics = append(topics, name)
+		}
+	}
+	for _, r := range rules {
+		if r.Disabled {
+			continue
+		}
+		w.rules = append(w.rules, r)
+		addTopic(r.Channel)
+		addTopic(r.When)
+	}
+	w.states = make([]state, len(w.rules))
+	return eventbus.NewAggregator(topics, func(c eventbus.DiffPublisher, name string, value float64) {
+		for _, a := range w.update(name, value, time.Now()) {
+			c.Publish(datalogger.ALARMSYM, float64(e.count.Add(1)))
+			e.onAlarm(a)
+		}
+	})
+}
+
+// update sets name to value and returns the alarms that fire
+func (w *watcher) update(name string, value float64, now time.Time) []Alarm {
+	w.values[name] = value
+	var fired []Alarm
+	for i, r := range w.rules {
+		if r.Channel != name && r.When != name {
+			continue
+		}
+		st := &w.states[i]
+		v, ok := w.values[r.Channel]
+		if !ok {
+			continue
+		}
+		holding := false
+		switch r.Condition {
+		case events.Increases:
+			if r.Channel != name {
+				continue
+			}
+			holding = st.seen && v > st.last
+			st.last, st.seen = v, true
+			// every increase is a new alarm, also when the last update increased too
+			st.fired = false
+		case events.AtLeast:
+			if st.holding {
+				holding = v > r.Threshold-r.Hysteresis
+			} else {
+				holding = v >= r.Threshold
+			}
+		case events.AtMost:
+			if st.holding {
+				holding = v < r.Threshold+r.Hysteresis
+			} else {
+				holding = v <= r.Threshold
+			}
+		}
+		if holding && r.When != "" {
+			wv, ok := w.values[r.When]
+			holding = ok && wv >= r.WhenAtLeast
+		}
+		if holding && !st.holding {
+			st.since = now
+		}
+		st.holding = holding
+		if !holding {
+			st.fired = false
+			continue
+		}
+		if st.fired || (r.Condition != events.Increases && now.Sub(st.since).Seconds() < r.Duration) {
+			continue
+		}
+		st.fired = true
+		fired = append(fired, Alarm{Time: now, Rule: r.Name, Value: v, Silent: r.Silent})
+	}
+	return fired
+}
+
+// Defaults returns the alarms a new installation starts out with
+func Defaults(ecu string) []Rule {
+	switch ecu {
+	case "T5":
+		return []Rule{
+			{Rule: events.Rule{Name: "Knock", Channel: "Knock_offset1234", Condition: events.Increases}},
+			{Rule: events.Rule{Name: "Lean under boost", Channel: datalogger.EXTERNALWBLSYM, Condition: events.AtLeast, Threshold: 0.9, When: "P_medel", WhenAtLeast: 0.5}, Hysteresis: 0.02, Duration: 0.3},
+			{Rule: events.Rule{Name: "Coolant temperature", Channel: "Kyl_temp", Condition: events.AtLeast, Threshold: 105}, Hysteresis: 3, Duration: 2},
+			{Rule: events.Rule{Name: "Overboost", Channel: "P_medel", Condition: events.AtLeast, Threshold: 1.6}, Hysteresis: 0.05, Duration: 0.5},
+		}
+	case "T7":
+		return []Rule{
+			{Rule: events.Rule{Name: "Knock", Channel: "KnkDet.KnockCyl", Condition: events.Increases}},
+			{Rule: events.Rule{Name: "Lean under boost", Channel: datalogger.EXTERNALWBLSYM, Condition: events.AtLeast, Threshold: 0.9, When: "ActualIn.p_AirInlet", WhenAtLeast: 0.5}, Hysteresis: 0.02, Duration: 0.3},
+			{Rule: events.Rule{Name: "Coolant temperature", Channel: "ActualIn.T_Engine", Condition: events.AtLeast, Threshold: 105}, Hysteresis: 3, Duration: 2},
+			{Rule: events.Rule{Name: "Limp home", Channel: "LIMP", Condition: events.AtLeast, Threshold: 1}},
+			{Rule: events.Rule{Name: "Check engine", Channel: "CEL", Condition: events.AtLeast, Threshold: 1}},
+			{Rule: events.Rule{Name: "Overboost", Channel: "In.p_AirBefThrottle", Condition: events.AtLeast, Threshold: 1.6}, Hysteresis: 0.05, Duration: 0.5},
+		}
+	case "T8":
+		return []Rule{
+			{Rule: events.Rule{Name: "Knock", Channel: "KnkDet.KnockCyl", Condition: events.Increases}},
+			{Rule: events.Rule{Name: "Lean under boost", Channel: datalogger.EXTERNALWBLSYM, Condition: events.AtLeast, Threshold: 0.9, When: "In.p_AirInlet", WhenAtLeast: 0.5}, Hysteresis: 0.02, Duration: 0.3},
+			{Rule: events.Rule{Name: "Coolant temperature", Channel: "ActualIn.T_Engine", Condition: events.AtLeast, Threshold: 105}, Hysteresis: 3, Duration: 2},
+			{Rule: events.Rule{Name: "Overboost", Channel: "ActualIn.p_AirBefThrottle", Condition: events.AtLeast, Threshold: 1.6}, Hysteresis: 0.05, Duration: 0.5},
+		}
+	}
+	return nil
+}
+
+// Load returns the alarms stored for ecu
+func Load(prefs fyne.Preferences, ecu string) ([]Rule, error) {
+	data := prefs.String(prefsAlarmRules + ecu)
+	if data == "" {
+		return Defaults(ecu), nil
+	}
+	var rules []Rule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return Defaults(ecu), fmt.Errorf("failed to load alarms: %w", err)
+	}
+	return rules, nil
+}
+
+// Save stores the alarms for ecu
+func Save(prefs fyne.Preferences, ecu string, rules []Rule) error {
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	prefs.SetString(prefsAlarmRules+ecu, string(data))
+	return nil
+}
@@ -0,0 +1,124 @@
+// Package canadapter opens a CANbus adapter with the filter and CAN rate an ECU is logged with
+package canadapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/ecusim"
+	"github.com/roffe/txlogger/pkg/mdns"
+	"github.com/roffe/txlogger/pkg/obd2"
+	"github.com/roffe/txlogger/pkg/ota"
+)
+
+type Config struct {
+	// Name of the adapter as listed by gocan
+	Name     string
+	Port     string
+	Baudrate int
+	ECU      string
+	// WidebandCAN listens for a wideband sending on CAN with T7, T8 and Z22SE
+	WidebandCAN bool
+	// Replay is the capture the ECU simulator plays
+	Replay string
+	Debug  bool
+	// Filters are extra CAN ids to listen for with T8 and Z22SE
+	Filters []uint32
+	// OnMessage is optional
+	OnMessage func(string)
+}
+
+// ParseBaudrate converts a port speed like 115200 or 1mbit, empty is 1 Mbit
+func ParseBaudrate(s string) (int, error) {
+	switch s {
+	case "", "1mbit":
+		return 1000000, nil
+	case "2mbit":
+		return 2000000, nil
+	case "3mbit":
+		return 3000000, nil
+	}
+	return strconv.Atoi(s)
+}
+
+func New(cfg Config) (gocan.Adapter, error) {
+	if cfg.Name == "" {
+		return nil, errors.New("no CANbus adapter selected")
+	}
+
+	var canFilter []uint32
+	var canRate float64
+
+	switch cfg.ECU {
+	case "T5", "Trionic 5":
+		canFilter = []uint32{0xC}
+		canRate = 615.384
+	case "T7", "Trionic 7":
+		if strings.Contains(cfg.Name, "ELM327") || strings.Contains(cfg.Name, "STN") || strings.Contains(cfg.Name, "OBDLink") || strings.HasSuffix(cfg.Name, "Wifi") {
+			canFilter = []uint32{0x238, 0x258, 0x270}
+		} else {
+			canFilter = []uint32{0x1A0, 0x238, 0x258, 0x270, 0x280, 0x3A0, 0x664, 0x665}
+		}
+		if cfg.WidebandCAN {
+			canFilter = append(canFilter, 0x180)
+		}
+		canRate = 500
+	case "T8", "Trionic 8", "Trionic 8 MCP", "Z22SE", "Z22SE MCP":
+		if strings.Contains(cfg.Name, "ELM327") || strings.Contains(cfg.Name, "STN") || strings.Contains(cfg.Name, "OBDLink") {
+			canFilter = []uint32{0x5E8, 0x7E8}
+		} else {
+			canFilter = []uint32{0x5E8, 0x7E8, 0x664, 0x665}
+		}
+		if cfg.WidebandCAN {
+			canFilter = append(canFilter, 0x180)
+		}
+		canFilter = append(canFilter, cfg.Filters...)
+
+		canRate = 500
+	case "OBD2":
+		canFilter = obd2.ResponseIDs()
+		canRate = 500
+	}
+
+	adapterCfg := &gocan.AdapterConfig{
+		Port:         cfg.Port,
+		PortBaudrate: cfg.Baudrate,
+		CANRate:      canRate,
+		CANFilter:    canFilter,
+		Debug:        cfg.Debug,
+		PrintVersion: true,
+	}
+
+	if cfg.Name == ecusim.Name {
+		adapterCfg.AdditionalConfig = map[string]string{
+			ecusim.ConfigECU:    cfg.ECU,
+			ecusim.ConfigReplay: cfg.Replay,
+		}
+	}
+
+	if strings.HasPrefix(cfg.Name, "J2534") { // || strings.HasPrefix(cfg.Name, "CANlib") {
+		return gocan.NewGWClient(cfg.Name, adapterCfg)
+	}
+
+	if cfg.Name == "txbridge wifi" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		addr, err := mdns.Query(ctx, "txbridge.local")
+		if err != nil {
+			if cfg.OnMessage != nil {
+				cfg.OnMessage(fmt.Sprintf("Failed to resolve txbridge address via mDNS: %v", err))
+			}
+		} else {
+			adapterCfg.AdditionalConfig = map[string]string{
+				"address":    fmt.Sprintf("%s:%d", addr.String(), 1337),
+				"minversion": ota.MinimumtxbridgeVersion,
+			}
+		}
+	}
+	return gocan.NewAdapter(cfg.Name, adapterCfg)
+}
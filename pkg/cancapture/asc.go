@@ -0,0 +1,211 @@
+// Package cancapture records the raw CAN frames of a logging session to a Vector ASC file
+// and replays them as a gocan adapter so a capture can be decoded again
+package cancapture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roffe/gocan"
+)
+
+// ascDate is how ASC files write dates, "Tue Oct 17 10:04:05.000 am 2026"
+const ascDate = "Mon Jan 2 03:04:05.000 pm 2006"
+
+// Record is a frame in a capture
+type Record struct {
+	// Offset is the time since the start of the capture
+	Offset time.Duration
+	// Tx is set for frames sent by txlogger
+	Tx    bool
+	Frame *gocan.CANFrame
+}
+
+// Header describes a capture, Adapter and ECU are what it was recorded with
+type Header struct {
+	Start   time.Time
+	Adapter string
+	ECU     string
+}
+
+// Writer writes frames in the ASC format, it is safe to use from several goroutines
+type Writer struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	c      io.Closer
+	start  time.Time
+	err    error
+	closed bool
+}
+
+func NewWriter(wc io.WriteCloser, h Header) (*Writer, error) {
+	w := &Writer{
+		w:     bufio.NewWriter(wc),
+		c:     wc,
+		start: h.Start,
+	}
+	date := h.Start.Format(ascDate)
+	fmt.Fprintf(w.w, "date %s\n", date)
+	fmt.Fprintf(w.w, "base hex  timestamps absolute\n")
+	fmt.Fprintf(w.w, "internal events logged\n")
+	fmt.Fprintf(w.w, "// adapter: %s\n", h.Adapter)
+	fmt.Fprintf(w.w, "// ecu: %s\n", h.ECU)
+	fmt.Fprintf(w.w, "Begin Triggerblock %s\n", date)
+	fmt.Fprintf(w.w, "   0.000000 Start of measurement\n")
+	if err := w.w.Flush(); err != nil {
+		wc.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write records f at the current time, the first error is kept and returned by Close
+func (w *Writer) Write(tx bool, f *gocan.CANFrame) {
+	offset := time.Since(w.start)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || w.closed {
+		return
+	}
+	_, w.err = w.w.WriteString(formatRecord(Record{Offset: offset, Tx: tx, Frame: f}))
+}
+
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err == nil {
+		_, w.err = w.w.WriteString("End TriggerBlock\n")
+	}
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	if err := w.c.Close(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+func formatRecord(r Record) string {
+	var b strings.Builder
+	id := strconv.FormatUint(uint64(r.Frame.Identifier), 16)
+	if r.Frame.Extended {
+		id += "x"
+	}
+	dir := "Rx"
+	if r.Tx {
+		dir = "Tx"
+	}
+	fmt.Fprintf(&b, "%11.6f 1  %-15s %s   ", r.Offset.Seconds(), strings.ToUpper(id), dir)
+	if r.Frame.RTR {
+		fmt.Fprintf(&b, "r %d\n", r.Frame.DLC())
+		return b.String()
+	}
+	fmt.Fprintf(&b, "d %d", r.Frame.DLC())
+	for _, d := range r.Frame.Data {
+		fmt.Fprintf(&b, " %02X", d)
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// Read reads a capture, lines that aren't CAN frames like error frames and events are skipped
+func Read(r io.Reader) (*Header, []*Record, error) {
+	h := &Header{}
+	var records []*Record
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "date "):
+			if t, err := time.ParseInLocation(ascDate, strings.TrimPrefix(line, "date "), time.Local); err == nil {
+				h.Start = t
+			}
+			continue
+		case strings.HasPrefix(line, "// adapter:"):
+			h.Adapter = strings.TrimSpace(strings.TrimPrefix(line, "// adapter:"))
+			continue
+		case strings.HasPrefix(line, "// ecu:"):
+			h.ECU = strings.TrimSpace(strings.TrimPrefix(line, "// ecu:"))
+			continue
+		}
+		rec, ok, err := parseRecord(line)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if ok {
+			records = append(records, rec)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, nil, err
+	}
+	return h, records, nil
+}
+
+// parseRecord parses "   0.001234 1  7E0             Tx   d 8 01 02 03 04 05 06 07 08",
+// ok is false for lines that aren't frames
+func parseRecord(line string) (*Record, bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return nil, false, nil
+	}
+	if _, err := strconv.Atoi(fields[1]); err != nil {
+		return nil, false, nil
+	}
+	if fields[3] != "Rx" && fields[3] != "Tx" {
+		return nil, false, nil
+	}
+	secs, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return nil, false, nil
+	}
+	ident := fields[2]
+	extended := strings.HasSuffix(ident, "x")
+	id, err := strconv.ParseUint(strings.TrimSuffix(ident, "x"), 16, 32)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid identifier %q", ident)
+	}
+	dlc, err := strconv.Atoi(fields[5])
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid length %q", fields[5])
+	}
+	frame := &gocan.CANFrame{
+		Identifier: uint32(id),
+		Extended:   extended,
+		FrameType:  gocan.Incoming,
+	}
+	switch fields[4] {
+	case "r":
+		frame.RTR = true
+	case "d":
+		if len(fields) < 6+dlc {
+			return nil, false, fmt.Errorf("expected %d data bytes", dlc)
+		}
+		frame.Data = make([]byte, dlc)
+		for i := range dlc {
+			b, err := strconv.ParseUint(fields[6+i], 16, 8)
+			if err != nil {
+				return nil, false, fmt.Errorf("invalid data byte %q", fields[6+i])
+			}
+			frame.Data[i] = byte(b)
+		}
+	default:
+		return nil, false, nil
+	}
+	return &Record{
+		Offset: time.Duration(secs * float64(time.Second)),
+		Tx:     fields[3] == "Tx",
+		Frame:  frame,
+	}, true, nil
+}
@@ -0,0 +1,119 @@
+package cancapture
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/roffe/gocan"
+)
+
+// Adapter records every frame sent and received through the adapter it wraps
+type Adapter struct {
+	gocan.Adapter
+	w *Writer
+
+	sendChan, recvChan chan *gocan.CANFrame
+
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closeChan chan struct{}
+	closeErr  error
+}
+
+// adcAdapter keeps wrapped adapters that can read analog inputs doing so
+type adcAdapter struct {
+	*Adapter
+	adc gocan.ADCCapable
+}
+
+func (a *adcAdapter) GetADCValue(ctx context.Context, channel int) (float64, error) {
+	return a.adc.GetADCValue(ctx, channel)
+}
+
+// Wrap returns an adapter recording the frames of inner to w, w is closed with the adapter
+func Wrap(inner gocan.Adapter, w *Writer) gocan.Adapter {
+	a := &Adapter{
+		Adapter:   inner,
+		w:         w,
+		sendChan:  make(chan *gocan.CANFrame, 40),
+		recvChan:  make(chan *gocan.CANFrame, 1024),
+		closeChan: make(chan struct{}),
+	}
+	if adc, ok := inner.(gocan.ADCCapable); ok {
+		return &adcAdapter{Adapter: a, adc: adc}
+	}
+	return a
+}
+
+func (a *Adapter) Open(ctx context.Context) error {
+	if err := a.Adapter.Open(ctx); err != nil {
+		a.w.Close()
+		return err
+	}
+	a.wg.Add(2)
+	go a.send(ctx)
+	go a.recv(ctx)
+	return nil
+}
+
+func (a *Adapter) Close() error {
+	a.closeOnce.Do(func() {
+		err := a.Adapter.Close()
+		close(a.closeChan)
+		a.wg.Wait()
+		a.closeErr = errors.Join(err, a.w.Close())
+	})
+	return a.closeErr
+}
+
+func (a *Adapter) Send() chan<- *gocan.CANFrame {
+	return a.sendChan
+}
+
+func (a *Adapter) Recv() <-chan *gocan.CANFrame {
+	return a.recvChan
+}
+
+func (a *Adapter) send(ctx context.Context) {
+	defer a.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.closeChan:
+			return
+		case frame := <-a.sendChan:
+			a.w.Write(true, frame)
+			select {
+			case a.Adapter.Send() <- frame:
+			case <-ctx.Done():
+				return
+			case <-a.closeChan:
+				return
+			}
+		}
+	}
+}
+
+func (a *Adapter) recv(ctx context.Context) {
+	defer a.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.closeChan:
+			return
+		case frame, ok := <-a.Adapter.Recv():
+			if !ok {
+				return
+			}
+			a.w.Write(false, frame)
+			select {
+			case a.recvChan <- frame:
+			default:
+				// the client lost the frame, the capture has it
+			}
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package cancapture
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/roffe/gocan"
+)
+
+// ErrEndOfCapture ends a replay when every frame of the capture has been played
+var ErrEndOfCapture = errors.New("end of capture")
+
+// replayWindow is how many sent frames of the capture a frame from the logger is looked for in.
+// Frames that aren't found, like tester present sent at another time than in the capture, get no answer
+const replayWindow = 4
+
+// Replay is an adapter that answers the frames sent to it with what the ECU answered in a capture.
+// It has the name of the adapter the capture was made with so the logger takes the same path
+type Replay struct {
+	*gocan.BaseAdapter
+	header  *Header
+	records []*Record
+	pos     int
+	offset  atomic.Int64
+
+	sendChan, recvChan chan *gocan.CANFrame
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func OpenReplay(filename string) (*Replay, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h, records, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("the capture has no frames")
+	}
+	name := h.Adapter
+	if name == "" {
+		name = "CAN capture"
+	}
+	return &Replay{
+		BaseAdapter: gocan.NewBaseAdapter(name, &gocan.AdapterConfig{}),
+		header:      h,
+		records:     records,
+		sendChan:    make(chan *gocan.CANFrame, 40),
+		recvChan:    make(chan *gocan.CANFrame, 1024),
+		closeChan:   make(chan struct{}),
+	}, nil
+}
+
+// ECU is the ECU the capture was made from, empty when unknown
+func (r *Replay) ECU() string {
+	return r.header.ECU
+}
+
+// Now is the time in the capture of the last frame played
+func (r *Replay) Now() time.Time {
+	return r.header.Start.Add(time.Duration(r.offset.Load()))
+}
+
+func (r *Replay) Open(ctx context.Context) error {
+	go r.run(ctx)
+	return nil
+}
+
+func (r *Replay) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closeChan)
+		r.BaseAdapter.Close()
+	})
+	return nil
+}
+
+func (r *Replay) Send() chan<- *gocan.CANFrame {
+	return r.sendChan
+}
+
+func (r *Replay) Recv() <-chan *gocan.CANFrame {
+	return r.recvChan
+}
+
+func (r *Replay) run(ctx context.Context) {
+	// what the ECU broadcast before the first request
+	if !r.play(ctx) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.closeChan:
+			return
+		case frame := <-r.sendChan:
+			if r.pos >= len(r.records) {
+				r.Fatal(ErrEndOfCapture)
+				return
+			}
+			i := r.match(frame)
+			if i < 0 {
+				continue
+			}
+			r.offset.Store(int64(r.records[i].Offset))
+			r.pos = i + 1
+			if !r.play(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// match returns the index of frame among the next sent frames of the capture, -1 if it isn't there
+func (r *Replay) match(frame *gocan.CANFrame) int {
+	sent := 0
+	for i := r.pos; i < len(r.records) && sent < replayWindow; i++ {
+		rec := r.records[i]
+		if !rec.Tx {
+			continue
+		}
+		if rec.Frame.Identifier == frame.Identifier && bytes.Equal(rec.Frame.Data, frame.Data) {
+			return i
+		}
+		sent++
+	}
+	return -1
+}
+
+// play sends the received frames up to the next sent frame of the capture
+func (r *Replay) play(ctx context.Context) bool {
+	for ; r.pos < len(r.records) && !r.records[r.pos].Tx; r.pos++ {
+		rec := r.records[r.pos]
+		r.offset.Store(int64(rec.Offset))
+		frame := gocan.NewFrame(rec.Frame.Identifier, rec.Frame.Data, gocan.Incoming)
+		frame.Extended = rec.Frame.Extended
+		frame.RTR = rec.Frame.RTR
+		select {
+		case r.recvChan <- frame:
+		case <-ctx.Done():
+			return false
+		case <-r.closeChan:
+			return false
+		}
+	}
+	return true
+}
@@ -10,7 +10,7 @@ import (
 	"time"
 )
 
-var EcuList = []string{"T5", "T7", "T8"}
+var EcuList = []string{"T5", "T7", "T8", "Z22SE", "OBD2"}
 
 const (
 	Pi15            = math.Pi * 1.5
@@ -65,6 +65,16 @@ func GetBinPath() (string, error) {
 	return binPath, createDirIfNotExists(binPath)
 }
 
+// GetAPITokenPath returns the file the token of the remote control API is kept in
+func GetAPITokenPath() (string, error) {
+	dir, err := GetUserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "txlogger")
+	return filepath.Join(path, "api-token"), createDirIfNotExists(path)
+}
+
 func GetComponentPath(base, typ string) string {
 	return filepath.Join(base, "txlogger", typ)
 }
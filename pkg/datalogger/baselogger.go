@@ -31,6 +31,7 @@ type BaseLogger struct {
 	closeOnce sync.Once
 
 	secondTicker *time.Ticker
+	rc           *rateController
 
 	firstTime      time.Time
 	firstTimestamp uint32
@@ -50,6 +51,7 @@ func NewBaseLogger(cfg Config, lw LogWriter) *BaseLogger {
 		readChan:     make(chan *DataRequest, 1),
 		quitChan:     make(chan struct{}),
 		secondTicker: time.NewTicker(time.Second),
+		rc:           newRateController(cfg),
 	}
 
 	if cfg.RemoteMode == 1 {
@@ -106,6 +108,9 @@ func (bl *BaseLogger) onError() {
 }
 
 func (bl *BaseLogger) resetPerSecond() {
+	if bl.ErrorRateCounter != nil {
+		bl.ErrorRateCounter(bl.errPerSecond)
+	}
 	bl.capturePerSecond = 0
 	bl.errPerSecond = 0
 }
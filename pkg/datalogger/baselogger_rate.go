@@ -0,0 +1,90 @@
+package datalogger
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// maxErrPerSecond aborts logging when reached at the lowest rate
+	maxErrPerSecond = 5
+	// the share of each second spent waiting on the ECU that the adaptive rate aims for
+	busyLow  = 0.60
+	busyHigh = 0.85
+)
+
+// rateController adapts the polling rate between RateMin and RateMax from the round trip
+// latency and the errors of the last second
+type rateController struct {
+	enabled  bool
+	min, max float64
+	rate     float64
+
+	busy    time.Duration // time spent on polls the last second
+	polls   int
+	latency time.Duration // average round trip of a poll the last second
+}
+
+func newRateController(cfg Config) *rateController {
+	rc := &rateController{
+		rate: float64(cfg.Rate),
+	}
+	if cfg.RateMin > 0 && cfg.RateMax > cfg.RateMin {
+		rc.enabled = true
+		rc.min = float64(cfg.RateMin)
+		rc.max = float64(cfg.RateMax)
+		rc.rate = clamp(rc.rate, rc.min, rc.max)
+	}
+	return rc
+}
+
+// pollDone records the round trip of a poll started at start
+func (bl *BaseLogger) pollDone(start time.Time) {
+	bl.rc.busy += time.Since(start)
+	bl.rc.polls++
+}
+
+// pollInterval returns base scaled to the current rate, base is the tick at the configured rate
+func (bl *BaseLogger) pollInterval(base time.Duration) time.Duration {
+	if !bl.rc.enabled {
+		return base
+	}
+	return time.Duration(float64(base) * float64(bl.Rate) / bl.rc.rate)
+}
+
+// everySecond reports the achieved rate and adapts the polling rate. Errors first lower the rate,
+// ok is false when logging should abort because there are too many errors at the lowest rate
+func (bl *BaseLogger) everySecond() (changed, ok bool) {
+	defer bl.resetPerSecond()
+	bl.FpsCounter(bl.capturePerSecond)
+
+	rc := bl.rc
+	if rc.polls > 0 {
+		rc.latency = rc.busy / time.Duration(rc.polls)
+	}
+	busy := rc.busy.Seconds()
+	rc.busy, rc.polls = 0, 0
+
+	if !rc.enabled {
+		return false, bl.errPerSecond <= maxErrPerSecond
+	}
+
+	old := rc.rate
+	switch {
+	case bl.errPerSecond > 0:
+		if rc.rate <= rc.min && bl.errPerSecond > maxErrPerSecond {
+			return false, false
+		}
+		factor := 0.8
+		if bl.errPerSecond > maxErrPerSecond {
+			factor = 0.5
+		}
+		rc.rate = max(rc.min, rc.rate*factor)
+		bl.OnMessage(fmt.Sprintf("%d errors at %.0f Hz (round trip %s), backing off to %.0f Hz", bl.errPerSecond, old, rc.latency.Round(time.Millisecond), rc.rate))
+	case busy > busyHigh:
+		rc.rate = max(rc.min, rc.rate*0.9)
+	case busy < busyLow:
+		rc.rate = min(rc.max, rc.rate*1.1+1)
+	}
+	return rc.rate != old, true
+}
@@ -0,0 +1,58 @@
+package datalogger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/cancapture"
+)
+
+// Clock is implemented by adapters replaying a capture, logs then get the time of the capture
+type Clock interface {
+	Now() time.Time
+}
+
+// newCapture wraps the device of cfg so every frame is recorded to an ASC file named after the log,
+// triggered logs have no name up front so the capture gets its own
+func newCapture(cfg Config, logFilename string) (gocan.Adapter, error) {
+	var (
+		file     *os.File
+		filename string
+		err      error
+	)
+	if logFilename != "" {
+		_, name := compressionFromExtension(logFilename)
+		filename = strings.TrimSuffix(name, filepath.Ext(name)) + ".asc"
+		file, err = os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0666)
+	} else {
+		file, filename, err = createLog(cfg.LogPath, cfg.FilenamePrefix, "asc")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CAN capture: %w", err)
+	}
+	w, err := cancapture.NewWriter(file, cancapture.Header{
+		Start:   time.Now(),
+		Adapter: cfg.Device.Name(),
+		ECU:     cfg.ECU,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CAN capture: %w", err)
+	}
+	cfg.OnMessage(fmt.Sprintf("Capturing CAN frames to %s", filename))
+	return cancapture.Wrap(cfg.Device, w), nil
+}
+
+// retimeWriter writes samples with the time of a replayed capture
+type retimeWriter struct {
+	LogWriter
+	clock Clock
+}
+
+func (r *retimeWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, _ time.Time) error {
+	return r.LogWriter.Write(sysvars, sysvarOrder, vars, r.clock.Now())
+}
@@ -39,14 +39,47 @@ type Config struct {
 	Device         gocan.Adapter
 	Symbols        []*symbol.Symbol
 	Rate           int
+	// RateMin and RateMax bound the adaptive polling rate of T5, T7, T8, Z22SE and OBD2, Rate is where it starts. 0 keeps Rate fixed
+	RateMin int
+	RateMax int
+	// RateClasses by symbol name, T7, T8 and Z22SE read each class as its own group. Missing symbols are read at normal rate
+	RateClasses    map[string]RateClass
 	OnMessage      func(string)
 	CaptureCounter func(int)
 	ErrorCounter   func(int)
 	FpsCounter     func(int)
-	LogFormat      string
+	// ErrorRateCounter gets the errors of the last second, it is optional
+	ErrorRateCounter func(int)
+	LogFormat        string
+	// LogFormats writes a log in each format at once, LogFormat is used when empty
+	LogFormats     []string
 	LogPath        string
 	WidebandConfig WidebandConfig
-	RemoteMode     int
+	// GPS adds position and speed from an NMEA receiver to every sample when its port is set
+	GPS        GPSConfig
+	RemoteMode int
+	// Trigger enables triggered logging when set
+	Trigger *Trigger
+	// Compression of CSV and TXL logs, None, gzip or zstd
+	Compression string
+	// RotateSize and RotateInterval start a new log when reached, 0 disables
+	RotateSize     int64
+	RotateInterval time.Duration
+	// Outputs are written next to the logs through a FanoutWriter for the whole session, like telemetry streams
+	Outputs []Output
+	// CANCapture records every CAN frame of the session to an ASC file next to the log
+	CANCapture bool
+	// Alarms returns how many alarms have fired, it is logged as ALARMSYM when set
+	Alarms func() float64
+	// Metadata is written as a header in CSV and TXL logs when set, ECU, adapter, rate and wideband are filled in from
+	// the config. Readers that don't know the header, like T7Suite, can't open such logs so it is opt in
+	Metadata *Metadata
+}
+
+// Output is a LogWriter that isn't a log file
+type Output struct {
+	Name string
+	LogWriter
 }
 
 type Client struct {
@@ -65,16 +98,44 @@ type WidebandConfig struct {
 
 func New(cfg Config) (IClient, string, error) {
 	log.Println("RemoteMode", cfg.RemoteMode)
+	if cfg.Metadata != nil {
+		meta := *cfg.Metadata
+		meta.ECU = cfg.ECU
+		meta.Rate = cfg.Rate
+		meta.Wideband = WidebandDescription(cfg.WidebandConfig)
+		if cfg.Device != nil {
+			meta.Adapter = cfg.Device.Name()
+		}
+		cfg.Metadata = &meta
+	}
 	datalogger := &Client{
 		cfg: cfg,
 	}
 
-	filename, lw, err := NewWriter(cfg)
+	filename, lw, err := newSessionWriter(cfg)
 	if err != nil {
 		return nil, "", err
 	}
 
-	cfg.OnMessage(fmt.Sprintf("Logging to %s", filename))
+	clock, replaying := cfg.Device.(Clock)
+	if cfg.GPS.Port != "" && !replaying {
+		lw = newGPSWriter(lw, cfg.GPS, cfg.OnMessage)
+	}
+
+	if cfg.Alarms != nil {
+		lw = &alarmWriter{LogWriter: lw, alarms: cfg.Alarms}
+	}
+
+	if replaying {
+		lw = &retimeWriter{LogWriter: lw, clock: clock}
+	} else if cfg.CANCapture && cfg.Device != nil && cfg.RemoteMode != 2 {
+		device, err := newCapture(cfg, filename)
+		if err != nil {
+			lw.Close()
+			return nil, "", err
+		}
+		cfg.Device = device
+	}
 
 	if cfg.RemoteMode == 2 {
 		datalogger.IClient, err = NewRemote(cfg, lw)
@@ -109,6 +170,16 @@ func New(cfg Config) (IClient, string, error) {
 		if err != nil {
 			return nil, "", err
 		}
+	case "Z22SE":
+		datalogger.IClient, err = NewZ22SE(cfg, lw)
+		if err != nil {
+			return nil, "", err
+		}
+	case "OBD2":
+		datalogger.IClient, err = NewOBD2(cfg, lw)
+		if err != nil {
+			return nil, "", err
+		}
 	default:
 		return nil, "", fmt.Errorf("%s not supported yet", cfg.ECU)
 	}
@@ -120,5 +191,8 @@ func (d *Client) Start() error {
 	d.cfg.ErrorCounter(0)
 	d.cfg.CaptureCounter(0)
 	d.cfg.FpsCounter(0)
+	if d.cfg.ErrorRateCounter != nil {
+		d.cfg.ErrorRateCounter(0)
+	}
 	return d.IClient.Start()
 }
@@ -1,9 +1,12 @@
 package datalogger
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,24 +14,163 @@ import (
 	"github.com/roffe/txlogger/pkg/common"
 )
 
+// NewWriter creates the logs of the session. With LogFormats a log is written in each format, one after another,
+// and the name of the first log is returned, otherwise a single LogFormat log
 func NewWriter(cfg Config) (string, LogWriter, error) {
-	switch cfg.LogFormat {
+	filename, logs, err := newLogs(cfg)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(logs) == 1 {
+		return filename, logs[0].LogWriter, nil
+	}
+	return filename, multiWriter(logs), nil
+}
+
+// newSessionWriter creates the logs of the session with rotation and the trigger applied. The logs and cfg.Outputs
+// are written through a single FanoutWriter when there is more than one, rotation and the trigger write their
+// formats one after another so no sample is buffered twice. The filename is empty while waiting for the trigger
+func newSessionWriter(cfg Config) (string, LogWriter, error) {
+	var (
+		filename string
+		logs     []Output
+		err      error
+	)
+	rotating := cfg.RotateSize > 0 || cfg.RotateInterval > 0
+	newLog := func() (string, LogWriter, error) {
+		if rotating {
+			return NewRotatingWriter(cfg.RotateSize, cfg.RotateInterval, func() (string, LogWriter, error) {
+				return NewWriter(cfg)
+			}, cfg.OnMessage)
+		}
+		return NewWriter(cfg)
+	}
+	switch {
+	case cfg.Trigger != nil:
+		logs = []Output{{Name: "Log", LogWriter: NewTriggerWriter(*cfg.Trigger, newLog, cfg.OnMessage)}}
+		cfg.OnMessage(fmt.Sprintf("Waiting for trigger, pulls are logged to %s", cfg.LogPath))
+	case rotating:
+		var lw LogWriter
+		if filename, lw, err = newLog(); err != nil {
+			return "", nil, err
+		}
+		logs = []Output{{Name: "Log", LogWriter: lw}}
+		cfg.OnMessage(fmt.Sprintf("Logging to %s", filename))
+	default:
+		if filename, logs, err = newLogs(cfg); err != nil {
+			return "", nil, err
+		}
+		cfg.OnMessage(fmt.Sprintf("Logging to %s", filename))
+	}
+
+	if len(logs) == 1 && len(cfg.Outputs) == 0 {
+		return filename, logs[0].LogWriter, nil
+	}
+	fw := NewFanoutWriter(cfg.OnMessage)
+	for _, o := range append(logs, cfg.Outputs...) {
+		fw.Add(o.Name, o.LogWriter)
+	}
+	return filename, fw, nil
+}
+
+// newLogs creates a log in each of LogFormats, or in LogFormat when empty, and returns the name of the first
+func newLogs(cfg Config) (string, []Output, error) {
+	if len(cfg.LogFormats) == 0 {
+		filename, lw, err := newFormatWriter(cfg, cfg.LogFormat)
+		if err != nil {
+			return "", nil, err
+		}
+		return filename, []Output{{Name: cfg.LogFormat, LogWriter: lw}}, nil
+	}
+	var first string
+	logs := make([]Output, 0, len(cfg.LogFormats))
+	for _, format := range cfg.LogFormats {
+		filename, lw, err := newFormatWriter(cfg, format)
+		if err != nil {
+			multiWriter(logs).Close()
+			return "", nil, fmt.Errorf("%s: %w", format, err)
+		}
+		logs = append(logs, Output{Name: format, LogWriter: lw})
+		if first == "" {
+			first = filename
+			continue
+		}
+		cfg.OnMessage(fmt.Sprintf("Also logging to %s", filename))
+	}
+	return first, logs, nil
+}
+
+// multiWriter writes every sample to several logs in turn
+type multiWriter []Output
+
+func (m multiWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	var errs []error
+	for _, o := range m {
+		if err := o.LogWriter.Write(sysvars, sysvarOrder, vars, ts); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiWriter) Close() error {
+	var errs []error
+	for _, o := range m {
+		if err := o.LogWriter.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newFormatWriter(cfg Config, format string) (string, LogWriter, error) {
+	switch format {
 	case "CSV":
-		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, "csv")
+		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, "csv"+compressionExtension(cfg.Compression))
 		if err != nil {
 			return "", nil, err
 		}
-		return filename, NewCSVWriter(file), nil
+		w, err := compress(file, cfg.Compression)
+		if err != nil {
+			return "", nil, err
+		}
+		cw := NewCSVWriter(w)
+		cw.SetMetadata(cfg.Metadata)
+		return filename, cw, nil
 	case "TXL":
-		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, strings.ToLower(cfg.ECU)+"l")
+		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, strings.ToLower(cfg.ECU)+"l"+compressionExtension(cfg.Compression))
+		if err != nil {
+			return "", nil, err
+		}
+		w, err := compress(file, cfg.Compression)
+		if err != nil {
+			return "", nil, err
+		}
+		tw := NewTXLWriter(w)
+		tw.SetMetadata(cfg.Metadata)
+		return filename, tw, nil
+	case "TXB":
+		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, "txb")
 		if err != nil {
 			return "", nil, err
 		}
-		return filename, NewTXLWriter(file), nil
+		return filename, NewTXBinWriter(file, cfg.ECU), nil
+	case "MLG", "MLG v1":
+		file, filename, err := createLog(cfg.LogPath, cfg.FilenamePrefix, "mlg")
+		if err != nil {
+			return "", nil, err
+		}
+		version := 2
+		if format == "MLG v1" {
+			version = 1
+		}
+		return filename, NewMLGWriter(file, version, cfg.ECU), nil
 	}
-	return "unknown", nil, fmt.Errorf("unknown format: %s", cfg.LogFormat)
+	return "unknown", nil, fmt.Errorf("unknown format: %s", format)
 }
 
+// createLog creates a new log file in path, a counter is added to the name if a log
+// was already started the same second, which happens when logs are rotated by size
 func createLog(path, prefix, extension string) (*os.File, string, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		if err := os.Mkdir(path, 0755); err != nil {
@@ -38,33 +180,104 @@ func createLog(path, prefix, extension string) (*os.File, string, error) {
 		}
 	}
 
-	filename := fmt.Sprintf("%s-%s.%s", strings.ReplaceAll(prefix, ".", "_"), time.Now().Format("2006-01-02_150405"), extension)
-	filename = common.SanitizeFilename(filename)
+	base := fmt.Sprintf("%s-%s", strings.ReplaceAll(prefix, ".", "_"), time.Now().Format("2006-01-02_150405"))
+	for n := 1; ; n++ {
+		filename := base + "." + extension
+		if n > 1 {
+			filename = fmt.Sprintf("%s-%d.%s", base, n, extension)
+		}
+		fullFilename := filepath.Join(path, common.SanitizeFilename(filename))
 
-	fullFilename := filepath.Join(path, filename)
+		file, err := os.OpenFile(fullFilename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to open file: %w", err)
+		}
+		return file, fullFilename, nil
+	}
+}
 
-	file, err := os.OpenFile(fullFilename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to open file: %w", err)
+// sysvarPrecision is how many decimals text logs write val of sysvar k with
+func sysvarPrecision(k string, val float64) int {
+	switch {
+	case val == math.Trunc(val):
+		return 0
+	case k == EXTERNALWBLSYM:
+		return 3
+	case k == GPSLATITUDESYM, k == GPSLONGITUDESYM:
+		return 7
 	}
-	return file, fullFilename, nil
+	return 2
 }
 
-func replaceDot(s string) string {
-	return strings.Replace(s, ".", ",", 1)
+// float32Decimals is how many decimals a float32 keeps for values below 1000, it has about 7 significant digits
+const float32Decimals = 4
+
+// wideSysvar reports if binary logs need more than a float32 for sysvar k, GPS coordinates are logged with 7 decimals.
+// Exported channels are wide when their precision needs it or is unknown so nothing read from a log is rounded
+func wideSysvar(k string, precision map[string]int) bool {
+	if k == GPSLATITUDESYM || k == GPSLONGITUDESYM {
+		return true
+	}
+	p, ok := precision[k]
+	return ok && (p < 0 || p > float32Decimals)
 }
 
-type TXBinWriter struct {
-	file *os.File
+// ExportWriter is implemented by the log writers that can write another log the way it was read
+type ExportWriter interface {
+	// SetExport makes text logs write channels with the decimals in precision, -1 for as many as the value needs.
+	// Channels missing from sysvars are left empty instead of written as 0, older txlogger builds can't read that
+	SetExport(precision map[string]int)
 }
 
-func NewTXBinWriter(f *os.File) *TXBinWriter {
-	return &TXBinWriter{
-		file: f,
+// exportValue formats val of channel k for a log written by SetExport, empty when the channel has no value
+func exportValue(k string, val float64, ok bool, precision map[string]int) string {
+	if !ok {
+		return ""
+	}
+	p, found := precision[k]
+	if !found {
+		p = sysvarPrecision(k, val)
 	}
+	return strconv.FormatFloat(val, 'f', p, 64)
 }
 
-func (t *TXBinWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+func replaceDot(s string) string {
+	return strings.Replace(s, ".", ",", 1)
+}
 
-	return nil
+// NewFileWriter creates filename and returns a writer for the format given by its extension,
+// CSV and TXL logs are compressed when the name ends with .gz or .zst
+func NewFileWriter(filename, ecu string) (LogWriter, error) {
+	compression, name := compressionFromExtension(filename)
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".csv", ".t5l", ".t7l", ".t8l":
+	case ".txb", ".mlg":
+		if compression != "" {
+			return nil, fmt.Errorf("%s logs can't be compressed", ext)
+		}
+	default:
+		return nil, fmt.Errorf("unknown log format: %s", ext)
+	}
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	switch ext {
+	case ".txb":
+		return NewTXBinWriter(file, ecu), nil
+	case ".mlg":
+		return NewMLGWriter(file, 2, ecu), nil
+	}
+	w, err := compress(file, compression)
+	if err != nil {
+		return nil, err
+	}
+	if ext == ".csv" {
+		return NewCSVWriter(w), nil
+	}
+	return NewTXLWriter(w), nil
 }
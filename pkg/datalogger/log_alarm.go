@@ -0,0 +1,26 @@
+package datalogger
+
+import (
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// ALARMSYM counts the alarms fired since txlogger started, a log has an alarm where it increases
+const ALARMSYM = "Alarm"
+
+// alarmWriter adds the alarm count to every sample so alarms can be found in the log
+type alarmWriter struct {
+	LogWriter
+	alarms func() float64
+	order  []string
+}
+
+func (a *alarmWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	sysvars.Set(ALARMSYM, a.alarms())
+	// the order of the logger doesn't change during a session
+	if len(a.order) != len(sysvarOrder)+1 {
+		a.order = append(append(make([]string, 0, len(sysvarOrder)+1), sysvarOrder...), ALARMSYM)
+	}
+	return a.LogWriter.Write(sysvars, a.order, vars, ts)
+}
@@ -0,0 +1,78 @@
+package datalogger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionExtension returns the file extension added for compression
+func compressionExtension(compression string) string {
+	switch strings.ToLower(compression) {
+	case "gzip":
+		return ".gz"
+	case "zstd":
+		return ".zst"
+	}
+	return ""
+}
+
+// compressionFromExtension returns the compression used for filename and the filename without the compression extension
+func compressionFromExtension(filename string) (string, string) {
+	switch {
+	case strings.HasSuffix(strings.ToLower(filename), ".gz"):
+		return "gzip", filename[:len(filename)-3]
+	case strings.HasSuffix(strings.ToLower(filename), ".zst"):
+		return "zstd", filename[:len(filename)-4]
+	}
+	return "", filename
+}
+
+// compressedFile compresses everything written to it into file
+type compressedFile struct {
+	io.WriteCloser
+	file *os.File
+}
+
+func (c *compressedFile) Close() error {
+	if err := c.WriteCloser.Close(); err != nil {
+		c.file.Close()
+		return err
+	}
+	if err := c.file.Sync(); err != nil {
+		c.file.Close()
+		return err
+	}
+	return c.file.Close()
+}
+
+// compress wraps f with the named compression, f is returned as is when compression is empty or None
+func compress(f *os.File, compression string) (io.WriteCloser, error) {
+	switch strings.ToLower(compression) {
+	case "", "none":
+		return f, nil
+	case "gzip":
+		return &compressedFile{WriteCloser: gzip.NewWriter(f), file: f}, nil
+	case "zstd":
+		zw, err := zstd.NewWriter(f, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		return &compressedFile{WriteCloser: zw, file: f}, nil
+	}
+	f.Close()
+	return nil, fmt.Errorf("unknown compression: %s", compression)
+}
+
+// syncFile flushes w to disk if it is a plain file, compressed files are synced on Close
+func syncFile(w io.Writer) error {
+	if f, ok := w.(*os.File); ok {
+		return f.Sync()
+	}
+	return nil
+}
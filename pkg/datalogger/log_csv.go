@@ -2,15 +2,14 @@ package datalogger
 
 import (
 	"encoding/csv"
-	"math"
-	"os"
+	"io"
 	"strconv"
 	"time"
 
 	symbol "github.com/roffe/ecusymbol"
 )
 
-func NewCSVWriter(f *os.File) *CSVWriter {
+func NewCSVWriter(f io.WriteCloser) *CSVWriter {
 	return &CSVWriter{
 		file: f,
 		cw:   csv.NewWriter(f),
@@ -18,10 +17,25 @@ func NewCSVWriter(f *os.File) *CSVWriter {
 }
 
 type CSVWriter struct {
-	file          *os.File
+	file          io.WriteCloser
 	headerWritten bool
 	cw            *csv.Writer
 	precission    int
+	meta          *Metadata
+	export        map[string]int
+}
+
+// SetMetadata sets the metadata written before the header
+func (c *CSVWriter) SetMetadata(m *Metadata) {
+	c.meta = m
+}
+
+// SetExport writes the values of another log, see ExportWriter
+func (c *CSVWriter) SetExport(precision map[string]int) {
+	c.export = precision
+	if c.export == nil {
+		c.export = make(map[string]int)
+	}
 }
 
 func (c *CSVWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
@@ -33,14 +47,13 @@ func (c *CSVWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*
 	var record []string
 	record = append(record, ts.Format(ISONICO))
 	for _, k := range sysvarOrder {
-		val := sysvars.Get(k)
-		if val == math.Trunc(val) {
-			c.precission = 0
-		} else if k == "Lambda.External" {
-			c.precission = 3
-		} else {
-			c.precission = 2
+		if c.export != nil {
+			val, ok := sysvars.Lookup(k)
+			record = append(record, exportValue(k, val, ok, c.export))
+			continue
 		}
+		val := sysvars.Get(k)
+		c.precission = sysvarPrecision(k, val)
 		record = append(record, strconv.FormatFloat(val, 'f', c.precission, 64))
 	}
 	for _, va := range vars {
@@ -53,6 +66,11 @@ func (c *CSVWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*
 }
 
 func (c *CSVWriter) writeHeader(vars []*symbol.Symbol, sysvarOrder []string) error {
+	if c.meta != nil {
+		if err := c.meta.withChannels(sysvarOrder, vars).WriteHeader(c.file); err != nil {
+			return err
+		}
+	}
 	var header []string
 	header = append(header, "Time")
 	header = append(header, sysvarOrder...)
@@ -68,7 +86,11 @@ func (c *CSVWriter) writeHeader(vars []*symbol.Symbol, sysvarOrder []string) err
 
 func (c *CSVWriter) Close() error {
 	c.cw.Flush()
-	if err := c.file.Sync(); err != nil {
+	if err := c.cw.Error(); err != nil {
+		c.file.Close()
+		return err
+	}
+	if err := syncFile(c.file); err != nil {
 		return err
 	}
 	return c.file.Close()
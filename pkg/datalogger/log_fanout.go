@@ -0,0 +1,108 @@
+package datalogger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// how many samples an output can fall behind before samples are dropped for it
+const fanoutBufferSize = 256
+
+// FanoutWriter writes every sample to several outputs, each output is written from its own
+// goroutine so a slow disk or socket can't stall the logger. Output errors are reported
+// through onMessage and never returned, a failing output doesn't end the session
+type FanoutWriter struct {
+	outputs   []*bufferedWriter
+	onMessage func(string)
+}
+
+type bufferedWriter struct {
+	name    string
+	lw      LogWriter
+	samples chan logSample
+	done    chan struct{}
+	dropped int
+}
+
+func NewFanoutWriter(onMessage func(string)) *FanoutWriter {
+	return &FanoutWriter{
+		onMessage: onMessage,
+	}
+}
+
+// Add starts writing to lw, name is used in messages about the output
+func (f *FanoutWriter) Add(name string, lw LogWriter) {
+	o := &bufferedWriter{
+		name:    name,
+		lw:      lw,
+		samples: make(chan logSample, fanoutBufferSize),
+		done:    make(chan struct{}),
+	}
+	f.outputs = append(f.outputs, o)
+	go f.run(o)
+}
+
+func (f *FanoutWriter) run(o *bufferedWriter) {
+	defer close(o.done)
+	var failing bool
+	for s := range o.samples {
+		if err := o.lw.Write(s.sysvars, s.sysvarOrder, s.vars, s.ts); err != nil {
+			// only the first error of a run of failures is reported
+			if !failing {
+				f.onMessage(fmt.Sprintf("%s: %v", o.name, err))
+				failing = true
+			}
+			continue
+		}
+		if failing {
+			f.onMessage(o.name + ": writing again")
+			failing = false
+		}
+	}
+}
+
+func (f *FanoutWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	// the logger keeps updating sysvars and vars, the outputs get a copy
+	s := logSample{
+		sysvars:     sysvars.Clone(),
+		sysvarOrder: slices.Clone(sysvarOrder),
+		vars:        cloneSymbols(vars),
+		ts:          ts,
+	}
+	for _, o := range f.outputs {
+		select {
+		case o.samples <- s:
+			// wait for the buffer to drain to half before saying so, or an output at its limit floods the messages
+			if o.dropped > 0 && len(o.samples) <= fanoutBufferSize/2 {
+				f.onMessage(fmt.Sprintf("%s: caught up, %d samples dropped", o.name, o.dropped))
+				o.dropped = 0
+			}
+		default:
+			if o.dropped == 0 {
+				f.onMessage(o.name + ": can't keep up, dropping samples")
+			}
+			o.dropped++
+		}
+	}
+	return nil
+}
+
+// Close waits for the outputs to write what is buffered and closes them
+func (f *FanoutWriter) Close() error {
+	var errs []error
+	for _, o := range f.outputs {
+		close(o.samples)
+		<-o.done
+		if o.dropped > 0 {
+			f.onMessage(fmt.Sprintf("%s: %d samples dropped", o.name, o.dropped))
+		}
+		if err := o.lw.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", o.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
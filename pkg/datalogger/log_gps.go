@@ -0,0 +1,78 @@
+package datalogger
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/gps"
+)
+
+const (
+	GPSLATITUDESYM  = "GPS.Latitude"
+	GPSLONGITUDESYM = "GPS.Longitude"
+	GPSSPEEDSYM     = "GPS.Speed"
+	GPSHEADINGSYM   = "GPS.Heading"
+	GPSFIXSYM       = "GPS.Fix"
+)
+
+// GPSSymbols are the channels a GPS adds to every sample
+var GPSSymbols = []string{GPSLATITUDESYM, GPSLONGITUDESYM, GPSSPEEDSYM, GPSHEADINGSYM, GPSFIXSYM}
+
+// GPSConfig selects an NMEA receiver, Port is a serial port or host:port, empty when there is none
+type GPSConfig struct {
+	Port     string
+	Baudrate int
+}
+
+// gpsWriter adds the latest GPS fix to every sample. The receiver is opened with the first sample
+// so a logger that fails to start doesn't hold the port
+type gpsWriter struct {
+	LogWriter
+	client    *gps.Client
+	onMessage func(string)
+	order     []string
+
+	startOnce sync.Once
+	cancel    context.CancelFunc
+}
+
+func newGPSWriter(lw LogWriter, cfg GPSConfig, onMessage func(string)) *gpsWriter {
+	return &gpsWriter{
+		LogWriter: lw,
+		client:    gps.NewNMEAClient(cfg.Port, cfg.Baudrate, onMessage),
+		onMessage: onMessage,
+	}
+}
+
+func (g *gpsWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	g.startOnce.Do(func() {
+		var ctx context.Context
+		ctx, g.cancel = context.WithCancel(context.Background())
+		if err := g.client.Start(ctx); err != nil {
+			g.onMessage(err.Error())
+			return
+		}
+		g.onMessage("GPS started")
+	})
+	fix := g.client.Fix()
+	values := [...]float64{fix.Latitude, fix.Longitude, fix.Speed, fix.Heading, float64(fix.Quality)}
+	for i, name := range GPSSymbols {
+		sysvars.Set(name, values[i])
+		ebus.Publish(name, values[i])
+	}
+	if len(g.order) != len(sysvarOrder)+len(GPSSymbols) {
+		g.order = append(append(make([]string, 0, len(sysvarOrder)+len(GPSSymbols)), sysvarOrder...), GPSSymbols...)
+	}
+	return g.LogWriter.Write(sysvars, g.order, vars, ts)
+}
+
+func (g *gpsWriter) Close() error {
+	if g.cancel != nil {
+		g.cancel()
+	}
+	g.client.Stop()
+	return g.LogWriter.Close()
+}
@@ -0,0 +1,304 @@
+package datalogger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+/*
+MegaLogViewer binary log (MLVLG), all numbers are big endian.
+
+Version 1 header:
+
+	file format       [6]byte "MLVLG\0"
+	format version    int16 1
+	timestamp         int32 unix seconds
+	info data start   int16
+	data begin index  int32
+	record length     int16
+	field count       int16
+	fields            55 bytes each
+
+Version 2 widens info data start to int32 and adds a 34 byte category to each field (89 bytes).
+
+Data blocks:
+
+	block type        uint8 0 = data, 1 = marker
+	counter           uint8 rolling
+	timestamp         uint16 rolling, 10us resolution
+	data              record length bytes, or a 50 byte message for markers
+	crc               uint8 sum of data bytes, data blocks only
+*/
+const (
+	MLGFileFormat = "MLVLG\x00"
+
+	MLGFieldNameSize     = 34
+	MLGFieldUnitsSize    = 10
+	MLGFieldCategorySize = 34
+	MLGMarkerSize        = 50
+
+	MLGBlockData   = 0
+	MLGBlockMarker = 1
+)
+
+// MLG field types
+const (
+	MLGTypeU08 = iota
+	MLGTypeS08
+	MLGTypeU16
+	MLGTypeS16
+	MLGTypeU32
+	MLGTypeS32
+	MLGTypeS64
+	MLGTypeF32
+)
+
+// MLGFieldSize returns the size of a field descriptor for the given format version
+func MLGFieldSize(version int) int {
+	if version == 1 {
+		return 55
+	}
+	return 89
+}
+
+// MLGHeaderSize returns the size of the fixed header for the given format version
+func MLGHeaderSize(version int) int {
+	if version == 1 {
+		return 22
+	}
+	return 24
+}
+
+// MLGTypeSize returns the size in bytes of a field type
+func MLGTypeSize(typ uint8) int {
+	switch typ {
+	case MLGTypeU08, MLGTypeS08:
+		return 1
+	case MLGTypeU16, MLGTypeS16:
+		return 2
+	case MLGTypeU32, MLGTypeS32, MLGTypeF32:
+		return 4
+	case MLGTypeS64:
+		return 8
+	}
+	return 0
+}
+
+type MLGWriter struct {
+	file    *os.File
+	w       *bufio.Writer
+	version int
+	ecu     string
+
+	headerWritten bool
+	start         time.Time
+	counter       uint8
+	buf           []byte
+	fields        []mlgField
+	export        map[string]int
+}
+
+// NewMLGWriter creates a writer for MegaLogViewer MLG format version 1 or 2
+func NewMLGWriter(f *os.File, version int, ecu string) *MLGWriter {
+	return &MLGWriter{
+		file:    f,
+		w:       bufio.NewWriterSize(f, 64*1024),
+		version: version,
+		ecu:     ecu,
+	}
+}
+
+// SetExport makes channels missing from sysvars NaN instead of 0 and shows them with the decimals in precision
+func (m *MLGWriter) SetExport(precision map[string]int) {
+	m.export = precision
+	if m.export == nil {
+		m.export = make(map[string]int)
+	}
+}
+
+type mlgField struct {
+	typ      uint8
+	scale    float32
+	name     string
+	units    string
+	digits   int
+	category string
+}
+
+func (m *MLGWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	if !m.headerWritten {
+		if err := m.writeHeader(sysvarOrder, vars, ts); err != nil {
+			return err
+		}
+	}
+
+	elapsed := ts.Sub(m.start)
+
+	m.buf = m.buf[:0]
+	m.buf = append(m.buf, MLGBlockData, m.counter)
+	m.buf = binary.BigEndian.AppendUint16(m.buf, uint16(elapsed.Microseconds()/10))
+	dataStart := len(m.buf)
+	m.buf = binary.BigEndian.AppendUint32(m.buf, uint32(elapsed.Milliseconds()))
+	// fields[0] is the time
+	n := 1
+	for _, k := range sysvarOrder {
+		val, ok := sysvars.Lookup(k)
+		if !ok && m.export != nil {
+			val = math.NaN()
+		}
+		m.appendValue(m.fields[n], val)
+		n++
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		m.appendValue(m.fields[n], va.Float64())
+		n++
+	}
+	var crc uint8
+	for _, b := range m.buf[dataStart:] {
+		crc += b
+	}
+	m.buf = append(m.buf, crc)
+	m.counter++
+
+	_, err := m.w.Write(m.buf)
+	return err
+}
+
+func (m *MLGWriter) appendValue(f mlgField, val float64) {
+	switch f.typ {
+	case MLGTypeS32:
+		m.buf = binary.BigEndian.AppendUint32(m.buf, uint32(int32(mlgRaw(val, f.digits))))
+	case MLGTypeS64:
+		m.buf = binary.BigEndian.AppendUint64(m.buf, uint64(mlgRaw(val, f.digits)))
+	default:
+		m.buf = binary.BigEndian.AppendUint32(m.buf, math.Float32bits(float32(val)))
+	}
+}
+
+// mlgRaw returns val in steps of the last of digits decimals, integers have no NaN so it is written as 0
+func mlgRaw(val float64, digits int) int64 {
+	if math.IsNaN(val) {
+		return 0
+	}
+	return int64(math.Round(val * math.Pow10(digits)))
+}
+
+// mlgValueField returns the field for a channel shown with digits decimals. Channels with more decimals than a
+// float32 keeps are stored as integers in steps of the last decimal, GPS coordinates with 7 decimals fit in an S32
+func mlgValueField(name, units string, digits int) mlgField {
+	f := mlgField{typ: MLGTypeF32, scale: 1, name: name, units: units, digits: digits, category: mlgCategory(name)}
+	switch {
+	case name == GPSLATITUDESYM, name == GPSLONGITUDESYM:
+		f.typ, f.scale, f.digits = MLGTypeS32, 1e-7, 7
+	case digits > float32Decimals:
+		f.typ, f.scale = MLGTypeS64, float32(math.Pow10(-digits))
+	}
+	return f
+}
+
+func (m *MLGWriter) writeHeader(sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	// a float32 of seconds is off by more than a millisecond after a few hours, milliseconds last 49 days
+	fields := []mlgField{{typ: MLGTypeU32, scale: 0.001, name: "Time", units: "s", digits: 3}}
+	for _, k := range sysvarOrder {
+		digits := 2
+		if k == EXTERNALWBLSYM {
+			digits = 3
+		}
+		if p, ok := m.export[k]; ok && p >= 0 {
+			digits = p
+		}
+		fields = append(fields, mlgValueField(k, "", digits))
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		fields = append(fields, mlgValueField(va.Name, va.Unit, symbol.GetPrecision(va.Correctionfactor)))
+	}
+	if len(fields) > math.MaxInt16 {
+		return errors.New("too many channels for MLG log")
+	}
+
+	info := []byte("Logged with txlogger, ECU: " + m.ecu + "\x00")
+	infoStart := MLGHeaderSize(m.version) + len(fields)*MLGFieldSize(m.version)
+	dataBegin := infoStart + len(info)
+	var recordLength int
+	for _, f := range fields {
+		recordLength += MLGTypeSize(f.typ)
+	}
+
+	hdr := []byte(MLGFileFormat)
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(m.version))
+	hdr = binary.BigEndian.AppendUint32(hdr, uint32(ts.Unix()))
+	if m.version == 1 {
+		if infoStart > math.MaxUint16 {
+			return errors.New("too many channels for MLG v1 log")
+		}
+		hdr = binary.BigEndian.AppendUint16(hdr, uint16(infoStart))
+	} else {
+		hdr = binary.BigEndian.AppendUint32(hdr, uint32(infoStart))
+	}
+	hdr = binary.BigEndian.AppendUint32(hdr, uint32(dataBegin))
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(recordLength))
+	hdr = binary.BigEndian.AppendUint16(hdr, uint16(len(fields)))
+
+	for _, f := range fields {
+		hdr = append(hdr, f.typ)
+		hdr = appendFixedString(hdr, f.name, MLGFieldNameSize)
+		hdr = appendFixedString(hdr, f.units, MLGFieldUnitsSize)
+		hdr = append(hdr, 0) // display style float
+		hdr = binary.BigEndian.AppendUint32(hdr, math.Float32bits(f.scale))
+		hdr = binary.BigEndian.AppendUint32(hdr, math.Float32bits(0))
+		hdr = append(hdr, uint8(int8(f.digits)))
+		if m.version != 1 {
+			hdr = appendFixedString(hdr, f.category, MLGFieldCategorySize)
+		}
+	}
+	hdr = append(hdr, info...)
+
+	m.fields = fields
+	m.start = ts
+	m.headerWritten = true
+	_, err := m.w.Write(hdr)
+	return err
+}
+
+// mlgCategory groups symbols by their prefix, ActualIn.n_Engine ends up in ActualIn
+func mlgCategory(name string) string {
+	if idx := strings.IndexByte(name, '.'); idx > 0 {
+		return name[:idx]
+	}
+	return ""
+}
+
+// appendFixedString appends s null padded or truncated to size bytes
+func appendFixedString(b []byte, s string, size int) []byte {
+	if len(s) > size {
+		s = s[:size]
+	}
+	b = append(b, s...)
+	for range size - len(s) {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func (m *MLGWriter) Close() error {
+	if err := m.w.Flush(); err != nil {
+		return err
+	}
+	if err := m.file.Sync(); err != nil {
+		return err
+	}
+	return m.file.Close()
+}
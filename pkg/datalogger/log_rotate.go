@@ -0,0 +1,106 @@
+package datalogger
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// how often the size of the current log is checked
+const rotateCheckInterval = time.Second
+
+// RotatingWriter starts a new log when the current one reaches a size or age
+type RotatingWriter struct {
+	maxSize   int64
+	maxAge    time.Duration
+	newWriter func() (string, LogWriter, error)
+	onMessage func(string)
+
+	lw        LogWriter
+	filename  string
+	started   time.Time
+	lastCheck time.Time
+}
+
+// NewRotatingWriter creates the first log with newWriter and returns its filename.
+// A maxSize or maxAge of 0 disables that limit, the size is what has reached the disk
+// so buffered and compressed output rotates a bit later than maxSize.
+func NewRotatingWriter(maxSize int64, maxAge time.Duration, newWriter func() (string, LogWriter, error), onMessage func(string)) (string, *RotatingWriter, error) {
+	r := &RotatingWriter{
+		maxSize:   maxSize,
+		maxAge:    maxAge,
+		newWriter: newWriter,
+		onMessage: onMessage,
+	}
+	if err := r.open(); err != nil {
+		return "", nil, err
+	}
+	return r.filename, r, nil
+}
+
+func (r *RotatingWriter) open() error {
+	filename, lw, err := r.newWriter()
+	if err != nil {
+		return err
+	}
+	r.filename = filename
+	r.lw = lw
+	r.started = time.Now()
+	r.lastCheck = r.started
+	return nil
+}
+
+func (r *RotatingWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	if r.lw == nil {
+		if err := r.open(); err != nil {
+			return err
+		}
+	}
+	if err := r.lw.Write(sysvars, sysvarOrder, vars, ts); err != nil {
+		return err
+	}
+	if r.shouldRotate() {
+		return r.rotate()
+	}
+	return nil
+}
+
+func (r *RotatingWriter) shouldRotate() bool {
+	now := time.Now()
+	if r.maxAge > 0 && now.Sub(r.started) >= r.maxAge {
+		return true
+	}
+	if r.maxSize > 0 && now.Sub(r.lastCheck) >= rotateCheckInterval {
+		r.lastCheck = now
+		if fi, err := os.Stat(r.filename); err == nil && fi.Size() >= r.maxSize {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate closes the current log and starts the next one, if that fails it is retried on the next write
+func (r *RotatingWriter) rotate() error {
+	lw := r.lw
+	r.lw = nil
+	if err := lw.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", r.filename, err)
+	}
+	r.onMessage("Log rotated, closed " + r.filename)
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.onMessage("Logging to " + r.filename)
+	return nil
+}
+
+func (r *RotatingWriter) Close() error {
+	if r.lw == nil {
+		return nil
+	}
+	lw := r.lw
+	r.lw = nil
+	return lw.Close()
+}
@@ -0,0 +1,81 @@
+package datalogger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+type countingWriter struct {
+	writes int
+	closes int
+}
+
+func (c *countingWriter) Write(*ThreadSafeMap, []string, []*symbol.Symbol, time.Time) error {
+	c.writes++
+	return nil
+}
+
+func (c *countingWriter) Close() error {
+	c.closes++
+	return nil
+}
+
+func TestSessionWriterOutputsWithRotation(t *testing.T) {
+	dir := t.TempDir()
+	out := &countingWriter{}
+	cfg := Config{
+		ECU:            "T7",
+		LogFormats:     []string{"CSV", "TXB"},
+		LogPath:        dir,
+		FilenamePrefix: "test",
+		// every sample rotates the logs
+		RotateInterval: time.Nanosecond,
+		Outputs:        []Output{{Name: "Telemetry", LogWriter: out}},
+		OnMessage:      func(string) {},
+	}
+	_, lw, err := newSessionWriter(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw, ok := lw.(*FanoutWriter)
+	if !ok {
+		t.Fatalf("got %T, want *FanoutWriter", lw)
+	}
+	if len(fw.outputs) != 2 {
+		t.Fatalf("got %d fanout outputs, want the log and the output", len(fw.outputs))
+	}
+	for _, o := range fw.outputs {
+		if _, nested := o.lw.(*FanoutWriter); nested {
+			t.Fatalf("%s is a FanoutWriter inside the FanoutWriter", o.name)
+		}
+	}
+
+	const samples = 3
+	sysvars := NewThreadSafeMap()
+	for i := range samples {
+		sysvars.Set("x", float64(i))
+		if err := lw.Write(sysvars, []string{"x"}, nil, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.writes != samples || out.closes != 1 {
+		t.Errorf("output got %d writes and %d closes, want %d and 1", out.writes, out.closes, samples)
+	}
+	// the logs opened by the last rotation are empty
+	for _, pattern := range []string{"*.csv", "*.txb"} {
+		files, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != samples+1 {
+			t.Errorf("got %d %s logs, want %d", len(files), pattern, samples+1)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package datalogger
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// Trigger makes the logger only write pulls to disk
+type Trigger struct {
+	// Condition starts a pull when it returns true, get returns the current value of a channel
+	Condition func(ts time.Time, get func(name string) (float64, bool)) bool
+	// PreTrigger is how much of the log before the trigger is included in the pull
+	PreTrigger time.Duration
+	// Hold is how long the pull continues after the condition stops matching
+	Hold time.Duration
+}
+
+type logSample struct {
+	sysvars     *ThreadSafeMap
+	sysvarOrder []string
+	vars        []*symbol.Symbol
+	ts          time.Time
+}
+
+// TriggerWriter keeps the last PreTrigger of samples in memory and only writes to a log
+// while the trigger is active, every pull is written to a new file
+type TriggerWriter struct {
+	trigger   Trigger
+	newWriter func() (string, LogWriter, error)
+	onMessage func(string)
+
+	buffer []logSample
+
+	lw        LogWriter
+	filename  string
+	lastMatch time.Time
+	pulls     int
+}
+
+// NewTriggerWriter creates a TriggerWriter, newWriter is called to create the log for each pull
+func NewTriggerWriter(trigger Trigger, newWriter func() (string, LogWriter, error), onMessage func(string)) *TriggerWriter {
+	return &TriggerWriter{
+		trigger:   trigger,
+		newWriter: newWriter,
+		onMessage: onMessage,
+	}
+}
+
+func (t *TriggerWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	match := t.trigger.Condition(ts, lookup(sysvars, vars))
+
+	if t.lw == nil {
+		if !match {
+			t.buffer = append(t.buffer, logSample{
+				sysvars:     sysvars.Clone(),
+				sysvarOrder: sysvarOrder,
+				vars:        cloneSymbols(vars),
+				ts:          ts,
+			})
+			t.trim(ts)
+			return nil
+		}
+		if err := t.startPull(); err != nil {
+			return err
+		}
+	}
+
+	if match {
+		t.lastMatch = ts
+	}
+	if err := t.lw.Write(sysvars, sysvarOrder, vars, ts); err != nil {
+		return err
+	}
+	if !match && ts.Sub(t.lastMatch) >= t.trigger.Hold {
+		return t.endPull()
+	}
+	return nil
+}
+
+func (t *TriggerWriter) startPull() error {
+	filename, lw, err := t.newWriter()
+	if err != nil {
+		return err
+	}
+	t.lw = lw
+	t.filename = filename
+	t.pulls++
+	t.onMessage(fmt.Sprintf("Trigger %d, logging to %s", t.pulls, filename))
+	for _, s := range t.buffer {
+		if err := t.lw.Write(s.sysvars, s.sysvarOrder, s.vars, s.ts); err != nil {
+			return err
+		}
+	}
+	t.buffer = t.buffer[:0]
+	return nil
+}
+
+func (t *TriggerWriter) endPull() error {
+	lw := t.lw
+	t.lw = nil
+	if err := lw.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", t.filename, err)
+	}
+	t.onMessage("Trigger released, closed " + t.filename)
+	return nil
+}
+
+// trim drops samples older than PreTrigger before ts
+func (t *TriggerWriter) trim(ts time.Time) {
+	cutoff := ts.Add(-t.trigger.PreTrigger)
+	n := 0
+	for n < len(t.buffer) && t.buffer[n].ts.Before(cutoff) {
+		n++
+	}
+	if n > 0 {
+		t.buffer = append(t.buffer[:0], t.buffer[n:]...)
+	}
+}
+
+func (t *TriggerWriter) Close() error {
+	t.buffer = nil
+	if t.lw == nil {
+		return nil
+	}
+	return t.endPull()
+}
+
+func lookup(sysvars *ThreadSafeMap, vars []*symbol.Symbol) func(string) (float64, bool) {
+	return func(name string) (float64, bool) {
+		if sysvars.Exists(name) {
+			return sysvars.Get(name), true
+		}
+		for _, va := range vars {
+			if va.Name == name && va.Number >= 0 {
+				return va.Float64(), true
+			}
+		}
+		return 0, false
+	}
+}
+
+// cloneSymbols copies the symbols and their current data so the logger can keep reading into the originals
+func cloneSymbols(vars []*symbol.Symbol) []*symbol.Symbol {
+	out := make([]*symbol.Symbol, len(vars))
+	for i, va := range vars {
+		c := &symbol.Symbol{
+			Name:             va.Name,
+			Number:           va.Number,
+			SramOffset:       va.SramOffset,
+			Address:          va.Address,
+			Length:           va.Length,
+			Mask:             va.Mask,
+			Type:             va.Type,
+			ExtendedType:     va.ExtendedType,
+			Correctionfactor: va.Correctionfactor,
+			Unit:             va.Unit,
+		}
+		if data := va.Bytes(); len(data) > 0 {
+			_ = c.Read(bytes.NewReader(bytes.Clone(data)))
+		}
+		out[i] = c
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package datalogger
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+/*
+TXB binary log format, all numbers are little endian
+
+Header:
+
+	magic             [4]byte "TXB1"
+	ecu               string
+	start time        int64 unix milliseconds
+	channel count     uint16
+	channels          name string, unit string, correction factor float64, type uint8
+
+Strings are stored as a uint8 length followed by the bytes.
+
+Records follow the header until EOF and are fixed width:
+
+	timestamp         uint32 milliseconds since start time
+	values            per channel in header order, NaN when the channel has no value
+
+Channels are float32 unless more decimals are needed than it keeps, like GPS coordinates, which are float64.
+*/
+const (
+	TXBMagic = "TXB1"
+)
+
+// TXB channel types
+const (
+	TXBTypeF32 = iota
+	TXBTypeF64
+)
+
+// TXBTypeSize returns the size in bytes of a channel type
+func TXBTypeSize(typ uint8) int {
+	switch typ {
+	case TXBTypeF32:
+		return 4
+	case TXBTypeF64:
+		return 8
+	}
+	return 0
+}
+
+type TXBinWriter struct {
+	file *os.File
+	w    *bufio.Writer
+	ecu  string
+
+	headerWritten bool
+	start         time.Time
+	buf           []byte
+	types         []uint8
+	export        map[string]int
+}
+
+func NewTXBinWriter(f *os.File, ecu string) *TXBinWriter {
+	return &TXBinWriter{
+		file: f,
+		w:    bufio.NewWriterSize(f, 64*1024),
+		ecu:  ecu,
+	}
+}
+
+// SetExport makes channels missing from sysvars NaN instead of 0, channels with more decimals in precision than
+// a float32 keeps are stored as float64
+func (t *TXBinWriter) SetExport(precision map[string]int) {
+	t.export = precision
+	if t.export == nil {
+		t.export = make(map[string]int)
+	}
+}
+
+func (t *TXBinWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	if !t.headerWritten {
+		if err := t.writeHeader(sysvarOrder, vars, ts); err != nil {
+			return err
+		}
+	}
+
+	t.buf = t.buf[:0]
+	t.buf = binary.LittleEndian.AppendUint32(t.buf, uint32(ts.Sub(t.start).Milliseconds()))
+	var n int
+	for _, k := range sysvarOrder {
+		val, ok := sysvars.Lookup(k)
+		if !ok && t.export != nil {
+			val = math.NaN()
+		}
+		t.appendValue(t.types[n], val)
+		n++
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		t.appendValue(t.types[n], va.Float64())
+		n++
+	}
+	_, err := t.w.Write(t.buf)
+	return err
+}
+
+func (t *TXBinWriter) appendValue(typ uint8, val float64) {
+	if typ == TXBTypeF64 {
+		t.buf = binary.LittleEndian.AppendUint64(t.buf, math.Float64bits(val))
+		return
+	}
+	t.buf = binary.LittleEndian.AppendUint32(t.buf, math.Float32bits(float32(val)))
+}
+
+func (t *TXBinWriter) writeHeader(sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	t.types = t.types[:0]
+	hdr := []byte(TXBMagic)
+	hdr = appendTXBString(hdr, t.ecu)
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(ts.UnixMilli()))
+	countPos := len(hdr)
+	hdr = binary.LittleEndian.AppendUint16(hdr, 0)
+	for _, k := range sysvarOrder {
+		hdr = appendTXBString(hdr, k)
+		hdr = appendTXBString(hdr, "")
+		hdr = binary.LittleEndian.AppendUint64(hdr, math.Float64bits(1))
+		typ := uint8(TXBTypeF32)
+		if wideSysvar(k, t.export) {
+			typ = TXBTypeF64
+		}
+		hdr = append(hdr, typ)
+		t.types = append(t.types, typ)
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		hdr = appendTXBString(hdr, va.Name)
+		hdr = appendTXBString(hdr, va.Unit)
+		hdr = binary.LittleEndian.AppendUint64(hdr, math.Float64bits(va.Correctionfactor))
+		typ := uint8(TXBTypeF32)
+		if symbol.GetPrecision(va.Correctionfactor) > float32Decimals {
+			typ = TXBTypeF64
+		}
+		hdr = append(hdr, typ)
+		t.types = append(t.types, typ)
+	}
+	if len(t.types) > math.MaxUint16 {
+		return errors.New("too many channels for TXB log")
+	}
+	binary.LittleEndian.PutUint16(hdr[countPos:], uint16(len(t.types)))
+
+	t.start = ts
+	t.headerWritten = true
+	_, err := t.w.Write(hdr)
+	return err
+}
+
+func appendTXBString(b []byte, s string) []byte {
+	if len(s) > math.MaxUint8 {
+		s = s[:math.MaxUint8]
+	}
+	b = append(b, uint8(len(s)))
+	return append(b, s...)
+}
+
+func (t *TXBinWriter) Close() error {
+	if err := t.w.Flush(); err != nil {
+		return err
+	}
+	if err := t.file.Sync(); err != nil {
+		return err
+	}
+	return t.file.Close()
+}
@@ -1,39 +1,65 @@
 package datalogger
 
 import (
-	"math"
-	"os"
+	"io"
 	"strconv"
 	"time"
 
 	symbol "github.com/roffe/ecusymbol"
 )
 
-func NewTXLWriter(f *os.File) *TXWriter {
+func NewTXLWriter(f io.WriteCloser) *TXWriter {
 	return &TXWriter{
 		file: f,
 	}
 }
 
 type TXWriter struct {
-	file       *os.File
-	precission int
+	file          io.WriteCloser
+	precission    int
+	meta          *Metadata
+	export        map[string]int
+	headerWritten bool
+}
+
+// SetMetadata sets the metadata written before the first record
+func (t *TXWriter) SetMetadata(m *Metadata) {
+	t.meta = m
+}
+
+// SetExport writes the values of another log, see ExportWriter
+func (t *TXWriter) SetExport(precision map[string]int) {
+	t.export = precision
+	if t.export == nil {
+		t.export = make(map[string]int)
+	}
 }
 
 func (t *TXWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	if !t.headerWritten {
+		t.headerWritten = true
+		if t.meta != nil {
+			if err := t.meta.withChannels(sysvarOrder, vars).WriteHeader(t.file); err != nil {
+				return err
+			}
+		}
+	}
 	_, err := t.file.Write([]byte(ts.Format("02-01-2006 15:04:05.999") + "|"))
 	if err != nil {
 		return err
 	}
 	for _, k := range sysvarOrder {
-		val := sysvars.Get(k)
-		if val == math.Trunc(val) {
-			t.precission = 0
-		} else if k == "Lambda.External" {
-			t.precission = 3
-		} else {
-			t.precission = 2
+		if t.export != nil {
+			// channels without a value are left out of the record
+			if val, ok := sysvars.Lookup(k); ok {
+				if _, err := t.file.Write([]byte(k + "=" + replaceDot(exportValue(k, val, ok, t.export)) + "|")); err != nil {
+					return err
+				}
+			}
+			continue
 		}
+		val := sysvars.Get(k)
+		t.precission = sysvarPrecision(k, val)
 		if _, err := t.file.Write([]byte(k + "=" + replaceDot(strconv.FormatFloat(val, 'f', t.precission, 64)) + "|")); err != nil {
 			return err
 		}
@@ -51,7 +77,7 @@ func (t *TXWriter) Write(sysvars *ThreadSafeMap, sysvarOrder []string, vars []*s
 }
 
 func (t *TXWriter) Close() error {
-	if err := t.file.Sync(); err != nil {
+	if err := syncFile(t.file); err != nil {
 		return err
 	}
 	return t.file.Close()
@@ -0,0 +1,170 @@
+package datalogger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// MetadataPrefix starts every metadata line at the top of CSV and TXL logs
+const MetadataPrefix = "#"
+
+// Metadata describes where a log came from, the CSV and TXL writers write it as a header
+type Metadata struct {
+	Version   string
+	ECU       string
+	VIN       string
+	Binary    string
+	BinaryMD5 string
+	Adapter   string
+	Rate      int
+	Wideband  string
+	Channels  []ChannelMetadata
+}
+
+// ChannelMetadata is the unit and correction factor of a logged channel
+type ChannelMetadata struct {
+	Name             string
+	Unit             string
+	Correctionfactor float64
+}
+
+// MetadataWriter is implemented by the log writers that write Metadata
+type MetadataWriter interface {
+	SetMetadata(m *Metadata)
+}
+
+// Channel returns the metadata of the channel name
+func (m *Metadata) Channel(name string) (ChannelMetadata, bool) {
+	for _, ch := range m.Channels {
+		if ch.Name == name {
+			return ch, true
+		}
+	}
+	return ChannelMetadata{}, false
+}
+
+// Fields returns the non empty metadata as name and value pairs, channels excluded
+func (m *Metadata) Fields() [][2]string {
+	var fields [][2]string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{name, value})
+		}
+	}
+	add("txlogger", m.Version)
+	add("ECU", m.ECU)
+	add("VIN", m.VIN)
+	add("Binary", m.Binary)
+	add("Binary MD5", m.BinaryMD5)
+	add("Adapter", m.Adapter)
+	if m.Rate > 0 {
+		add("Rate", strconv.Itoa(m.Rate))
+	}
+	add("Wideband", m.Wideband)
+	return fields
+}
+
+// WriteHeader writes the metadata as lines starting with MetadataPrefix
+func (m *Metadata) WriteHeader(w io.Writer) error {
+	var sb strings.Builder
+	for _, f := range m.Fields() {
+		sb.WriteString(MetadataPrefix + " " + f[0] + ": " + oneLine(f[1]) + "\n")
+	}
+	for _, ch := range m.Channels {
+		sb.WriteString(MetadataPrefix + " Channel: " + ch.Name + "; " + oneLine(ch.Unit) + "; " + strconv.FormatFloat(ch.Correctionfactor, 'g', -1, 64) + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ParseLine reads a line written by WriteHeader into m, false is returned if it is not a metadata line
+func (m *Metadata) ParseLine(line string) bool {
+	line, ok := strings.CutPrefix(strings.TrimSpace(line), MetadataPrefix)
+	if !ok {
+		return false
+	}
+	name, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return true
+	}
+	value = strings.TrimSpace(value)
+	switch strings.TrimSpace(name) {
+	case "txlogger":
+		m.Version = value
+	case "ECU":
+		m.ECU = value
+	case "VIN":
+		m.VIN = value
+	case "Binary":
+		m.Binary = value
+	case "Binary MD5":
+		m.BinaryMD5 = value
+	case "Adapter":
+		m.Adapter = value
+	case "Rate":
+		m.Rate, _ = strconv.Atoi(value)
+	case "Wideband":
+		m.Wideband = value
+	case "Channel":
+		parts := strings.Split(value, ";")
+		ch := ChannelMetadata{Name: strings.TrimSpace(parts[0]), Correctionfactor: 1}
+		if len(parts) > 1 {
+			ch.Unit = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(parts[2]), 64); err == nil {
+				ch.Correctionfactor = f
+			}
+		}
+		m.Channels = append(m.Channels, ch)
+	}
+	return true
+}
+
+// withChannels returns a copy of m with the channels of a log written with sysvarOrder and vars
+func (m *Metadata) withChannels(sysvarOrder []string, vars []*symbol.Symbol) *Metadata {
+	out := *m
+	out.Channels = make([]ChannelMetadata, 0, len(sysvarOrder)+len(vars))
+	for _, name := range sysvarOrder {
+		ch := ChannelMetadata{Name: name, Correctionfactor: 1}
+		if c, ok := m.Channel(name); ok {
+			ch = c
+		} else if name == EXTERNALWBLSYM {
+			ch.Unit = "λ"
+		}
+		out.Channels = append(out.Channels, ch)
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		out.Channels = append(out.Channels, ChannelMetadata{
+			Name:             va.Name,
+			Unit:             va.Unit,
+			Correctionfactor: va.Correctionfactor,
+		})
+	}
+	return &out
+}
+
+// WidebandDescription describes the wideband setup of cfg, empty when no wideband is used
+func WidebandDescription(cfg WidebandConfig) string {
+	switch cfg.Type {
+	case "", "None":
+		return ""
+	case "ECU":
+		return fmt.Sprintf("ECU, %.2f-%.2f V = %.2f-%.2f λ", cfg.MinimumVoltageWideband, cfg.MaximumVoltageWideband, cfg.Low, cfg.High)
+	}
+	if cfg.Port == "" {
+		return cfg.Type
+	}
+	return cfg.Type + " on " + cfg.Port
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,129 @@
+package datalogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/obd2"
+)
+
+var errOBD2NoRAM = errors.New("RAM access is not available over OBD-II")
+
+type OBD2Client struct {
+	*BaseLogger
+}
+
+func NewOBD2(cfg Config, lw LogWriter) (IClient, error) {
+	return &OBD2Client{BaseLogger: NewBaseLogger(cfg, lw)}, nil
+}
+
+func (c *OBD2Client) Start() error {
+	defer c.secondTicker.Stop()
+	defer c.lw.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventHandler := func(e gocan.Event) {
+		c.OnMessage(e.String())
+		if e.Type == gocan.EventTypeError {
+			c.onError()
+		}
+	}
+
+	cl, err := gocan.NewWithOpts(ctx, c.Device, gocan.WithEventHandler(eventHandler))
+	if err != nil {
+		return fmt.Errorf("failed to create obd2 client: %w", err)
+	}
+	defer cl.Close()
+
+	if err := c.setupWBL(ctx, cl); err != nil {
+		return err
+	}
+
+	ob := obd2.New(cl)
+
+	supported, err := ob.SupportedPIDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read supported PIDs: %w", err)
+	}
+	c.OnMessage(fmt.Sprintf("ECU supports %d PIDs", len(supported)))
+
+	var symbols []*symbol.Symbol
+	var order []string
+	for _, sym := range c.Symbols {
+		if sym.Number < 0 || sym.Number > 0xFF || !supported[byte(sym.Number)] {
+			c.OnMessage(sym.Name + " is not supported by the ECU, skipping")
+			continue
+		}
+		symbols = append(symbols, sym)
+		order = append(order, sym.Name)
+	}
+
+	if c.lamb != nil {
+		defer c.lamb.Stop()
+		order = append(order, EXTERNALWBLSYM)
+	}
+
+	interval := time.Second / time.Duration(c.Rate)
+	t := time.NewTicker(c.pollInterval(interval))
+	defer t.Stop()
+
+	go func() {
+		defer cl.Close()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.quitChan:
+				c.OnMessage("Stopped logging..")
+				return
+			case <-c.secondTicker.C:
+				changed, ok := c.everySecond()
+				if !ok {
+					c.OnMessage("too many errors, aborting logging")
+					return
+				}
+				if changed {
+					t.Reset(c.pollInterval(interval))
+				}
+			case read := <-c.readChan:
+				read.Complete(errOBD2NoRAM)
+			case write := <-c.writeChan:
+				write.Complete(errOBD2NoRAM)
+			case <-t.C:
+				ts := time.Now()
+				for _, sym := range symbols {
+					start := time.Now()
+					val, err := ob.ReadPID(ctx, byte(sym.Number))
+					c.pollDone(start)
+					if err != nil {
+						c.onError()
+						c.OnMessage(err.Error())
+						continue
+					}
+					c.sysvars.Set(sym.Name, val)
+					ebus.Publish(sym.Name, val)
+				}
+
+				if c.lamb != nil {
+					lambda := c.lamb.GetLambda()
+					c.sysvars.Set(EXTERNALWBLSYM, lambda)
+					ebus.Publish(EXTERNALWBLSYM, lambda)
+				}
+
+				if err := c.lw.Write(c.sysvars, order, []*symbol.Symbol{}, ts); err != nil {
+					c.onError()
+					c.OnMessage("failed to write log: " + err.Error())
+				}
+				c.onCapture()
+			}
+		}
+	}()
+	return cl.Wait(ctx)
+}
@@ -0,0 +1,128 @@
+package datalogger
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// RateClass is how often a symbol is polled compared to the logging rate, symbols without a class are polled at normal rate
+type RateClass string
+
+const (
+	RateFast   RateClass = "Fast"
+	RateNormal RateClass = "Normal"
+	RateSlow   RateClass = "Slow"
+)
+
+// RateClasses lists the rate classes in the order they are read
+var RateClasses = []RateClass{RateFast, RateNormal, RateSlow}
+
+const (
+	// fastMultiplier is how many times faster than the logging rate fast symbols are polled when groups are read on their own
+	fastMultiplier = 4
+	// slowDivider is how many times slower than the logging rate slow symbols are polled
+	slowDivider = 10
+)
+
+// symbolGroup is the symbols of one rate class, updated every n ticks
+type symbolGroup struct {
+	class   RateClass
+	every   int
+	symbols []*symbol.Symbol
+	size    uint16
+}
+
+// due reports if the group is read on tick, every group is read on the first tick so slow symbols have a value from the start
+func (g *symbolGroup) due(tick int) bool {
+	return tick%g.every == 0
+}
+
+// newSymbolGroups splits symbols into groups by rate class, empty groups are left out. The returned interval is the
+// tick of the fastest group, normal symbols are still read at rate. For loggers reading each group on its own
+func newSymbolGroups(symbols []*symbol.Symbol, classes map[string]RateClass, rate int) ([]*symbolGroup, time.Duration) {
+	return groupSymbols(symbols, classes, rate, fastMultiplier)
+}
+
+// newRegisterGroups groups symbols like newSymbolGroups for loggers reading every group in one dynamic register.
+// The register is read whole on every tick, so polling faster would only add bus load: the tick stays at rate, fast
+// symbols are read as often as normal ones and slow symbols are decoded every slowDivider ticks
+func newRegisterGroups(symbols []*symbol.Symbol, classes map[string]RateClass, rate int) ([]*symbolGroup, time.Duration) {
+	return groupSymbols(symbols, classes, rate, 1)
+}
+
+func groupSymbols(symbols []*symbol.Symbol, classes map[string]RateClass, rate, multiplier int) ([]*symbolGroup, time.Duration) {
+	groups := make(map[RateClass]*symbolGroup)
+	for _, sym := range symbols {
+		class := classes[sym.Name]
+		if class != RateFast && class != RateSlow {
+			class = RateNormal
+		}
+		g, ok := groups[class]
+		if !ok {
+			g = &symbolGroup{class: class}
+			groups[class] = g
+		}
+		g.symbols = append(g.symbols, sym)
+		g.size += sym.Length
+	}
+
+	normalEvery := 1
+	if _, ok := groups[RateFast]; ok {
+		normalEvery = multiplier
+	}
+
+	var out []*symbolGroup
+	for _, class := range RateClasses {
+		g, ok := groups[class]
+		if !ok {
+			continue
+		}
+		switch class {
+		case RateFast:
+			g.every = 1
+		case RateNormal:
+			g.every = normalEvery
+		case RateSlow:
+			g.every = normalEvery * slowDivider
+		}
+		out = append(out, g)
+	}
+	return out, time.Second / time.Duration(rate*normalEvery)
+}
+
+// registerSymbols returns the symbols of all groups in the order they are defined in the dynamic register and their
+// total size in bytes
+func registerSymbols(groups []*symbolGroup) ([]*symbol.Symbol, uint16) {
+	var symbols []*symbol.Symbol
+	var size uint16
+	for _, g := range groups {
+		symbols = append(symbols, g.symbols...)
+		size += g.size
+	}
+	return symbols, size
+}
+
+// readRegister decodes a read of the dynamic register holding every group. T7 and T8 have the one register, so rate
+// classes skip symbols rather than read them less often: symbols of groups not due on tick are passed over and keep
+// their last value, publish is called for the others
+func readRegister(r *bytes.Reader, groups []*symbolGroup, tick int, publish func(*symbol.Symbol)) error {
+	for _, g := range groups {
+		due := g.due(tick)
+		for _, sym := range g.symbols {
+			if !due {
+				if _, err := r.Seek(int64(sym.Length), io.SeekCurrent); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := sym.Read(r); err != nil {
+				return err
+			}
+			publish(sym)
+		}
+	}
+	return nil
+}
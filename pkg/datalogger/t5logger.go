@@ -41,7 +41,8 @@ func (c *T5Client) Start() error {
 	}
 	defer cl.Close()
 
-	t := time.NewTicker(time.Second / time.Duration(c.Rate))
+	interval := time.Second / time.Duration(c.Rate)
+	t := time.NewTicker(c.pollInterval(interval))
 	defer t.Stop()
 	t5 := t5can.NewClient(cl)
 
@@ -75,12 +76,14 @@ func (c *T5Client) Start() error {
 				c.OnMessage("Stopped logging..")
 				return
 			case <-c.secondTicker.C:
-				c.FpsCounter(c.capturePerSecond)
-				if c.errPerSecond > 5 {
+				changed, ok := c.everySecond()
+				if !ok {
 					c.OnMessage("too many errors, aborting logging")
 					return
 				}
-				c.resetPerSecond()
+				if changed {
+					t.Reset(c.pollInterval(interval))
+				}
 			case read := <-c.readChan:
 				data, err := t5.ReadRam(ctx, read.Address, read.Length)
 				if err != nil {
@@ -99,7 +102,9 @@ func (c *T5Client) Start() error {
 			case <-t.C:
 				ts := time.Now()
 				for _, sym := range c.Symbols {
+					start := time.Now()
 					resp, err := t5.ReadRam(ctx, sym.SramOffset, uint32(sym.Length))
+					c.pollDone(start)
 					if err != nil {
 						c.onError()
 						c.OnMessage(err.Error())
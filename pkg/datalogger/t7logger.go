@@ -138,20 +138,50 @@ func (c *T7Client) Start() error {
 
 	adConverter := newDisplProtADConverterT7(c.WidebandConfig)
 
-	if err := initT7logging(ctx, kwp, c.Symbols, c.OnMessage); err != nil {
+	var ecuSymbols []*symbol.Symbol
+	for _, sym := range c.Symbols {
+		if sym.Number >= 0 {
+			ecuSymbols = append(ecuSymbols, sym)
+		}
+	}
+	groups, interval := newRegisterGroups(ecuSymbols, c.RateClasses, c.Rate)
+	ecuSymbols, size := registerSymbols(groups)
+
+	if err := initT7logging(ctx, kwp, ecuSymbols, c.OnMessage); err != nil {
 		return fmt.Errorf("failed to init t7 logging: %w", err)
 	}
 
-	t := time.NewTicker(time.Second / time.Duration(c.Rate))
+	t := time.NewTicker(c.pollInterval(interval))
 	defer t.Stop()
 
 	var timeStamp time.Time
-	var expectedPayloadSize uint16
-	for _, sym := range c.Symbols {
-		if sym.Number < 0 {
-			continue
+	var tick int
+
+	readData := func() error {
+		start := time.Now()
+		databuff, err := kwp.ReadDataByIdentifier(ctx, 0xF0)
+		c.pollDone(start)
+		if err != nil {
+			return err
 		}
-		expectedPayloadSize += sym.Length
+		if len(databuff) != int(size) {
+			return fmt.Errorf("expected %d bytes, got %d", size, len(databuff))
+		}
+		r := bytes.NewReader(databuff)
+		if err := readRegister(r, groups, tick, func(va *symbol.Symbol) {
+			if va.Name == "DisplProt.AD_Scanner" {
+				ebus.Publish(va.Name, adConverter(va.Float64()))
+				return
+			}
+			ebus.Publish(va.Name, va.Float64())
+		}); err != nil {
+			log.Printf("data ex %d %X len %d", size, databuff, len(databuff))
+			return err
+		}
+		if r.Len() > 0 {
+			c.OnMessage(fmt.Sprintf("%d leftover bytes!", r.Len()))
+		}
+		return nil
 	}
 
 	//lastPresent := time.Now()
@@ -180,12 +210,14 @@ func (c *T7Client) Start() error {
 				c.OnMessage("Stopped logging..")
 				return
 			case <-c.secondTicker.C:
-				c.FpsCounter(c.capturePerSecond)
-				if c.errPerSecond > 5 {
+				changed, ok := c.everySecond()
+				if !ok {
 					c.OnMessage("too many errors, aborting logging")
 					return
 				}
-				c.resetPerSecond()
+				if changed {
+					t.Reset(c.pollInterval(interval))
+				}
 			case read := <-c.readChan:
 				data, err := kwp.ReadMemoryByAddress(ctx, int(read.Address), int(read.Length))
 				if err != nil {
@@ -215,57 +247,34 @@ func (c *T7Client) Start() error {
 				write.Complete(nil)
 			case <-t.C:
 				timeStamp = time.Now()
-				databuff, err := kwp.ReadDataByIdentifier(ctx, 0xF0)
-				if err != nil {
+				// symbols of groups not due this tick keep their last value in the log
+				readErr := readData()
+				tick++
+				if readErr != nil {
 					c.onError()
-					c.OnMessage(err.Error())
+					c.OnMessage(readErr.Error())
 					continue
 				}
 
-				if len(databuff) != int(expectedPayloadSize) {
-					c.onError()
-					c.OnMessage(fmt.Sprintf("expected %d bytes, got %d", expectedPayloadSize, len(databuff)))
-					continue
-				}
-
-				r := bytes.NewReader(databuff)
 				for _, va := range c.Symbols {
-
-					if va.Number < 0 {
-						if va.Number <= -1000 {
-							if ca, ok := cl.Adapter().(gocan.ADCCapable); ok {
-								adcNumber := -va.Number - 1000
-								val, err := ca.GetADCValue(ctx, adcNumber)
-								if err != nil {
-									c.onError()
-									c.OnMessage(err.Error())
-									continue
-								}
-								c.sysvars.Set(va.Name, float64(val))
-								ebus.Publish(va.Name, float64(val))
+					if va.Number >= 0 {
+						continue
+					}
+					if va.Number <= -1000 {
+						if ca, ok := cl.Adapter().(gocan.ADCCapable); ok {
+							adcNumber := -va.Number - 1000
+							val, err := ca.GetADCValue(ctx, adcNumber)
+							if err != nil {
+								c.onError()
+								c.OnMessage(err.Error())
 								continue
 							}
-						} else {
-							ebus.Publish(va.Name, c.sysvars.Get(va.Name))
+							c.sysvars.Set(va.Name, float64(val))
+							ebus.Publish(va.Name, float64(val))
 						}
 						continue
 					}
-
-					if err := va.Read(r); err != nil {
-						log.Printf("data ex %d %X len %d", expectedPayloadSize, databuff, len(databuff))
-						c.onError()
-						c.OnMessage(err.Error())
-						break
-					}
-					if va.Name == "DisplProt.AD_Scanner" {
-						ebus.Publish(va.Name, adConverter(va.Float64()))
-						continue
-					}
-					ebus.Publish(va.Name, va.Float64())
-				}
-
-				if r.Len() > 0 {
-					c.OnMessage(fmt.Sprintf("%d leftover bytes!", r.Len()))
+					ebus.Publish(va.Name, c.sysvars.Get(va.Name))
 				}
 
 				if c.lamb != nil {
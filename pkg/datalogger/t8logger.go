@@ -78,25 +78,24 @@ func (c *T8Client) Start() error {
 
 	gm := gmlan.NewWithOpts(cl, opts...)
 
-	if err := initT8Logging(ctx, gm, c.Symbols, c.OnMessage); err != nil {
+	groups, interval := newRegisterGroups(c.Symbols, c.RateClasses, c.Rate)
+	symbols, size := registerSymbols(groups)
+
+	if err := initT8Logging(ctx, gm, symbols, c.OnMessage); err != nil {
 		return fmt.Errorf("failed to init t8 logging: %w", err)
 	}
 
-	go c.run(ctx, cl, gm, order)
+	go c.run(ctx, cl, gm, order, groups, size, interval)
 
 	return cl.Wait(ctx)
 }
 
-func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client, order []string) {
+func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client, order []string, groups []*symbolGroup, size uint16, interval time.Duration) {
 	defer cl.Close()
 
 	var timeStamp time.Time
 	var chunkSize uint32
-
-	var expectedPayloadSize uint16
-	for _, sym := range c.Symbols {
-		expectedPayloadSize += sym.Length
-	}
+	var tick int
 
 	lastPresent := time.Now()
 
@@ -116,7 +115,7 @@ func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client,
 		time.Sleep(50 * time.Millisecond)
 	}()
 
-	t := time.NewTicker(time.Second / time.Duration(c.Rate))
+	t := time.NewTicker(c.pollInterval(interval))
 	defer t.Stop()
 
 	for {
@@ -128,12 +127,14 @@ func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client,
 			c.OnMessage("Stopped logging..")
 			return
 		case <-c.secondTicker.C:
-			c.FpsCounter(c.capturePerSecond)
-			if c.errPerSecond > 5 {
+			changed, ok := c.everySecond()
+			if !ok {
 				c.OnMessage("too many errors, aborting logging")
 				return
 			}
-			c.resetPerSecond()
+			if changed {
+				t.Reset(c.pollInterval(interval))
+			}
 		case read := <-c.readChan:
 			for read.Left > 0 {
 				chunkSize = uint32(math.Min(float64(read.Left), T8ReadChunkSize))
@@ -160,7 +161,7 @@ func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client,
 			upd.Data = upd.Data[chunkSize:]
 			if upd.Length > 0 {
 				c.writeChan <- upd
-				t.Reset(time.Second / time.Duration(c.Rate))
+				t.Reset(c.pollInterval(interval))
 				continue
 			}
 			upd.Complete(nil)
@@ -171,29 +172,33 @@ func (c *T8Client) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client,
 				testerPresent()
 				continue
 			}
+			start := time.Now()
 			databuff, err := gm.ReadDataByIdentifier(ctx, 0x18)
-			if err != nil {
-				c.onError()
-				c.OnMessage(err.Error())
-				continue
-			}
-			if len(databuff) != int(expectedPayloadSize) {
-				c.OnMessage(fmt.Sprintf("expected %d bytes, got %d", expectedPayloadSize, len(databuff)))
-				return
-			}
-			r := bytes.NewReader(databuff)
-
-			for _, va := range c.Symbols {
-				if err := va.Read(r); err != nil {
-					c.onError()
-					c.OnMessage("failed to set data: " + err.Error())
+			c.pollDone(start)
+			var readErr error
+			switch {
+			case err != nil:
+				readErr = err
+			case len(databuff) != int(size):
+				readErr = fmt.Errorf("expected %d bytes, got %d", size, len(databuff))
+			default:
+				// symbols of groups not due this tick keep their last value in the log
+				r := bytes.NewReader(databuff)
+				if err := readRegister(r, groups, tick, func(va *symbol.Symbol) {
+					ebus.Publish(va.Name, va.Float64())
+				}); err != nil {
+					readErr = fmt.Errorf("failed to set data: %w", err)
 					break
 				}
-				ebus.Publish(va.Name, va.Float64())
+				if r.Len() > 0 {
+					c.OnMessage(fmt.Sprintf("%d leftover bytes!", r.Len()))
+				}
 			}
-
-			if r.Len() > 0 {
-				c.OnMessage(fmt.Sprintf("%d leftover bytes!", r.Len()))
+			tick++
+			if readErr != nil {
+				c.onError()
+				c.OnMessage(readErr.Error())
+				continue
 			}
 
 			if c.lamb != nil {
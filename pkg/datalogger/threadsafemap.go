@@ -1,6 +1,9 @@
 package datalogger
 
-import "sync"
+import (
+	"maps"
+	"sync"
+)
 
 type ThreadSafeMap struct {
 	values map[string]float64
@@ -42,8 +45,25 @@ func (t *ThreadSafeMap) Get(name string) float64 {
 	return t.values[name]
 }
 
+// Lookup returns the value of name and if it is set
+func (t *ThreadSafeMap) Lookup(name string) (float64, bool) {
+	t.Lock()
+	defer t.Unlock()
+	v, ok := t.values[name]
+	return v, ok
+}
+
 func (t *ThreadSafeMap) Delete(name string) {
 	t.Lock()
 	defer t.Unlock()
 	delete(t.values, name)
 }
+
+// Clone returns a copy of the map
+func (t *ThreadSafeMap) Clone() *ThreadSafeMap {
+	t.Lock()
+	defer t.Unlock()
+	return &ThreadSafeMap{
+		values: maps.Clone(t.values),
+	}
+}
@@ -0,0 +1,257 @@
+package datalogger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/gocan"
+	"github.com/roffe/gocan/pkg/gmlan"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/ecu/t8sec"
+)
+
+// The Z22SE has no symbol table to define dynamic registers from, symbols are read by address
+// in blocks of neighbouring symbols instead
+
+const Z22SEReadChunkSize = 245
+const Z22SEWriteChunkSize = 245
+
+// z22seMaxGap is how many unused bytes between two symbols are read rather than starting a new block
+const z22seMaxGap = 16
+
+type Z22SEClient struct {
+	*BaseLogger
+}
+
+func NewZ22SE(cfg Config, lw LogWriter) (IClient, error) {
+	return &Z22SEClient{BaseLogger: NewBaseLogger(cfg, lw)}, nil
+}
+
+type memBlock struct {
+	address uint32
+	length  uint32
+	symbols []*symbol.Symbol
+}
+
+// newMemBlocks merges symbols close to each other into blocks of at most maxLength bytes
+func newMemBlocks(symbols []*symbol.Symbol, maxLength uint32) []*memBlock {
+	sorted := slices.Clone(symbols)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Address < sorted[j].Address
+	})
+	var blocks []*memBlock
+	var cur *memBlock
+	for _, sym := range sorted {
+		end := sym.Address + uint32(sym.Length)
+		if cur != nil && sym.Address <= cur.address+cur.length+z22seMaxGap && end-cur.address <= maxLength {
+			cur.length = max(cur.length, end-cur.address)
+			cur.symbols = append(cur.symbols, sym)
+			continue
+		}
+		cur = &memBlock{address: sym.Address, length: uint32(sym.Length), symbols: []*symbol.Symbol{sym}}
+		blocks = append(blocks, cur)
+	}
+	return blocks
+}
+
+func (c *Z22SEClient) Start() error {
+	defer c.secondTicker.Stop()
+	defer c.lw.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventHandler := func(e gocan.Event) {
+		c.OnMessage(e.String())
+		if e.Type == gocan.EventTypeError {
+			c.onError()
+		}
+	}
+
+	cl, err := gocan.NewWithOpts(ctx, c.Device, gocan.WithEventHandler(eventHandler))
+	if err != nil {
+		return err
+	}
+	defer cl.Close()
+
+	order := c.sysvars.Keys()
+
+	if err := c.setupWBL(ctx, cl); err != nil {
+		return err
+	}
+
+	if c.lamb != nil {
+		defer c.lamb.Stop()
+		order = append(order, EXTERNALWBLSYM)
+	}
+
+	sort.StringSlice(order).Sort()
+
+	// the same ids and security access level as the Z22SE ECU client
+	opts := []gmlan.GMLanOption{gmlan.WithCanID(0x7E0), gmlan.WithRecvID(0x5E8, 0x7E8)}
+	if cl.AdapterName() == "ELM327" {
+		opts = append(opts, gmlan.WithDefaultTimeout(400*time.Millisecond))
+	}
+
+	gm := gmlan.NewWithOpts(cl, opts...)
+
+	if err := gm.InitiateDiagnosticOperation(ctx, gmlan.LEV_EDDDC); err != nil {
+		return fmt.Errorf("failed to init z22se logging: %w", err)
+	}
+
+	if err := gm.RequestSecurityAccess(ctx, 0x01, 0, t8sec.CalculateAccessKey); err != nil {
+		return fmt.Errorf("failed to init z22se logging: %w", err)
+	}
+
+	groups, interval := newSymbolGroups(c.Symbols, c.RateClasses, c.Rate)
+	blocks := make([][]*memBlock, len(groups))
+	for i, g := range groups {
+		blocks[i] = newMemBlocks(g.symbols, Z22SEReadChunkSize)
+	}
+
+	go c.run(ctx, cl, gm, order, groups, blocks, interval)
+
+	return cl.Wait(ctx)
+}
+
+func (c *Z22SEClient) run(ctx context.Context, cl *gocan.Client, gm *gmlan.Client, order []string, groups []*symbolGroup, blocks [][]*memBlock, interval time.Duration) {
+	defer cl.Close()
+
+	var timeStamp time.Time
+	var chunkSize uint32
+	var tick int
+
+	lastPresent := time.Now()
+
+	testerPresent := func() {
+		if time.Since(lastPresent) > lastPresentInterval {
+			if err := gm.TesterPresentNoResponseAllowed(); err != nil {
+				c.onError()
+				c.OnMessage("Failed to send tester present: " + err.Error())
+			}
+			lastPresent = time.Now()
+		}
+	}
+
+	defer func() {
+		_ = gm.ReturnToNormalMode(ctx)
+		time.Sleep(50 * time.Millisecond)
+	}()
+
+	t := time.NewTicker(c.pollInterval(interval))
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("ctx done")
+			return
+		case <-c.quitChan:
+			c.OnMessage("Stopped logging..")
+			return
+		case <-c.secondTicker.C:
+			changed, ok := c.everySecond()
+			if !ok {
+				c.OnMessage("too many errors, aborting logging")
+				return
+			}
+			if changed {
+				t.Reset(c.pollInterval(interval))
+			}
+		case read := <-c.readChan:
+			for read.Left > 0 {
+				chunkSize = uint32(math.Min(float64(read.Left), Z22SEReadChunkSize))
+				log.Printf("Reading RAM 0x%X %d", read.Address, chunkSize)
+				data, err := gm.ReadMemoryByAddress(ctx, read.Address, chunkSize)
+				if err != nil {
+					read.Complete(err)
+					break
+				}
+				read.Data = append(read.Data, data...)
+				read.Left -= chunkSize
+				read.Address += chunkSize
+			}
+			if read.Left == 0 {
+				read.Complete(nil)
+			}
+		case upd := <-c.writeChan:
+			chunkSize = uint32(math.Min(float64(upd.Length), Z22SEWriteChunkSize))
+			log.Printf("Updating RAM 0x%X %d", upd.Address, chunkSize)
+			if err := gm.WriteDataByAddress(ctx, upd.Address, upd.Data[:chunkSize]); err != nil {
+				upd.Complete(err)
+				continue
+			}
+			upd.Address += chunkSize
+			upd.Length -= chunkSize
+			upd.Data = upd.Data[chunkSize:]
+			if upd.Length > 0 {
+				c.writeChan <- upd
+				t.Reset(c.pollInterval(interval))
+				continue
+			}
+			upd.Complete(nil)
+			time.Sleep(12 * time.Millisecond)
+		case <-t.C:
+			timeStamp = time.Now()
+			if len(c.Symbols) == 0 {
+				testerPresent()
+				continue
+			}
+			// symbols of groups not due this tick keep their last value in the log
+			var readErr error
+		poll:
+			for i, g := range groups {
+				if !g.due(tick) {
+					continue
+				}
+				for _, b := range blocks[i] {
+					start := time.Now()
+					data, err := gm.ReadMemoryByAddress(ctx, b.address, b.length)
+					c.pollDone(start)
+					if err != nil {
+						readErr = err
+						break poll
+					}
+					if len(data) != int(b.length) {
+						readErr = fmt.Errorf("expected %d bytes from $%X, got %d", b.length, b.address, len(data))
+						break poll
+					}
+					for _, va := range b.symbols {
+						off := va.Address - b.address
+						if err := va.Read(bytes.NewReader(data[off : off+uint32(va.Length)])); err != nil {
+							c.onError()
+							c.OnMessage("failed to set data: " + err.Error())
+							continue
+						}
+						ebus.Publish(va.Name, va.Float64())
+					}
+				}
+			}
+			tick++
+			if readErr != nil {
+				c.onError()
+				c.OnMessage(readErr.Error())
+				continue
+			}
+
+			if c.lamb != nil {
+				ebus.Publish(EXTERNALWBLSYM, c.lamb.GetLambda())
+				c.sysvars.Set(EXTERNALWBLSYM, c.lamb.GetLambda())
+			}
+
+			if err := c.lw.Write(c.sysvars, order, c.Symbols, timeStamp); err != nil {
+				c.onError()
+				c.OnMessage("failed to write log: " + err.Error())
+			}
+			testerPresent()
+			c.onCapture()
+		}
+	}
+}
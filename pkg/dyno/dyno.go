@@ -0,0 +1,377 @@
+// Package dyno estimates wheel power and torque from the acceleration logged during a full throttle pull
+package dyno
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+const (
+	gravity    = 9.81   // m/s²
+	airDensity = 1.2041 // kg/m³ at 20°C
+
+	// DefaultSmoothing is the width in seconds of the moving average applied to speed and power
+	DefaultSmoothing = 0.5
+
+	// binRPM is the rpm step runs are averaged to so they can be compared
+	binRPM = 100
+
+	minPullDuration   = 2 * time.Second
+	minPullSpan       = 1500.0 // rpm
+	pullDropTolerance = 100.0  // rpm, less than a gear change
+)
+
+// channels in order of preference, T7 and T8 first, then T5 and OBD-II
+var (
+	rpmChannels    = []string{"ActualIn.n_Engine", "Rpm", "OBD2.EngineRPM"}
+	speedChannels  = []string{"In.v_Vehicle", "Bil_hast", "OBD2.VehicleSpeed"}
+	torqueChannels = []string{"Out.M_Engine"}
+)
+
+// Profile is the car a pull was made with
+type Profile struct {
+	Name string
+	// Mass is the weight of the car with driver and fuel in kg
+	Mass float64
+	// GearRatio is the ratio of the gear the pull is made in, FinalDrive that of the differential
+	GearRatio  float64
+	FinalDrive float64
+	// Tire is the size of the driven tires, like 225/45R17
+	Tire              string
+	DragCoefficient   float64
+	FrontalArea       float64 // m²
+	RollingResistance float64
+}
+
+// DefaultProfiles returns the profiles a new installation starts out with
+func DefaultProfiles() []Profile {
+	return []Profile{
+		{Name: "Default", Mass: 1550, GearRatio: 1.3, FinalDrive: 4.05, Tire: "215/55R16", DragCoefficient: 0.3, FrontalArea: 2.2, RollingResistance: 0.015},
+	}
+}
+
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return errors.New("profile has no name")
+	}
+	if p.Mass <= 0 {
+		return fmt.Errorf("%s: mass must be above zero", p.Name)
+	}
+	if p.GearRatio < 0 || p.FinalDrive < 0 || p.DragCoefficient < 0 || p.FrontalArea < 0 || p.RollingResistance < 0 {
+		return fmt.Errorf("%s: values can't be negative", p.Name)
+	}
+	if p.Tire != "" {
+		if _, err := TireDiameter(p.Tire); err != nil {
+			return fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	return nil
+}
+
+// HasGearing tells if the speed of the car can be calculated from engine rpm
+func (p Profile) HasGearing() bool {
+	d, err := TireDiameter(p.Tire)
+	return err == nil && d > 0 && p.GearRatio > 0 && p.FinalDrive > 0
+}
+
+// TireDiameter returns the diameter in meters of a tire size like 225/45R17
+func TireDiameter(size string) (float64, error) {
+	width, rest, ok := strings.Cut(strings.ToUpper(strings.ReplaceAll(size, " ", "")), "/")
+	if !ok {
+		return 0, fmt.Errorf("invalid tire size %q, expected like 225/45R17", size)
+	}
+	aspect, rim, ok := strings.Cut(rest, "R")
+	if !ok {
+		return 0, fmt.Errorf("invalid tire size %q, expected like 225/45R17", size)
+	}
+	w, err1 := strconv.ParseFloat(width, 64)
+	a, err2 := strconv.ParseFloat(aspect, 64)
+	r, err3 := strconv.ParseFloat(rim, 64)
+	if err := errors.Join(err1, err2, err3); err != nil || w <= 0 || a <= 0 || r <= 0 {
+		return 0, fmt.Errorf("invalid tire size %q, expected like 225/45R17", size)
+	}
+	return r*0.0254 + 2*w/1000*a/100, nil
+}
+
+// Channels are the channels a log is read with, empty fields are detected from the log
+type Channels struct {
+	RPM    string
+	Speed  string
+	Torque string
+}
+
+// Log is the part of a logfile needed to calculate runs from it
+type Log struct {
+	Name     string
+	Channels Channels
+	Times    []time.Time
+	RPM      []float64
+	Speed    []float64 // km/h
+	// Torque is what the ECU reports, nil when the log has no torque channel
+	Torque []float64
+}
+
+// Read reads the channels needed from lf, lf is rewound afterwards
+func Read(name string, lf logfile.Logfile, ch Channels) (*Log, error) {
+	l := &Log{Name: name}
+	var rpm, speed, torque float64
+	lf.Seek(-1)
+	defer lf.Seek(-1)
+	for rec := lf.Next(); !rec.EOF; rec = lf.Next() {
+		if l.Channels.RPM == "" {
+			l.Channels = Channels{
+				RPM:    pick(ch.RPM, rpmChannels, rec.Values),
+				Speed:  pick(ch.Speed, speedChannels, rec.Values),
+				Torque: pick(ch.Torque, torqueChannels, rec.Values),
+			}
+			if l.Channels.RPM == "" {
+				continue
+			}
+		}
+		v, ok := rec.Values[l.Channels.RPM]
+		if !ok {
+			continue
+		}
+		rpm = v
+		// channels missing from a record keep their last value
+		if v, ok := rec.Values[l.Channels.Speed]; ok {
+			speed = v
+		}
+		if v, ok := rec.Values[l.Channels.Torque]; ok {
+			torque = v
+		}
+		l.Times = append(l.Times, rec.Time)
+		l.RPM = append(l.RPM, rpm)
+		l.Speed = append(l.Speed, speed)
+		if l.Channels.Torque != "" {
+			l.Torque = append(l.Torque, torque)
+		}
+	}
+	if l.Channels.RPM == "" {
+		return nil, fmt.Errorf("%s has no engine speed", name)
+	}
+	return l, nil
+}
+
+func pick(name string, candidates []string, values map[string]float64) string {
+	if name != "" {
+		if _, ok := values[name]; ok {
+			return name
+		}
+		return ""
+	}
+	for _, c := range candidates {
+		if _, ok := values[c]; ok {
+			return c
+		}
+	}
+	return ""
+}
+
+// Pull is a stretch of a log where the engine speed rises, Start and End are sample indexes
+type Pull struct {
+	Start, End int
+}
+
+// Label names a pull of the log
+func (l *Log) Label(p Pull) string {
+	return fmt.Sprintf("%s %s %.0f-%.0f rpm", l.Name, l.Times[p.Start].Format("15:04:05"), l.RPM[p.Start], l.RPM[p.End])
+}
+
+// Pulls finds the pulls in the log, a pull ends when the rpm drops like at a gear change or lift off
+func (l *Log) Pulls() []Pull {
+	var pulls []Pull
+	start, top := 0, 0
+	end := func() {
+		p := Pull{Start: start, End: top}
+		if l.Times[p.End].Sub(l.Times[p.Start]) >= minPullDuration &&
+			l.RPM[p.End]-l.RPM[p.Start] >= minPullSpan &&
+			(l.Channels.Speed == "" || l.Speed[p.End] > l.Speed[p.Start]) {
+			pulls = append(pulls, p)
+		}
+	}
+	for i := 1; i < len(l.RPM); i++ {
+		switch {
+		case l.RPM[i] <= l.RPM[start]:
+			// not rising yet
+			start, top = i, i
+		case l.RPM[i] >= l.RPM[top]:
+			top = i
+		case l.RPM[i] < l.RPM[top]-pullDropTolerance:
+			end()
+			start, top = i, i
+		}
+	}
+	if len(l.RPM) > 0 {
+		end()
+	}
+	return pulls
+}
+
+// Options for calculating a run
+type Options struct {
+	// Smoothing is the width in seconds of the moving average applied to speed and power
+	Smoothing float64
+	// SpeedFromRPM calculates the speed of the car from engine rpm and the gearing of the profile
+	// instead of using the speed channel, the rpm has a finer resolution
+	SpeedFromRPM bool
+}
+
+// Point is the result at one rpm
+type Point struct {
+	RPM   float64
+	Speed float64 // km/h
+	Power float64 // kW at the wheels
+	// Torque is the wheel power as torque at the engine speed, comparable to what the ECU reports
+	// less the losses of the drivetrain
+	Torque    float64 // Nm
+	ECUTorque float64 // Nm
+}
+
+// Run is the result of one pull averaged in steps of binRPM
+type Run struct {
+	Name         string
+	Points       []Point
+	HasECUTorque bool
+}
+
+// Peaks returns the points with the most power and torque
+func (r *Run) Peaks() (power, torque Point) {
+	for i, p := range r.Points {
+		if i == 0 || p.Power > power.Power {
+			power = p
+		}
+		if i == 0 || p.Torque > torque.Torque {
+			torque = p
+		}
+	}
+	return power, torque
+}
+
+// Calculate the run of pull in l with the car in p
+func Calculate(p Profile, l *Log, pull Pull, opts Options) (*Run, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.SpeedFromRPM && !p.HasGearing() {
+		return nil, fmt.Errorf("%s: gear ratio, final drive and tire size are needed to get the speed from rpm", p.Name)
+	}
+	if !opts.SpeedFromRPM && l.Channels.Speed == "" {
+		return nil, fmt.Errorf("%s has no vehicle speed, set the gearing of the profile to use engine speed", l.Name)
+	}
+	n := pull.End - pull.Start + 1
+	if pull.Start < 0 || pull.End >= len(l.RPM) || n < 5 {
+		return nil, errors.New("the pull is too short")
+	}
+
+	t := make([]float64, n)
+	v := make([]float64, n)
+	var wheel float64 // m per engine revolution
+	if opts.SpeedFromRPM {
+		d, _ := TireDiameter(p.Tire)
+		wheel = math.Pi * d / (p.GearRatio * p.FinalDrive)
+	}
+	for i := range n {
+		j := pull.Start + i
+		t[i] = l.Times[j].Sub(l.Times[pull.Start]).Seconds()
+		if opts.SpeedFromRPM {
+			v[i] = l.RPM[j] / 60 * wheel
+		} else {
+			v[i] = l.Speed[j] / 3.6
+		}
+	}
+	v = smooth(t, v, opts.Smoothing)
+
+	power := make([]float64, n)
+	for i := range n {
+		lo, hi := max(i-1, 0), min(i+1, n-1)
+		var a float64
+		if dt := t[hi] - t[lo]; dt > 0 {
+			a = (v[hi] - v[lo]) / dt
+		}
+		force := p.Mass*a +
+			0.5*airDensity*p.DragCoefficient*p.FrontalArea*v[i]*v[i] +
+			p.RollingResistance*p.Mass*gravity
+		power[i] = force * v[i]
+	}
+	power = smooth(t, power, opts.Smoothing)
+
+	type bin struct {
+		n                                 int
+		rpm, speed, power, torque, ecuTrq float64
+	}
+	bins := make(map[int]*bin)
+	for i := range n {
+		j := pull.Start + i
+		rpm := l.RPM[j]
+		// the average is one sided at the ends of the pull
+		if rpm <= 0 || t[i] < opts.Smoothing/2 || t[i] > t[n-1]-opts.Smoothing/2 {
+			continue
+		}
+		k := int(math.Round(rpm/binRPM)) * binRPM
+		b, ok := bins[k]
+		if !ok {
+			b = &bin{}
+			bins[k] = b
+		}
+		b.n++
+		b.rpm += rpm
+		b.speed += v[i] * 3.6
+		b.power += power[i] / 1000
+		b.torque += power[i] / (rpm * 2 * math.Pi / 60)
+		if l.Torque != nil {
+			b.ecuTrq += l.Torque[j]
+		}
+	}
+	if len(bins) == 0 {
+		return nil, errors.New("the pull is too short for the smoothing")
+	}
+	keys := make([]int, 0, len(bins))
+	for k := range bins {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	r := &Run{Name: l.Label(pull), HasECUTorque: l.Torque != nil}
+	for _, k := range keys {
+		b := bins[k]
+		c := float64(b.n)
+		r.Points = append(r.Points, Point{
+			RPM:       float64(k),
+			Speed:     b.speed / c,
+			Power:     b.power / c,
+			Torque:    b.torque / c,
+			ECUTorque: b.ecuTrq / c,
+		})
+	}
+	return r, nil
+}
+
+// smooth returns the centered moving average of x over width seconds
+func smooth(t, x []float64, width float64) []float64 {
+	if width <= 0 {
+		return x
+	}
+	out := make([]float64, len(x))
+	lo, hi := 0, 0
+	var sum float64
+	for i := range x {
+		for hi < len(x) && t[hi] <= t[i]+width/2 {
+			sum += x[hi]
+			hi++
+		}
+		for t[lo] < t[i]-width/2 {
+			sum -= x[lo]
+			lo++
+		}
+		out[i] = sum / float64(hi-lo)
+	}
+	return out
+}
@@ -0,0 +1,210 @@
+package dyno
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	plotMarginL = 60
+	plotMarginR = 60
+	plotMarginT = 20
+	plotMarginB = 30
+	legendLine  = 15
+)
+
+var (
+	plotBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	plotGrid       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	plotText       = color.RGBA{0x30, 0x30, 0x30, 0xff}
+	plotColors     = []color.RGBA{
+		{0x1f, 0x77, 0xb4, 0xff},
+		{0xd6, 0x27, 0x28, 0xff},
+		{0x2c, 0xa0, 0x2c, 0xff},
+		{0xff, 0x7f, 0x0e, 0xff},
+		{0x94, 0x67, 0xbd, 0xff},
+		{0x8c, 0x56, 0x4b, 0xff},
+	}
+)
+
+// Render plots power and torque against rpm for every run. Power is the thick line on the left axis,
+// torque the thin line on the right axis and ECU torque the dots
+func Render(runs []*Run, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{plotBackground}, image.Point{}, draw.Src)
+
+	minRPM, maxRPM := math.Inf(1), math.Inf(-1)
+	var maxPower, maxTorque float64
+	for _, r := range runs {
+		for _, p := range r.Points {
+			minRPM = math.Min(minRPM, p.RPM)
+			maxRPM = math.Max(maxRPM, p.RPM)
+			maxPower = math.Max(maxPower, p.Power)
+			maxTorque = math.Max(maxTorque, p.Torque)
+			if r.HasECUTorque {
+				maxTorque = math.Max(maxTorque, p.ECUTorque)
+			}
+		}
+	}
+	if math.IsInf(minRPM, 1) {
+		drawText(img, plotMarginL, height/2, "No runs selected", plotText)
+		return img
+	}
+	minRPM = math.Floor(minRPM/500) * 500
+	maxRPM = math.Max(math.Ceil(maxRPM/500)*500, minRPM+500)
+	maxPower = niceMax(maxPower)
+	maxTorque = niceMax(maxTorque)
+
+	left, right := plotMarginL, width-plotMarginR
+	top, bottom := plotMarginT+len(runs)*legendLine, height-plotMarginB
+	if bottom-top < 50 {
+		top = bottom - 50
+	}
+	xOf := func(rpm float64) int {
+		return left + int((rpm-minRPM)/(maxRPM-minRPM)*float64(right-left))
+	}
+	yOf := func(v, maxV float64) int {
+		return bottom - int(v/maxV*float64(bottom-top))
+	}
+
+	for i := range 6 {
+		y := top + i*(bottom-top)/5
+		hline(img, left, right, y, plotGrid)
+		drawText(img, 4, y+4, strconv.FormatFloat(maxPower-float64(i)*maxPower/5, 'f', 0, 64), plotText)
+		drawText(img, right+6, y+4, strconv.FormatFloat(maxTorque-float64(i)*maxTorque/5, 'f', 0, 64), plotText)
+	}
+	for rpm := minRPM; rpm <= maxRPM; rpm += 500 {
+		x := xOf(rpm)
+		vline(img, x, top, bottom, plotGrid)
+		if int(rpm)%1000 == 0 {
+			label := strconv.Itoa(int(rpm))
+			drawText(img, x-len(label)*7/2, bottom+14, label, plotText)
+		}
+	}
+	drawText(img, 4, plotMarginT-6, "kW", plotText)
+	drawText(img, right+6, plotMarginT-6, "Nm", plotText)
+	drawText(img, (left+right)/2-10, height-4, "rpm", plotText)
+
+	for i, r := range runs {
+		c := plotColors[i%len(plotColors)]
+		power, torque := r.Peaks()
+		drawText(img, left+30, plotMarginT+i*legendLine+8, fmt.Sprintf("%s  %.0f kW @ %.0f  %.0f Nm @ %.0f",
+			r.Name, power.Power, power.RPM, torque.Torque, torque.RPM), plotText)
+		fill(img, left+10, plotMarginT+i*legendLine, left+24, plotMarginT+i*legendLine+4, c)
+
+		for j := 1; j < len(r.Points); j++ {
+			a, b := r.Points[j-1], r.Points[j]
+			x0, x1 := xOf(a.RPM), xOf(b.RPM)
+			y0, y1 := yOf(a.Power, maxPower), yOf(b.Power, maxPower)
+			line(img, x0, y0, x1, y1, c)
+			line(img, x0, y0+1, x1, y1+1, c)
+			line(img, x0, yOf(a.Torque, maxTorque), x1, yOf(b.Torque, maxTorque), c)
+		}
+		if r.HasECUTorque {
+			for _, p := range r.Points {
+				x, y := xOf(p.RPM), yOf(p.ECUTorque, maxTorque)
+				fill(img, x-1, y-1, x+1, y+1, c)
+			}
+		}
+	}
+	return img
+}
+
+// WriteCSV writes the points of every run, one row per run and rpm
+func WriteCSV(w io.Writer, runs []*Run) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Run", "RPM", "Speed (km/h)", "Power (kW)", "Power (hp)", "Torque (Nm)", "ECU torque (Nm)"})
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64)
+	}
+	for _, r := range runs {
+		for _, p := range r.Points {
+			ecu := ""
+			if r.HasECUTorque {
+				ecu = format(p.ECUTorque)
+			}
+			cw.Write([]string{r.Name, format(p.RPM), format(p.Speed), format(p.Power), format(p.Power * 1.34102), format(p.Torque), ecu})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// niceMax rounds the top of an axis up to a multiple of 50
+func niceMax(v float64) float64 {
+	if v <= 0 {
+		return 50
+	}
+	return math.Ceil(v*1.05/50) * 50
+}
+
+func hline(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, c)
+	}
+}
+
+func vline(img *image.RGBA, x, y0, y1 int, c color.Color) {
+	for y := y0; y <= y1; y++ {
+		img.Set(x, y, c)
+	}
+}
+
+func fill(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	draw.Draw(img, image.Rect(x0, y0, x1+1, y1+1), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// line draws a line with Bresenham's algorithm
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func drawText(img *image.RGBA, x, y int, s string, c color.Color) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
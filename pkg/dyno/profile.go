@@ -0,0 +1,41 @@
+package dyno
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+)
+
+const prefsDynoProfiles = "dynoProfiles"
+
+// LoadProfiles returns the stored car profiles
+func LoadProfiles(prefs fyne.Preferences) ([]Profile, error) {
+	data := prefs.String(prefsDynoProfiles)
+	if data == "" {
+		return DefaultProfiles(), nil
+	}
+	var profiles []Profile
+	if err := json.Unmarshal([]byte(data), &profiles); err != nil {
+		return DefaultProfiles(), fmt.Errorf("failed to load dyno profiles: %w", err)
+	}
+	if len(profiles) == 0 {
+		return DefaultProfiles(), nil
+	}
+	return profiles, nil
+}
+
+// SaveProfiles stores the car profiles
+func SaveProfiles(prefs fyne.Preferences, profiles []Profile) error {
+	for _, p := range profiles {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+	prefs.SetString(prefsDynoProfiles, string(data))
+	return nil
+}
@@ -65,6 +65,14 @@ func Unsubscribe(channel chan float64) {
 	CONTROLLER.Unsubscribe(channel)
 }
 
+// Values returns the last value of every topic, leaving out the internal ones above
+func Values() map[string]float64 {
+	values := CONTROLLER.Values()
+	delete(values, TOPIC_COLORBLINDMODE)
+	delete(values, TOPIC_ECU)
+	return values
+}
+
 func SetOnMessage(f func(string, float64)) {
 	CONTROLLER.SetOnMessage(f)
 }
@@ -0,0 +1,85 @@
+package z22se
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// Channel names the dashboard shows, name the address list entries after these to get gauges
+const (
+	ChannelEngineSpeed  = "Z22SE.EngineSpeed"  // rpm
+	ChannelVehicleSpeed = "Z22SE.VehicleSpeed" // km/h
+	ChannelBoost        = "Z22SE.Boost"        // bar relative
+	ChannelLambda       = "Z22SE.Lambda"       // λ
+	ChannelCoolantTemp  = "Z22SE.CoolantTemp"  // °C
+	ChannelIntakeTemp   = "Z22SE.IntakeTemp"   // °C
+	ChannelThrottle     = "Z22SE.Throttle"     // %
+	ChannelIgnition     = "Z22SE.Ignition"     // °
+)
+
+// typeSigned is the signed flag of the symbol type, same as in the Trionic symbol tables
+const typeSigned = 0x01
+
+// LoadAddressList reads the RAM address list used in place of a symbol table, the Z22SE binary has none.
+// One symbol per line: name,address,length[,factor[,unit[,signed]]], address in hex. Lines starting with # are skipped
+//
+//	Z22SE.EngineSpeed,0x3F8A2,2,1,rpm
+//	Z22SE.Ignition,0x3F9C0,1,0.75,°,signed
+func LoadAddressList(r io.Reader) ([]*symbol.Symbol, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	var symbols []*symbol.Symbol
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		if len(rec) < 3 {
+			return nil, fmt.Errorf("line %d: expected name,address,length", line)
+		}
+		address, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(rec[1]), "0x"), 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid address %q", line, rec[1])
+		}
+		length, err := strconv.ParseUint(rec[2], 10, 16)
+		if err != nil || (length != 1 && length != 2 && length != 4) {
+			return nil, fmt.Errorf("line %d: invalid length %q, 1, 2 or 4 bytes", line, rec[2])
+		}
+		sym := &symbol.Symbol{
+			Name:             strings.TrimSpace(rec[0]),
+			Number:           len(symbols),
+			Address:          uint32(address),
+			Length:           uint16(length),
+			Correctionfactor: 1,
+		}
+		if len(rec) > 3 && rec[3] != "" {
+			if sym.Correctionfactor, err = strconv.ParseFloat(rec[3], 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid factor %q", line, rec[3])
+			}
+		}
+		if len(rec) > 4 {
+			sym.Unit = rec[4]
+		}
+		if len(rec) > 5 && strings.EqualFold(rec[5], "signed") {
+			sym.Type |= typeSigned
+		}
+		symbols = append(symbols, sym)
+	}
+	if len(symbols) == 0 {
+		return nil, errors.New("no symbols in address list")
+	}
+	return symbols, nil
+}
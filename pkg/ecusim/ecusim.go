@@ -0,0 +1,234 @@
+// Package ecusim is a simulated Trionic ECU that plugs in as a gocan adapter.
+//
+// It answers the T5 CAN protocol, the KWP2000 services used by the T7 logger and
+// the GMLAN services used by the T8 logger with RAM backed by the symbol table of
+// the loaded binary. Symbol values can be driven by replaying a logfile.
+package ecusim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+// Name is the adapter name shown in settings
+const Name = "txlogger ECU simulator"
+
+// AdditionalConfig keys read by the simulator
+const (
+	ConfigECU    = "ecu"    // T5, T7 or T8
+	ConfigReplay = "replay" // logfile driving the symbol values, optional
+)
+
+func init() {
+	if err := gocan.RegisterAdapter(&gocan.AdapterInfo{
+		Name:               Name,
+		Description:        "Simulated Trionic 5, 7 and 8 ECU backed by the loaded binary",
+		RequiresSerialPort: false,
+		Capabilities: gocan.AdapterCapabilities{
+			HSCAN: true,
+		},
+		New: New,
+	}); err != nil {
+		panic(err)
+	}
+}
+
+var (
+	symbolsMu sync.Mutex
+	symbols   symbol.SymbolCollection
+)
+
+// SetSymbols sets the symbol table the next simulated ECU is created with
+func SetSymbols(s symbol.SymbolCollection) {
+	symbolsMu.Lock()
+	defer symbolsMu.Unlock()
+	symbols = s
+}
+
+func getSymbols() symbol.SymbolCollection {
+	symbolsMu.Lock()
+	defer symbolsMu.Unlock()
+	return symbols
+}
+
+// responder answers the frames sent to the simulated ECU
+type responder interface {
+	handle(f *gocan.CANFrame)
+}
+
+type Adapter struct {
+	*gocan.BaseAdapter
+	cfg *gocan.AdapterConfig
+
+	ecuType string
+	syms    []*symbol.Symbol
+	mem     *memory
+	ecu     responder
+
+	replayFile string
+	replay     logfile.Logfile
+
+	sendChan, recvChan chan *gocan.CANFrame
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+}
+
+func New(cfg *gocan.AdapterConfig) (gocan.Adapter, error) {
+	fw := getSymbols()
+	if fw == nil {
+		return nil, errors.New("load a binary before using the ECU simulator")
+	}
+	a := &Adapter{
+		BaseAdapter: gocan.NewBaseAdapter(Name, cfg),
+		cfg:         cfg,
+		ecuType:     ecuType(cfg),
+		syms:        fw.Symbols(),
+		mem:         newMemory(),
+		replayFile:  cfg.AdditionalConfig[ConfigReplay],
+		sendChan:    make(chan *gocan.CANFrame, 40),
+		recvChan:    make(chan *gocan.CANFrame, 1024),
+		closeChan:   make(chan struct{}),
+	}
+	switch a.ecuType {
+	case "T5":
+		a.ecu = newT5(a)
+	case "T7":
+		a.ecu = newT7(a)
+	case "T8":
+		a.ecu = newT8(a)
+	default:
+		return nil, fmt.Errorf("the ECU simulator does not support %q", a.ecuType)
+	}
+	for _, sym := range a.syms {
+		if data := sym.Bytes(); len(data) > 0 && len(data) == int(sym.Length) {
+			a.mem.write(a.address(sym), data)
+		}
+	}
+	return a, nil
+}
+
+// ecuType returns the simulated ECU, falling back on the CAN rate and filters when it is not configured
+func ecuType(cfg *gocan.AdapterConfig) string {
+	switch ecu := cfg.AdditionalConfig[ConfigECU]; ecu {
+	case "T5", "Trionic 5":
+		return "T5"
+	case "T7", "Trionic 7":
+		return "T7"
+	case "T8", "Trionic 8", "Trionic 8 MCP", "Z22SE", "Z22SE MCP":
+		return "T8"
+	case "":
+	default:
+		return ecu
+	}
+	if cfg.CANRate == 615.384 {
+		return "T5"
+	}
+	for _, id := range cfg.CANFilter {
+		switch id {
+		case 0x238, 0x258:
+			return "T7"
+		case 0x7E8:
+			return "T8"
+		}
+	}
+	return ""
+}
+
+func (a *Adapter) Open(ctx context.Context) error {
+	if a.replayFile != "" {
+		f, err := os.Open(a.replayFile)
+		if err != nil {
+			return fmt.Errorf("failed to open replay log: %w", err)
+		}
+		lf, err := logfile.Open(a.replayFile, f)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to open replay log: %w", err)
+		}
+		a.replay = lf
+		go a.runReplay(ctx)
+	}
+	go a.run(ctx)
+	if a.cfg.PrintVersion {
+		info := fmt.Sprintf("Simulated %s with %d symbols", a.ecuType, len(a.syms))
+		if a.replay != nil {
+			info += ", replaying " + a.replayFile
+		}
+		a.Info(info)
+	}
+	return nil
+}
+
+func (a *Adapter) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.closeChan)
+		a.BaseAdapter.Close()
+	})
+	return nil
+}
+
+func (a *Adapter) Send() chan<- *gocan.CANFrame {
+	return a.sendChan
+}
+
+func (a *Adapter) Recv() <-chan *gocan.CANFrame {
+	return a.recvChan
+}
+
+func (a *Adapter) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.closeChan:
+			return
+		case frame := <-a.sendChan:
+			if a.cfg.Debug {
+				a.Debug("<o> " + frame.String())
+			}
+			a.ecu.handle(frame)
+		}
+	}
+}
+
+// reply sends a frame from the simulated ECU to the tester
+func (a *Adapter) reply(id uint32, data ...byte) {
+	frame := gocan.NewFrame(id, data, gocan.Incoming)
+	if a.cfg.Debug {
+		a.Debug("<i> " + frame.String())
+	}
+	select {
+	case a.recvChan <- frame:
+	default:
+		a.Error(gocan.ErrDroppedFrame)
+	}
+}
+
+// address returns where the value of sym lives in the simulated RAM
+func (a *Adapter) address(sym *symbol.Symbol) uint32 {
+	switch a.ecuType {
+	case "T5":
+		return sym.SramOffset
+	case "T8":
+		return sym.Address + sym.SramOffset
+	}
+	return sym.Address
+}
+
+// symbolByNumber returns the symbol the ECU knows by number n
+func (a *Adapter) symbolByNumber(n int) *symbol.Symbol {
+	for _, sym := range a.syms {
+		if sym.Number == n {
+			return sym
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ecusim
+
+import "sync"
+
+const pageSize = 0x1000
+
+// memory is the sparse RAM of the simulated ECU, unwritten addresses read as zero
+type memory struct {
+	mu    sync.Mutex
+	pages map[uint32]*[pageSize]byte
+}
+
+func newMemory() *memory {
+	return &memory{
+		pages: make(map[uint32]*[pageSize]byte),
+	}
+}
+
+func (m *memory) read(address uint32, length int) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]byte, length)
+	for i := range out {
+		addr := address + uint32(i)
+		if page, ok := m.pages[addr/pageSize]; ok {
+			out[i] = page[addr%pageSize]
+		}
+	}
+	return out
+}
+
+func (m *memory) write(address uint32, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, b := range data {
+		addr := address + uint32(i)
+		page, ok := m.pages[addr/pageSize]
+		if !ok {
+			page = new([pageSize]byte)
+			m.pages[addr/pageSize] = page
+		}
+		page[addr%pageSize] = b
+	}
+}
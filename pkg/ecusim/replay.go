@@ -0,0 +1,58 @@
+package ecusim
+
+import (
+	"context"
+	"math"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// runReplay writes the values of the replayed log into RAM in real time, starting over at the end of the log
+func (a *Adapter) runReplay(ctx context.Context) {
+	defer a.replay.Close()
+
+	byName := make(map[string]*symbol.Symbol, len(a.syms))
+	for _, sym := range a.syms {
+		byName[sym.Name] = sym
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.closeChan:
+			return
+		case <-timer.C:
+			rec := a.replay.Next()
+			if rec.EOF {
+				a.replay.Seek(-1)
+				timer.Reset(100 * time.Millisecond)
+				continue
+			}
+			for name, value := range rec.Values {
+				if sym, ok := byName[name]; ok {
+					a.mem.write(a.address(sym), encode(sym, value))
+				}
+			}
+			timer.Reset(time.Duration(max(rec.DelayTillNext, 1)) * time.Millisecond)
+		}
+	}
+}
+
+// encode returns the raw big endian bytes of a symbol showing value
+func encode(sym *symbol.Symbol, value float64) []byte {
+	factor := sym.Correctionfactor
+	if factor == 0 {
+		factor = 1
+	}
+	raw := int64(math.Round(value / factor))
+	out := make([]byte, sym.Length)
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = byte(raw)
+		raw >>= 8
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package ecusim
+
+import (
+	"fmt"
+
+	"github.com/roffe/gocan"
+)
+
+const (
+	t5RequestID = 0x05
+	t5ReplyID   = 0x0C
+)
+
+// t5 answers the RAM read and write commands of the Trionic 5 CAN protocol
+type t5 struct {
+	a *Adapter
+
+	command []byte // ASCII command being received one character at a time
+
+	blockAddress uint32 // RAM address of the block write in progress
+	blockLeft    int
+}
+
+func newT5(a *Adapter) *t5 {
+	return &t5{a: a}
+}
+
+func (t *t5) handle(f *gocan.CANFrame) {
+	if f.Identifier != t5RequestID || len(f.Data) == 0 {
+		return
+	}
+	if t.blockLeft > 0 {
+		t.blockData(f.Data)
+		return
+	}
+	switch f.Data[0] {
+	case 0xC7: // read 6 bytes ending at the address, last byte first
+		if len(f.Data) < 5 {
+			return
+		}
+		address := uint32(f.Data[3])<<8 | uint32(f.Data[4])
+		data := t.a.mem.read(address-5, 6)
+		t.a.reply(t5ReplyID, 0xC7, 0x00, data[5], data[4], data[3], data[2], data[1], data[0])
+	case 0xC4: // one character of an ASCII command
+		if len(f.Data) < 2 {
+			return
+		}
+		t.a.reply(t5ReplyID, 0xC6, 0x00, f.Data[1])
+		t.commandChar(f.Data[1])
+	case 0xA5: // start of a block write
+		if len(f.Data) < 6 {
+			return
+		}
+		t.blockAddress = uint32(f.Data[1])<<24 | uint32(f.Data[2])<<16 | uint32(f.Data[3])<<8 | uint32(f.Data[4])
+		t.blockLeft = int(f.Data[5])
+		t.a.reply(t5ReplyID, 0xA5, 0x00)
+	}
+}
+
+// blockData writes a data frame of a block write, the first byte is the offset in the block
+func (t *t5) blockData(data []byte) {
+	if len(data) < 2 {
+		return
+	}
+	n := min(len(data)-1, t.blockLeft, 7)
+	t.a.mem.write(t.blockAddress+uint32(data[0]), data[1:1+n])
+	t.blockLeft -= n
+	t.a.reply(t5ReplyID, data[0], 0x00)
+}
+
+func (t *t5) commandChar(c byte) {
+	if c != '\r' {
+		t.command = append(t.command, c)
+		return
+	}
+	cmd := string(t.command)
+	t.command = t.command[:0]
+	var address uint16
+	var value byte
+	if _, err := fmt.Sscanf(cmd, "W%04X%02X", &address, &value); err == nil {
+		t.a.mem.write(uint32(address), []byte{value})
+	}
+}
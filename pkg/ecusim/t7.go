@@ -0,0 +1,210 @@
+package ecusim
+
+import (
+	"math/rand/v2"
+
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/kwp2000"
+)
+
+// t7ResponseID is where the simulated Trionic 7 sends its responses, told to the tester when the session starts
+const t7ResponseID = 0x258
+
+// ddlEntry is a RAM range read through a dynamically defined identifier
+type ddlEntry struct {
+	address uint32
+	length  int
+}
+
+// t7 answers the KWP2000 services used by the Trionic 7 logger
+type t7 struct {
+	a *Adapter
+
+	request []byte // payload of the request being received in chunks
+
+	response []byte // response chunks waiting for the tester to acknowledge
+	chunk    int
+
+	seed    int
+	granted bool
+
+	ddl map[int]ddlEntry
+}
+
+func newT7(a *Adapter) *t7 {
+	return &t7{
+		a:   a,
+		ddl: make(map[int]ddlEntry),
+	}
+}
+
+func (t *t7) handle(f *gocan.CANFrame) {
+	switch f.Identifier {
+	case kwp2000.INIT_MSG_ID:
+		if len(f.Data) > 1 && f.Data[1] == kwp2000.START_COM_REQ {
+			t.a.reply(kwp2000.INIT_RESP_ID, 0x40, 0xBF, 0x21, kwp2000.START_COM_REQ|0x40, 0x00, 0x11, byte(t7ResponseID>>8), byte(t7ResponseID&0xFF))
+		}
+	case kwp2000.REQ_MSG_ID:
+		t.requestChunk(f.Data)
+	case kwp2000.RESP_CHUNK_CONF_ID:
+		t.sendChunk()
+	}
+}
+
+// requestChunk collects a request sent in 6 byte chunks, the request is handled when the last chunk is in
+func (t *t7) requestChunk(data []byte) {
+	if len(data) < 2 {
+		return
+	}
+	if data[0]&0x40 == 0x40 {
+		t.request = t.request[:0]
+	}
+	t.request = append(t.request, data[2:]...)
+	if data[0]&0x3F != 0 {
+		if data[0]&0x80 == 0x80 {
+			t.a.reply(kwp2000.REQ_CHUNK_CONF_ID, 0x40, 0xA1, 0x3F, data[0]&^0x40, 0x00, 0x00, 0x00, 0x00)
+		}
+		return
+	}
+	// the length byte is not trusted, WriteDataByAddress sends one less than it should
+	if len(t.request) < 2 {
+		return
+	}
+	t.service(t.request[1], t.request[2:])
+}
+
+func (t *t7) service(sid byte, params []byte) {
+	switch sid {
+	case kwp2000.READ_DATA_BY_IDENTIFIER:
+		if len(params) < 1 || params[0] != 0xF0 {
+			t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+			return
+		}
+		t.respond(append([]byte{sid | 0x40, 0xF0}, t.ddlData()...))
+	case kwp2000.READ_MEMORY_BY_ADDRESS:
+		if len(params) < 4 {
+			t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+			return
+		}
+		address := uint32(params[0])<<16 | uint32(params[1])<<8 | uint32(params[2])
+		t.respond(append([]byte{sid | 0x40, params[0], params[1], params[2]}, t.a.mem.read(address, int(params[3]))...))
+	case kwp2000.WRITE_DATA_BY_ADDRESS:
+		if len(params) < 4 || len(params) < 4+int(params[3]) {
+			t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+			return
+		}
+		if !t.granted {
+			t.negative(sid, kwp2000.SECURITY_ACCESS_DENIED_OR_REQUESTED)
+			return
+		}
+		address := uint32(params[0])<<16 | uint32(params[1])<<8 | uint32(params[2])
+		t.a.mem.write(address, params[4:4+int(params[3])])
+		t.respond([]byte{sid | 0x40})
+	case kwp2000.DYNAMICALLY_DEFINE_IDENTIFIER:
+		t.defineLocalID(sid, params)
+	case kwp2000.SECURITY_ACCESS:
+		t.securityAccess(sid, params)
+	case kwp2000.TESTER_PRESENT, kwp2000.STOP_COM_REQ:
+		t.respond([]byte{sid | 0x40})
+	default:
+		t.negative(sid, kwp2000.SERVICE_NOT_SUPPORTED)
+	}
+}
+
+func (t *t7) defineLocalID(sid byte, params []byte) {
+	if len(params) < 2 || params[0] != 0xF0 {
+		t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+		return
+	}
+	switch params[1] {
+	case kwp2000.DM_CDDLI:
+		clear(t.ddl)
+	case kwp2000.DM_DBMA:
+		if len(params) < 7 {
+			t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+			return
+		}
+		index, length := int(params[2]), int(params[3])
+		if length == 0 && params[4] == 0x80 {
+			sym := t.a.symbolByNumber(int(params[5])<<8 | int(params[6]))
+			if sym == nil {
+				t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+				return
+			}
+			t.ddl[index] = ddlEntry{address: t.a.address(sym), length: int(sym.Length)}
+		} else {
+			t.ddl[index] = ddlEntry{address: uint32(params[4])<<16 | uint32(params[5])<<8 | uint32(params[6]), length: length}
+		}
+	default:
+		t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+		return
+	}
+	t.respond([]byte{sid | 0x40, 0xF0})
+}
+
+// ddlData returns the RAM of the defined entries in index order
+func (t *t7) ddlData() []byte {
+	var out []byte
+	for index := range 0x100 {
+		if e, ok := t.ddl[index]; ok {
+			out = append(out, t.a.mem.read(e.address, e.length)...)
+		}
+	}
+	return out
+}
+
+func (t *t7) securityAccess(sid byte, params []byte) {
+	if len(params) < 1 {
+		t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+		return
+	}
+	switch params[0] {
+	case kwp2000.DEVELOPMENT_PRIORITY:
+		t.seed = rand.IntN(0xFFFF) + 1
+		t.respond([]byte{sid | 0x40, params[0], byte(t.seed >> 8), byte(t.seed)})
+	case kwp2000.DEVELOPMENT_PRIORITY + 1:
+		if len(params) < 3 || t.seed == 0 {
+			t.negative(sid, kwp2000.INVALID_KEY)
+			return
+		}
+		key := int(params[1])<<8 | int(params[2])
+		for method := range 5 {
+			if kwp2000.CalcKey(t.seed, method) == key {
+				t.granted = true
+				t.respond([]byte{sid | 0x40, params[0], 0x34})
+				return
+			}
+		}
+		t.negative(sid, kwp2000.INVALID_KEY)
+	default:
+		t.negative(sid, kwp2000.REQUEST_OUT_OF_RANGE)
+	}
+}
+
+func (t *t7) negative(sid, code byte) {
+	t.respond([]byte{0x7F, sid, code})
+}
+
+// respond sends payload with a length byte in 6 byte chunks, the first chunk goes now and
+// every following chunk when the tester acknowledges the previous one
+func (t *t7) respond(payload []byte) {
+	t.response = append([]byte{byte(len(payload))}, payload...)
+	t.chunk = 0
+	t.sendChunk()
+}
+
+func (t *t7) sendChunk() {
+	chunks := (len(t.response) + 5) / 6
+	if t.chunk >= chunks {
+		return
+	}
+	data := make([]byte, 8)
+	data[0] = 0x80 | byte(chunks-t.chunk-1)&0x3F
+	if t.chunk == 0 {
+		data[0] |= 0x40
+	}
+	data[1] = 0xBF
+	copy(data[2:], t.response[t.chunk*6:])
+	t.chunk++
+	t.a.reply(t7ResponseID, data...)
+}
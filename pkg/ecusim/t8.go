@@ -0,0 +1,234 @@
+package ecusim
+
+import (
+	"math/rand/v2"
+
+	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/ecu/t8sec"
+)
+
+const (
+	t8RequestID  = 0x7E0
+	t8ResponseID = 0x7E8
+)
+
+// GMLAN services and negative response codes
+const (
+	gmInitiateDiagnosticOperation = 0x10
+	gmReadDataByIdentifier        = 0x1A
+	gmReturnToNormalMode          = 0x20
+	gmReadMemoryByAddress         = 0x23
+	gmSecurityAccess              = 0x27
+	gmWriteDataByIdentifier       = 0x3B
+	gmTesterPresent               = 0x3E
+
+	gmServiceNotSupported = 0x11
+	gmRequestOutOfRange   = 0x31
+	gmSecurityDenied      = 0x33
+	gmInvalidKey          = 0x35
+
+	t8SecurityLevel = 0xFD
+	// z22seSecurityLevel is the level the Z22SE logger asks for
+	z22seSecurityLevel = 0x01
+)
+
+// t8 answers the GMLAN services used by the Trionic 8 logger over ISO-TP
+type t8 struct {
+	a *Adapter
+
+	request   []byte // multi frame request being received
+	requestSz int
+	blockSize byte
+
+	response []byte // consecutive frames waiting for the flow control from the tester
+	seq      byte
+
+	seed    []byte
+	level   byte // security access level the seed was sent for
+	granted bool
+
+	symbols []ddlEntry // the dynamically defined register 0x18
+}
+
+func newT8(a *Adapter) *t8 {
+	return &t8{a: a}
+}
+
+func (t *t8) handle(f *gocan.CANFrame) {
+	if f.Identifier != t8RequestID || len(f.Data) == 0 {
+		return
+	}
+	d := f.Data
+	switch d[0] & 0xF0 {
+	case 0x00: // single frame
+		n := int(d[0])
+		if n == 0 || n >= len(d) {
+			return
+		}
+		t.service(d[1 : 1+n])
+	case 0x10: // first frame
+		if len(d) < 3 {
+			return
+		}
+		t.requestSz = int(d[0]&0x0F)<<8 | int(d[1])
+		t.request = append(t.request[:0], d[2:]...)
+		// writes by address want flow control after every consecutive frame
+		t.blockSize = 0x00
+		if len(d) > 3 && d[2] == gmWriteDataByIdentifier && d[3] == 0x15 {
+			t.blockSize = 0x01
+		}
+		t.reply(0x30, t.blockSize, 0x00)
+	case 0x20: // consecutive frame
+		if t.requestSz == 0 {
+			return
+		}
+		t.request = append(t.request, d[1:]...)
+		if len(t.request) < t.requestSz {
+			if t.blockSize != 0 {
+				t.reply(0x30, t.blockSize, 0x00)
+			}
+			return
+		}
+		req := t.request[:t.requestSz]
+		t.requestSz = 0
+		t.service(req)
+	case 0x30: // flow control, send the rest of a multi frame response
+		t.sendConsecutive()
+	}
+}
+
+func (t *t8) service(req []byte) {
+	sid := req[0]
+	params := req[1:]
+	switch sid {
+	case gmInitiateDiagnosticOperation:
+		t.reply(0x01, sid|0x40)
+	case gmReturnToNormalMode:
+		t.granted = false
+		// sent without padding, a padded response reads as busy to the tester
+		t.a.reply(t8ResponseID, 0x01, sid|0x40)
+	case gmTesterPresent:
+		t.reply(0x01, sid|0x40)
+	case gmSecurityAccess:
+		t.securityAccess(sid, params)
+	case gmReadDataByIdentifier:
+		if len(params) < 1 || params[0] != 0x18 {
+			t.negative(sid, gmRequestOutOfRange)
+			return
+		}
+		var data []byte
+		for _, e := range t.symbols {
+			data = append(data, t.a.mem.read(e.address, e.length)...)
+		}
+		t.respond(append([]byte{sid | 0x40, params[0]}, data...))
+	case gmReadMemoryByAddress:
+		if len(params) < 5 {
+			t.negative(sid, gmRequestOutOfRange)
+			return
+		}
+		address := uint32(params[0])<<16 | uint32(params[1])<<8 | uint32(params[2])
+		length := int(params[3])<<8 | int(params[4])
+		t.respond(append([]byte{sid | 0x40, params[0], params[1], params[2]}, t.a.mem.read(address, length)...))
+	case gmWriteDataByIdentifier:
+		t.writeDataByIdentifier(sid, params)
+	default:
+		t.negative(sid, gmServiceNotSupported)
+	}
+}
+
+func (t *t8) writeDataByIdentifier(sid byte, params []byte) {
+	if len(params) < 1 {
+		t.negative(sid, gmRequestOutOfRange)
+		return
+	}
+	switch pid, data := params[0], params[1:]; {
+	case pid == 0x15: // write by address
+		if len(data) < 4 || len(data) < 4+int(data[3]) {
+			t.negative(sid, gmRequestOutOfRange)
+			return
+		}
+		if !t.granted {
+			t.negative(sid, gmSecurityDenied)
+			return
+		}
+		address := uint32(data[0])<<16 | uint32(data[1])<<8 | uint32(data[2])
+		t.a.mem.write(address, data[4:4+int(data[3])])
+	case pid == 0x17 && len(data) >= 2 && data[0] == 0xF0 && data[1] == 0x04: // clear the dynamic register
+		t.symbols = t.symbols[:0]
+	case pid == 0x17 && len(data) >= 7 && data[0] == 0xF0 && data[1] == 0x80: // add a symbol by number
+		sym := t.a.symbolByNumber(int(data[5])<<8 | int(data[6]))
+		if sym == nil {
+			t.negative(sid, gmRequestOutOfRange)
+			return
+		}
+		t.symbols = append(t.symbols, ddlEntry{address: t.a.address(sym), length: int(sym.Length)})
+	default:
+		t.negative(sid, gmRequestOutOfRange)
+		return
+	}
+	t.reply(0x02, sid|0x40, params[0])
+}
+
+func (t *t8) securityAccess(sid byte, params []byte) {
+	if len(params) < 1 {
+		t.negative(sid, gmRequestOutOfRange)
+		return
+	}
+	switch params[0] {
+	case t8SecurityLevel, z22seSecurityLevel:
+		if t.granted {
+			t.reply(0x04, sid|0x40, params[0], 0x00, 0x00)
+			return
+		}
+		seed := rand.IntN(0xFFFF) + 1
+		t.seed = []byte{byte(seed >> 8), byte(seed)}
+		t.level = params[0]
+		t.reply(0x04, sid|0x40, params[0], t.seed[0], t.seed[1])
+	case t8SecurityLevel + 1, z22seSecurityLevel + 1:
+		if len(params) < 3 || t.seed == nil || params[0] != t.level+1 {
+			t.negative(sid, gmInvalidKey)
+			return
+		}
+		high, low := t8sec.CalculateAccessKey(t.seed, t.level)
+		if params[1] != high || params[2] != low {
+			t.negative(sid, gmInvalidKey)
+			return
+		}
+		t.granted = true
+		t.reply(0x02, sid|0x40, params[0])
+	default:
+		t.negative(sid, gmRequestOutOfRange)
+	}
+}
+
+func (t *t8) negative(sid, code byte) {
+	t.reply(0x03, 0x7F, sid, code)
+}
+
+// respond sends payload as a single frame or as a first frame, the consecutive frames
+// are sent when the tester answers with flow control
+func (t *t8) respond(payload []byte) {
+	if len(payload) <= 7 {
+		t.reply(append([]byte{byte(len(payload))}, payload...)...)
+		return
+	}
+	t.reply(append([]byte{0x10 | byte(len(payload)>>8)&0x0F, byte(len(payload))}, payload[:6]...)...)
+	t.response = payload[6:]
+	t.seq = 0x21
+}
+
+func (t *t8) sendConsecutive() {
+	for len(t.response) > 0 {
+		n := min(len(t.response), 7)
+		t.reply(append([]byte{t.seq}, t.response[:n]...)...)
+		t.response = t.response[n:]
+		t.seq = 0x20 | ((t.seq + 1) & 0x0F)
+	}
+}
+
+// reply sends a frame padded to 8 bytes
+func (t *t8) reply(data ...byte) {
+	frame := make([]byte, 8)
+	copy(frame, data)
+	t.a.reply(t8ResponseID, frame...)
+}
@@ -1,5 +1,10 @@
 package eventbus
 
+import "time"
+
+// AllTopics is the topic of aggregators that see every published value
+const AllTopics = "*"
+
 type EventAggregatorFunc func(c DiffPublisher, name string, value float64)
 
 type DiffPublisher interface {
@@ -16,6 +21,14 @@ func (e *EventAggregator) GetTopics() []string {
 	return e.topics
 }
 
+// NewAggregator calls fn with every value published on topics
+func NewAggregator(topics []string, fn EventAggregatorFunc) *EventAggregator {
+	return &EventAggregator{
+		topics: topics,
+		fun:    fn,
+	}
+}
+
 func DIFFAggregator(first, second, output string) *EventAggregator {
 	var firstUpdated, secondUpdated bool
 	var firstValue, secondValue float64
@@ -41,3 +54,55 @@ func DIFFAggregator(first, second, output string) *EventAggregator {
 		},
 	}
 }
+
+// Expression is a calculation over other topics, implemented by math channels
+type Expression interface {
+	Vars() []string
+	Eval(ts time.Time, get func(name string) (float64, bool)) (float64, bool)
+}
+
+// constantInterval is how often an expression without inputs is published
+const constantInterval = time.Second
+
+// ExpressionAggregator publishes expr as output on every update of a topic it uses, with the last value of the
+// others, once all of them have been published. An expression without topics is a constant, it is published along
+// with everything else at most once per constantInterval
+func ExpressionAggregator(output string, expr Expression) *EventAggregator {
+	topics := expr.Vars()
+	if len(topics) == 0 {
+		var last time.Time
+		none := func(string) (float64, bool) { return 0, false }
+		return &EventAggregator{
+			topics: []string{AllTopics},
+			fun: func(c DiffPublisher, name string, _ float64) {
+				now := time.Now()
+				if name == output || now.Sub(last) < constantInterval {
+					return
+				}
+				last = now
+				if v, ok := expr.Eval(now, none); ok {
+					c.Publish(output, v)
+				}
+			},
+		}
+	}
+
+	values := make(map[string]float64, len(topics))
+	get := func(name string) (float64, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+
+	return &EventAggregator{
+		topics: topics,
+		fun: func(c DiffPublisher, name string, value float64) {
+			values[name] = value
+			if len(values) < len(topics) {
+				return
+			}
+			if v, ok := expr.Eval(time.Now(), get); ok {
+				c.Publish(output, v)
+			}
+		},
+	}
+}
@@ -2,6 +2,8 @@ package eventbus
 
 import (
 	"log"
+	"maps"
+	"slices"
 	"sync"
 )
 
@@ -39,6 +41,10 @@ type Controller struct {
 	quit      chan struct{}
 
 	onMessage func(string, float64)
+
+	// last value of every topic
+	values     map[string]float64
+	valuesLock sync.RWMutex
 }
 
 type newSub struct {
@@ -59,6 +65,7 @@ func New(cfg *Config) *Controller {
 		//cache:           ttlcache.New[string, float64](ttlcache.WithTTL[string, float64](cfg.CacheTTL)),
 		quit:            make(chan struct{}),
 		aggregatorIndex: make(map[string][]*EventAggregator),
+		values:          make(map[string]float64),
 	}
 
 	// Register default aggregators
@@ -96,7 +103,9 @@ func (e *Controller) handleMessage(msg *EBusMessage) {
 	if f := e.onMessage; f != nil {
 		f(msg.Topic, msg.Data)
 	}
-	//e.cache.Set(msg.Topic, msg.Data, ttlcache.DefaultTTL)
+	e.valuesLock.Lock()
+	e.values[msg.Topic] = msg.Data
+	e.valuesLock.Unlock()
 	// Get subscribers
 
 	for _, sub := range e.subs[msg.Topic] {
@@ -107,14 +116,17 @@ func (e *Controller) handleMessage(msg *EBusMessage) {
 		}
 	}
 
-	// Process aggregators
-	//e.aggregatorLock.RLock()
-	if aggregators, exists := e.aggregatorIndex[msg.Topic]; exists {
-		for _, agg := range aggregators {
-			agg.fun(e, msg.Topic, msg.Data)
-		}
+	// Process aggregators, math channels are registered and unregistered at runtime
+	e.aggregatorLock.RLock()
+	aggregators := e.aggregatorIndex[msg.Topic]
+	all := e.aggregatorIndex[AllTopics]
+	e.aggregatorLock.RUnlock()
+	for _, agg := range aggregators {
+		agg.fun(e, msg.Topic, msg.Data)
+	}
+	for _, agg := range all {
+		agg.fun(e, msg.Topic, msg.Data)
 	}
-	//e.aggregatorLock.RUnlock()
 }
 
 func (e *Controller) handleSubscription(sub newSub) {
@@ -179,6 +191,24 @@ func (e *Controller) RegisterAggregator(aggs ...*EventAggregator) {
 	}
 }
 
+// UnregisterAggregator removes aggregators added with RegisterAggregator
+func (e *Controller) UnregisterAggregator(aggs ...*EventAggregator) {
+	e.aggregatorLock.Lock()
+	defer e.aggregatorLock.Unlock()
+	for _, agg := range aggs {
+		for _, topic := range agg.GetTopics() {
+			list := slices.DeleteFunc(slices.Clone(e.aggregatorIndex[topic]), func(a *EventAggregator) bool {
+				return a == agg
+			})
+			if len(list) == 0 {
+				delete(e.aggregatorIndex, topic)
+				continue
+			}
+			e.aggregatorIndex[topic] = list
+		}
+	}
+}
+
 func (e *Controller) Publish(topic string, data float64) {
 	select {
 	case e.incoming <- &EBusMessage{Topic: topic, Data: data}:
@@ -213,12 +243,9 @@ func (e *Controller) Unsubscribe(channel chan float64) {
 	e.unsub <- channel
 }
 
-/*
+// Values returns a copy of the last value published on every topic
 func (e *Controller) Values() map[string]float64 {
-	values := make(map[string]float64)
-	for k, v := range e.cache.Items() {
-		values[k] = v.Value()
-	}
-	return values
+	e.valuesLock.RLock()
+	defer e.valuesLock.RUnlock()
+	return maps.Clone(e.values)
 }
-*/
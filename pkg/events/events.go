@@ -0,0 +1,173 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+const prefsEventRules = "eventRules"
+
+// Condition is how a rule compares its channel
+type Condition string
+
+const (
+	// Increases matches when the channel is higher than in the previous record
+	Increases Condition = "increases"
+	// AtLeast matches when the channel is at or above the threshold
+	AtLeast Condition = ">="
+	// AtMost matches when the channel is at or below the threshold
+	AtMost Condition = "<="
+)
+
+// Conditions lists the valid conditions in the order they are shown to the user
+var Conditions = []Condition{Increases, AtLeast, AtMost}
+
+// Rule describes an event in a log. An event is reported when the condition starts to match,
+// it has to stop matching before the rule can trigger again.
+type Rule struct {
+	Name      string    `json:"name"`
+	Channel   string    `json:"channel"`
+	Condition Condition `json:"condition"`
+	Threshold float64   `json:"threshold,omitempty"`
+	// When is an optional channel that must be at or above WhenAtLeast for the rule to match,
+	// used to only look at lambda under load or fuel cuts above idle
+	When        string  `json:"when,omitempty"`
+	WhenAtLeast float64 `json:"whenAtLeast,omitempty"`
+	Disabled    bool    `json:"disabled,omitempty"`
+}
+
+// Validate checks that the rule can be used
+func (r Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("event rule for %q has no name", r.Channel)
+	}
+	if strings.TrimSpace(r.Channel) == "" {
+		return fmt.Errorf("event rule %s has no channel", r.Name)
+	}
+	switch r.Condition {
+	case Increases, AtLeast, AtMost:
+	default:
+		return fmt.Errorf("event rule %s has unknown condition %q", r.Name, r.Condition)
+	}
+	return nil
+}
+
+// Event is a rule hit in a log
+type Event struct {
+	Pos   int // record index in the log
+	Time  time.Time
+	Rule  string
+	Value float64
+}
+
+// Scan runs rules over all records of lf and returns the events in record order, lf is rewound afterwards
+func Scan(lf logfile.Logfile, rules []Rule) []Event {
+	var evs []Event
+	active := make([]bool, len(rules))
+	last := make([]float64, len(rules))
+	seen := make([]bool, len(rules))
+
+	lf.Seek(-1)
+	for pos := 0; ; pos++ {
+		rec := lf.Next()
+		if rec.EOF {
+			break
+		}
+		for i, r := range rules {
+			if r.Disabled {
+				continue
+			}
+			v, ok := rec.Values[r.Channel]
+			if !ok {
+				continue
+			}
+			match := false
+			switch r.Condition {
+			case Increases:
+				match = seen[i] && v > last[i]
+			case AtLeast:
+				match = v >= r.Threshold
+			case AtMost:
+				match = v <= r.Threshold
+			}
+			if match && r.When != "" {
+				w, ok := rec.Values[r.When]
+				match = ok && w >= r.WhenAtLeast
+			}
+			if match && !active[i] {
+				evs = append(evs, Event{Pos: pos, Time: rec.Time, Rule: r.Name, Value: v})
+			}
+			active[i] = match
+			last[i] = v
+			seen[i] = true
+		}
+	}
+	lf.Seek(-1)
+	return evs
+}
+
+// Defaults returns the event rules a new installation starts out with
+func Defaults(ecu string) []Rule {
+	switch ecu {
+	case "T5":
+		return []Rule{
+			{Name: "Knock", Channel: "Knock_offset1234", Condition: Increases},
+			{Name: "Alarm", Channel: datalogger.ALARMSYM, Condition: Increases},
+			{Name: "Fuel cut", Channel: "Insptid_ms10", Condition: AtMost, Threshold: 0, When: "Rpm", WhenAtLeast: 1500},
+			{Name: "Lean under load", Channel: datalogger.EXTERNALWBLSYM, Condition: AtLeast, Threshold: 0.9, When: "P_medel", WhenAtLeast: 0.5},
+			{Name: "Overboost", Channel: "P_medel", Condition: AtLeast, Threshold: 1.6},
+		}
+	case "T7":
+		return []Rule{
+			{Name: "Knock", Channel: "KnkDet.KnockCyl", Condition: Increases},
+			{Name: "Alarm", Channel: datalogger.ALARMSYM, Condition: Increases},
+			{Name: "Limiter", Channel: "ECMStat.ST_ActiveAirDem", Condition: AtLeast, Threshold: 20},
+			{Name: "Fuel cut", Channel: "Myrtilos.InjectorDutyCycle", Condition: AtMost, Threshold: 0, When: "ActualIn.n_Engine", WhenAtLeast: 1500},
+			{Name: "Lean under load", Channel: datalogger.EXTERNALWBLSYM, Condition: AtLeast, Threshold: 0.9, When: "MAF.m_AirInlet", WhenAtLeast: 500},
+			{Name: "Overboost", Channel: "In.p_AirBefThrottle", Condition: AtLeast, Threshold: 1.6},
+		}
+	case "T8":
+		return []Rule{
+			{Name: "Knock", Channel: "KnkDet.KnockCyl", Condition: Increases},
+			{Name: "Alarm", Channel: datalogger.ALARMSYM, Condition: Increases},
+			{Name: "Limiter", Channel: "ECMStat.ST_ActiveAirDem", Condition: AtLeast, Threshold: 20},
+			{Name: "Lean under load", Channel: datalogger.EXTERNALWBLSYM, Condition: AtLeast, Threshold: 0.9, When: "MAF.m_AirInlet", WhenAtLeast: 500},
+			{Name: "Overboost", Channel: "ActualIn.p_AirBefThrottle", Condition: AtLeast, Threshold: 1.6},
+		}
+	}
+	return nil
+}
+
+// Load returns the event rules stored for ecu
+func Load(prefs fyne.Preferences, ecu string) ([]Rule, error) {
+	data := prefs.String(prefsEventRules + ecu)
+	if data == "" {
+		return Defaults(ecu), nil
+	}
+	var rules []Rule
+	if err := json.Unmarshal([]byte(data), &rules); err != nil {
+		return Defaults(ecu), fmt.Errorf("failed to load event rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Save stores the event rules for ecu
+func Save(prefs fyne.Preferences, ecu string, rules []Rule) error {
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	prefs.SetString(prefsEventRules+ecu, string(data))
+	return nil
+}
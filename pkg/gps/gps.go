@@ -0,0 +1,125 @@
+// Package gps reads position and speed from an NMEA-0183 receiver on a serial port or over TCP
+package gps
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+// DefaultBaudrate is what most NMEA receivers talk at out of the box
+const DefaultBaudrate = 9600
+
+// staleAfter is how long a fix is used without a new position before it counts as lost
+const staleAfter = 3 * time.Second
+
+// Client reads sentences from a receiver and keeps the latest fix
+type Client struct {
+	port     string
+	baudrate int
+
+	conn io.ReadCloser
+
+	mu  sync.Mutex
+	fix Fix
+
+	log       func(string)
+	closeOnce sync.Once
+}
+
+// NewNMEAClient creates a client for port, a serial port or host:port of a receiver serving NMEA over TCP
+// like GPSd in raw mode or a phone app
+func NewNMEAClient(port string, baudrate int, logFunc func(string)) *Client {
+	if baudrate <= 0 {
+		baudrate = DefaultBaudrate
+	}
+	return &Client{
+		port:     port,
+		baudrate: baudrate,
+		log:      logFunc,
+	}
+}
+
+// isTCP reports if port is a receiver on the network, tcp://host:port or host:port. Serial device paths like
+// /dev/serial/by-path/pci-0000:00:14.0-usb-0:1:1.0 contain colons too
+func isTCP(port string) bool {
+	if strings.HasPrefix(port, "tcp://") {
+		return true
+	}
+	if strings.HasPrefix(port, "/") {
+		return false
+	}
+	_, _, err := net.SplitHostPort(port)
+	return err == nil
+}
+
+func (c *Client) Start(ctx context.Context) error {
+	if isTCP(c.port) {
+		var d net.Dialer
+		dctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		conn, err := d.DialContext(dctx, "tcp", strings.TrimPrefix(c.port, "tcp://"))
+		if err != nil {
+			return fmt.Errorf("gps: %w", err)
+		}
+		c.conn = conn
+	} else {
+		sp, err := serial.Open(c.port, &serial.Mode{BaudRate: c.baudrate})
+		if err != nil {
+			return fmt.Errorf("gps: %w", err)
+		}
+		c.conn = sp
+	}
+	go func() {
+		<-ctx.Done()
+		c.Stop()
+	}()
+	go c.run()
+	return nil
+}
+
+func (c *Client) run() {
+	sc := bufio.NewScanner(c.conn)
+	for sc.Scan() {
+		c.mu.Lock()
+		err := parseSentence(sc.Text(), &c.fix, time.Now())
+		c.mu.Unlock()
+		if err == errChecksum {
+			c.log("GPS: " + err.Error())
+		}
+	}
+	if err := sc.Err(); err != nil && !strings.Contains(err.Error(), "closed") {
+		c.log("GPS read error: " + err.Error())
+	}
+}
+
+// Fix returns the latest fix, the quality is 0 when there has been no position for a while
+func (c *Client) Fix() Fix {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f := c.fix
+	if time.Since(f.Updated) > staleAfter {
+		f.Quality = 0
+	}
+	return f
+}
+
+func (c *Client) Stop() {
+	c.closeOnce.Do(func() {
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	})
+}
+
+func (c *Client) String() string {
+	f := c.Fix()
+	return fmt.Sprintf("Lat: %.6f, Lon: %.6f, Speed: %.1f km/h, Heading: %.0f, Fix: %d, Satellites: %d", f.Latitude, f.Longitude, f.Speed, f.Heading, f.Quality, f.Satellites)
+}
@@ -0,0 +1,148 @@
+package gps
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const knotsToKmh = 1.852
+
+// Fix is the latest position reported by the receiver
+type Fix struct {
+	Latitude  float64 // degrees, negative south
+	Longitude float64 // degrees, negative west
+	Speed     float64 // km/h
+	Heading   float64 // degrees from true north
+	// Quality is the GGA fix quality, 0 no fix, 1 GPS, 2 DGPS and up for RTK
+	Quality    int
+	Satellites int
+	// Updated is when a sentence with a position was last parsed
+	Updated time.Time
+}
+
+var (
+	errChecksum    = errors.New("checksum mismatch")
+	errNotSentence = errors.New("not an NMEA sentence")
+)
+
+// parseSentence updates fix from a RMC, GGA or VTG sentence of any talker, other sentences are ignored
+func parseSentence(line string, fix *Fix, now time.Time) error {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "$") {
+		return errNotSentence
+	}
+	body := line[1:]
+	if i := strings.IndexByte(body, '*'); i >= 0 {
+		sum, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil {
+			return fmt.Errorf("invalid checksum %q", body[i+1:])
+		}
+		var x byte
+		for j := 0; j < i; j++ {
+			x ^= body[j]
+		}
+		if x != byte(sum) {
+			return errChecksum
+		}
+		body = body[:i]
+	}
+	f := strings.Split(body, ",")
+	if len(f[0]) != 5 {
+		return errNotSentence
+	}
+	switch f[0][2:] {
+	case "RMC":
+		// $GPRMC,time,status,lat,N,lon,E,knots,course,date,...
+		if len(f) < 9 {
+			return fmt.Errorf("short RMC sentence")
+		}
+		if f[2] != "A" {
+			fix.Quality = 0
+			return nil
+		}
+		lat, lon, err := parsePosition(f[3], f[4], f[5], f[6])
+		if err != nil {
+			return err
+		}
+		fix.Latitude, fix.Longitude = lat, lon
+		if v, err := strconv.ParseFloat(f[7], 64); err == nil {
+			fix.Speed = v * knotsToKmh
+		}
+		if v, err := strconv.ParseFloat(f[8], 64); err == nil {
+			fix.Heading = v
+		}
+		if fix.Quality == 0 {
+			fix.Quality = 1
+		}
+		fix.Updated = now
+	case "GGA":
+		// $GPGGA,time,lat,N,lon,E,quality,satellites,hdop,altitude,...
+		if len(f) < 8 {
+			return fmt.Errorf("short GGA sentence")
+		}
+		quality, err := strconv.Atoi(f[6])
+		if err != nil {
+			return fmt.Errorf("invalid fix quality %q", f[6])
+		}
+		fix.Quality = quality
+		fix.Satellites, _ = strconv.Atoi(f[7])
+		if quality == 0 {
+			return nil
+		}
+		lat, lon, err := parsePosition(f[2], f[3], f[4], f[5])
+		if err != nil {
+			return err
+		}
+		fix.Latitude, fix.Longitude = lat, lon
+		fix.Updated = now
+	case "VTG":
+		// $GPVTG,course,T,course,M,knots,N,kmh,K
+		if len(f) < 8 {
+			return fmt.Errorf("short VTG sentence")
+		}
+		if v, err := strconv.ParseFloat(f[1], 64); err == nil {
+			fix.Heading = v
+		}
+		if v, err := strconv.ParseFloat(f[7], 64); err == nil {
+			fix.Speed = v
+		}
+	}
+	return nil
+}
+
+// parsePosition converts ddmm.mmmm and dddmm.mmmm with their hemispheres to degrees
+func parsePosition(lat, ns, lon, ew string) (float64, float64, error) {
+	la, err := parseDegrees(lat, 2)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid latitude %q", lat)
+	}
+	lo, err := parseDegrees(lon, 3)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid longitude %q", lon)
+	}
+	if ns == "S" {
+		la = -la
+	}
+	if ew == "W" {
+		lo = -lo
+	}
+	return la, lo, nil
+}
+
+func parseDegrees(s string, degDigits int) (float64, error) {
+	if len(s) < degDigits+2 {
+		return 0, errors.New("too short")
+	}
+	deg, err := strconv.Atoi(s[:degDigits])
+	if err != nil {
+		return 0, err
+	}
+	min, err := strconv.ParseFloat(s[degDigits:], 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(deg) + min/60, nil
+}
@@ -2,6 +2,8 @@ package logfile
 
 import (
 	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
 )
 
 type BaseLogfile struct {
@@ -9,6 +11,14 @@ type BaseLogfile struct {
 	length  int
 	pos     int
 	end     int
+	meta    *datalogger.Metadata
+}
+
+func (l *BaseLogfile) Metadata() *datalogger.Metadata {
+	if l.meta == nil {
+		l.meta = &datalogger.Metadata{}
+	}
+	return l.meta
 }
 
 func (l *BaseLogfile) Get() Record {
@@ -53,6 +63,10 @@ func (l *BaseLogfile) Pos() int {
 	return max(l.pos, 0)
 }
 
+func (l *BaseLogfile) Cursor() int {
+	return l.pos
+}
+
 func (l *BaseLogfile) Len() int {
 	return l.length
 }
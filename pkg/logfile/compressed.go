@@ -0,0 +1,35 @@
+package logfile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// openCompressed opens a log compressed with gzip or zstd, the name without the
+// compression extension decides the log format
+func openCompressed(filename string, reader io.Reader) (Logfile, error) {
+	ext := path.Ext(filename)
+	inner := strings.TrimSuffix(filename, ext)
+	switch strings.ToLower(ext) {
+	case ".gz":
+		zr, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip log: %w", err)
+		}
+		defer zr.Close()
+		return Open(inner, zr)
+	case ".zst":
+		zr, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zstd log: %w", err)
+		}
+		defer zr.Close()
+		return Open(inner, zr)
+	}
+	return nil, fmt.Errorf("Unsupported filetype")
+}
@@ -28,6 +28,7 @@ func NewFromCSVLogfile(reader io.Reader) (Logfile, error) {
 
 func (l *CSVLogfile) parseCSVLogfile(reader io.Reader) error {
 	r := csv.NewReader(reader)
+	r.Comment = '#'
 
 	records, err := r.ReadAll()
 	if err != nil {
@@ -0,0 +1,56 @@
+package logfile
+
+import (
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+// Export writes all records of lf to w and closes w. Channels missing from a record keep
+// their previous value since the log writers expect a value for every channel, channels
+// without a value yet are left empty if w supports it. The metadata of lf is written as a header
+// if withMetadata is set and w supports it.
+func Export(lf Logfile, w datalogger.LogWriter, withMetadata bool) error {
+	meta := lf.Metadata()
+	if mw, ok := w.(datalogger.MetadataWriter); ok && withMetadata {
+		mw.SetMetadata(meta)
+	}
+	order := Channels(lf)
+	values := datalogger.NewThreadSafeMap()
+	if ew, ok := w.(datalogger.ExportWriter); ok {
+		ew.SetExport(exportPrecision(order, meta))
+	} else {
+		for _, name := range order {
+			values.Set(name, 0)
+		}
+	}
+
+	lf.Seek(-1)
+	defer lf.Seek(-1)
+	for {
+		rec := lf.Next()
+		if rec.EOF {
+			break
+		}
+		for k, v := range rec.Values {
+			values.Set(k, v)
+		}
+		if err := w.Write(values, order, nil, rec.Time); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// exportPrecision returns the decimals of the channels in order. Symbols with a correction factor get the decimals
+// they are logged with, the rest as many as the value needs so nothing read from lf is rounded
+func exportPrecision(order []string, meta *datalogger.Metadata) map[string]int {
+	precision := make(map[string]int, len(order))
+	for _, name := range order {
+		precision[name] = -1
+		if ch, ok := meta.Channel(name); ok && ch.Correctionfactor != 0 && ch.Correctionfactor != 1 {
+			precision[name] = symbol.GetPrecision(ch.Correctionfactor)
+		}
+	}
+	return precision
+}
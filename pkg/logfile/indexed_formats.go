@@ -0,0 +1,148 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+// NewIndexedCSVLogfile opens a CSV logfile written by the CSV log writer
+func NewIndexedCSVLogfile(reader io.Reader) (Logfile, error) {
+	src, closer, err := newSource(reader)
+	if err != nil {
+		return nil, err
+	}
+	header, err := readCSVHeader(src)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+	return newIndexedLogfile(src, closer, &csvLineFormat{header: header})
+}
+
+// NewIndexedTxLogfile opens a T5L, T7L or T8L logfile
+func NewIndexedTxLogfile(reader io.Reader) (Logfile, error) {
+	src, closer, err := newSource(reader)
+	if err != nil {
+		return nil, err
+	}
+	timeFormat, err := detectTxTimeFormat(src)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+	return newIndexedLogfile(src, closer, &txLineFormat{timeFormat: timeFormat})
+}
+
+func readCSVHeader(src sourceReader) ([]string, error) {
+	r := csv.NewReader(io.NewSectionReader(src, 0, src.Size()))
+	r.FieldsPerRecord = -1
+	// metadata lines written before the header
+	r.Comment = '#'
+	header, err := r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("no lines in file")
+		}
+		return nil, err
+	}
+	return header, nil
+}
+
+func detectTxTimeFormat(src sourceReader) (string, error) {
+	scanner := bufio.NewScanner(io.NewSectionReader(src, 0, src.Size()))
+	scanner.Buffer(make([]byte, 64*1024), indexMaxLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, datalogger.MetadataPrefix) {
+			continue
+		}
+		return detectTimeFormat(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no lines in file")
+}
+
+type csvLineFormat struct {
+	header []string
+}
+
+func (f *csvLineFormat) parseTime(line []byte) (time.Time, error) {
+	var field []byte
+	if len(line) > 0 && line[0] == '"' {
+		end := bytes.IndexByte(line[1:], '"')
+		if end < 0 {
+			return time.Time{}, errors.New("unterminated time field")
+		}
+		field = line[1 : end+1]
+	} else if idx := bytes.IndexByte(line, ','); idx >= 0 {
+		field = line[:idx]
+	} else {
+		field = line
+	}
+	return time.Parse(datalogger.ISONICO, string(field))
+}
+
+func (f *csvLineFormat) parseValues(line []byte, set func(string, float64)) error {
+	r := csv.NewReader(bytes.NewReader(firstLine(line)))
+	r.FieldsPerRecord = -1
+	fields, err := r.Read()
+	if err != nil {
+		return err
+	}
+	for j := 1; j < len(fields) && j < len(f.header); j++ {
+		// exported logs leave channels empty until their first value
+		if fields[j] == "" {
+			continue
+		}
+		val, err := strconv.ParseFloat(fields[j], 64)
+		if err != nil {
+			return err
+		}
+		set(f.header[j], val)
+	}
+	return nil
+}
+
+type txLineFormat struct {
+	timeFormat string
+}
+
+func (f *txLineFormat) parseTime(line []byte) (time.Time, error) {
+	idx := bytes.IndexByte(line, '|')
+	if idx < 0 {
+		idx = len(line)
+	}
+	return time.Parse(f.timeFormat, string(line[:idx]))
+}
+
+func (f *txLineFormat) parseValues(line []byte, set func(string, float64)) error {
+	_, rawValues, err := splitTxLogLine(string(firstLine(line)), f.timeFormat)
+	if err != nil {
+		return err
+	}
+	for _, kv := range rawValues {
+		if strings.HasPrefix(kv, "IMPORTANTLINE") {
+			continue
+		}
+		key, value, err := parseCommaValue(kv)
+		if err != nil {
+			return err
+		}
+		set(key, value)
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+var _ Logfile = (*IndexedLogfile)(nil)
+
+const (
+	// number of records parsed together when a record is first accessed
+	indexBlockSize = 1024
+	// number of parsed blocks kept in memory
+	indexCachedBlocks = 16
+	// longest line we accept in a text logfile
+	indexMaxLineSize = 4 * 1024 * 1024
+)
+
+// recordFormat describes how a single record of a logfile is decoded
+type recordFormat interface {
+	// parseTime returns the timestamp of a record, an error means the line is not a record
+	parseTime(rec []byte) (time.Time, error)
+	// parseValues calls set for every channel value found in the record.
+	// Text records may be followed by the line ending and any skipped lines.
+	parseValues(rec []byte, set func(name string, value float64)) error
+}
+
+// sourceReader is a random access view of a logfile
+type sourceReader interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// IndexedLogfile is a Logfile that only keeps a byte offset and timestamp per record in memory.
+// Records are parsed on demand in blocks and the channel values are stored column wise.
+type IndexedLogfile struct {
+	src    sourceReader
+	closer io.Closer
+	format recordFormat
+
+	offsets []int64 // byte offset of each record
+	times   []int64 // unix milliseconds of each record
+
+	names   []string
+	nameIdx map[string]int
+
+	meta *datalogger.Metadata
+
+	blocks   map[int]*recordBlock
+	blockLRU []int
+
+	length int
+	pos    int
+	end    int
+
+	mu sync.Mutex
+}
+
+type recordBlock struct {
+	// columns[channel][record], NaN marks a value missing from the record
+	columns [][]float64
+}
+
+func newIndexedLogfile(src sourceReader, closer io.Closer, format recordFormat) (*IndexedLogfile, error) {
+	l := &IndexedLogfile{
+		src:     src,
+		closer:  closer,
+		format:  format,
+		nameIdx: make(map[string]int),
+		blocks:  make(map[int]*recordBlock),
+		meta:    &datalogger.Metadata{},
+		pos:     -1,
+	}
+	if err := l.buildIndex(); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// newSource returns a random access source for reader. Files are reopened so the caller
+// is free to close its own handle, anything else is buffered in memory.
+func newSource(reader io.Reader) (sourceReader, io.Closer, error) {
+	if f, ok := reader.(*os.File); ok {
+		if nf, err := os.Open(f.Name()); err == nil {
+			fi, err := nf.Stat()
+			if err != nil {
+				nf.Close()
+				return nil, nil, err
+			}
+			return io.NewSectionReader(nf, 0, fi.Size()), nf, nil
+		}
+	}
+	if sr, ok := reader.(sourceReader); ok {
+		return sr, nil, nil
+	}
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read logfile: %w", err)
+	}
+	return bytes.NewReader(b), nil, nil
+}
+
+func (l *IndexedLogfile) buildIndex() error {
+	scanner := bufio.NewScanner(io.NewSectionReader(l.src, 0, l.src.Size()))
+	scanner.Buffer(make([]byte, 64*1024), indexMaxLineSize)
+	scanner.Split(scanRawLines)
+
+	var offset int64
+	var lastErr error
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		line := trimLine(raw)
+		if len(l.offsets) == 0 && l.meta.ParseLine(string(line)) {
+			offset += int64(len(raw))
+			continue
+		}
+		if len(line) > 0 {
+			if ts, err := l.format.parseTime(line); err == nil {
+				l.offsets = append(l.offsets, offset)
+				l.times = append(l.times, ts.UnixMilli())
+			} else {
+				lastErr = err
+			}
+		}
+		offset += int64(len(raw))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	l.length = len(l.offsets)
+	l.end = l.length - 1
+
+	if l.length == 0 {
+		if lastErr != nil {
+			return lastErr
+		}
+		return errors.New("no records in file")
+	}
+	return nil
+}
+
+// scanRawLines is bufio.ScanLines but keeps the line ending so offsets can be tracked
+func scanRawLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func trimLine(line []byte) []byte {
+	return bytes.TrimRight(line, "\r\n")
+}
+
+// firstLine returns the first line of b without line ending
+func firstLine(b []byte) []byte {
+	if idx := bytes.IndexByte(b, '\n'); idx >= 0 {
+		b = b[:idx]
+	}
+	return trimLine(b)
+}
+
+func (l *IndexedLogfile) column(name string) int {
+	if idx, ok := l.nameIdx[name]; ok {
+		return idx
+	}
+	idx := len(l.names)
+	l.names = append(l.names, name)
+	l.nameIdx[name] = idx
+	return idx
+}
+
+func (l *IndexedLogfile) block(n int) *recordBlock {
+	if b, ok := l.blocks[n]; ok {
+		return b
+	}
+
+	first := n * indexBlockSize
+	last := min(first+indexBlockSize, l.length) - 1
+
+	start := l.offsets[first]
+	stop := l.src.Size()
+	if last+1 < l.length {
+		stop = l.offsets[last+1]
+	}
+
+	buf := make([]byte, stop-start)
+	if _, err := l.src.ReadAt(buf, start); err != nil && err != io.EOF {
+		// leave the values empty, timestamps are still valid
+		buf = buf[:0]
+	}
+
+	b := &recordBlock{}
+	count := last - first + 1
+	for i := first; i <= last; i++ {
+		recStart := l.offsets[i] - start
+		recStop := int64(len(buf))
+		if i < last {
+			recStop = min(l.offsets[i+1]-start, recStop)
+		}
+		if recStart >= recStop {
+			break
+		}
+		row := i - first
+		err := l.format.parseValues(buf[recStart:recStop], func(name string, value float64) {
+			col := l.column(name)
+			for len(b.columns) <= col {
+				b.columns = append(b.columns, nil)
+			}
+			if b.columns[col] == nil {
+				b.columns[col] = newNaNColumn(count)
+			}
+			b.columns[col][row] = value
+		})
+		if err != nil {
+			// values parsed before the error are kept
+			log.Printf("record %d: %v", i+1, err)
+		}
+	}
+
+	l.blocks[n] = b
+	l.blockLRU = append(l.blockLRU, n)
+	if len(l.blockLRU) > indexCachedBlocks {
+		delete(l.blocks, l.blockLRU[0])
+		l.blockLRU = l.blockLRU[1:]
+	}
+	return b
+}
+
+func newNaNColumn(n int) []float64 {
+	col := make([]float64, n)
+	for i := range col {
+		col[i] = math.NaN()
+	}
+	return col
+}
+
+func (l *IndexedLogfile) record(i int) Record {
+	if i < 0 || i >= l.length {
+		return Record{EOF: true}
+	}
+	b := l.block(i / indexBlockSize)
+	row := i % indexBlockSize
+
+	rec := Record{
+		Time:   time.UnixMilli(l.times[i]).UTC(),
+		Values: make(map[string]float64, len(b.columns)),
+	}
+	for col, values := range b.columns {
+		if values == nil || math.IsNaN(values[row]) {
+			continue
+		}
+		rec.Values[l.names[col]] = values[row]
+	}
+	if i < l.end {
+		rec.DelayTillNext = l.times[i+1] - l.times[i]
+	}
+	return rec
+}
+
+func (l *IndexedLogfile) Get() Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.record(max(l.pos, 0))
+}
+
+// Next returns the current record and advances the position to the next record.
+func (l *IndexedLogfile) Next() Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pos++
+	if l.pos > l.end {
+		l.pos = l.end
+		return Record{
+			EOF: true,
+		}
+	}
+	return l.record(l.pos)
+}
+
+// Prev moves the position to the previous record and returns the record.
+func (l *IndexedLogfile) Prev() Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pos--
+	if l.pos < 0 {
+		l.pos = 0
+	}
+	if l.pos > l.end {
+		l.pos = l.end
+	}
+	return l.record(l.pos)
+}
+
+func (l *IndexedLogfile) Seek(pos int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pos = pos
+	if l.pos >= l.end {
+		l.pos = l.end
+	}
+	if l.pos < 0 {
+		l.pos = -1
+	}
+}
+
+func (l *IndexedLogfile) Pos() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return max(l.pos, 0)
+}
+
+func (l *IndexedLogfile) Cursor() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pos
+}
+
+func (l *IndexedLogfile) Len() int {
+	return l.length
+}
+
+func (l *IndexedLogfile) Start() time.Time {
+	if l.length > 0 {
+		return time.UnixMilli(l.times[0]).UTC()
+	}
+	return time.Time{}
+}
+
+func (l *IndexedLogfile) End() time.Time {
+	if l.length > 0 {
+		return time.UnixMilli(l.times[l.end]).UTC()
+	}
+	return time.Time{}
+}
+
+func (l *IndexedLogfile) Length() time.Duration {
+	if l.length > 0 {
+		return time.Duration(l.times[l.end]-l.times[0]) * time.Millisecond
+	}
+	return 0
+}
+
+func (l *IndexedLogfile) Metadata() *datalogger.Metadata {
+	return l.meta
+}
+
+func (l *IndexedLogfile) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closer != nil {
+		l.closer.Close()
+		l.closer = nil
+	}
+	l.blocks = make(map[int]*recordBlock)
+	l.blockLRU = nil
+	l.offsets = nil
+	l.times = nil
+	l.length = 0
+	l.end = -1
+	l.pos = -1
+}
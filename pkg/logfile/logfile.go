@@ -6,6 +6,8 @@ import (
 	"path"
 	"strings"
 	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
 )
 
 type Logfile interface {
@@ -14,9 +16,13 @@ type Logfile interface {
 	Prev() Record
 	Seek(int)
 	Pos() int
+	// Cursor is the position without clamping, -1 before the first record. Seek(Cursor()) puts the log back where it was
+	Cursor() int
 	Len() int
 	Start() time.Time
 	End() time.Time
+	// Metadata returns what the log tells about where it came from, never nil
+	Metadata() *datalogger.Metadata
 	Close()
 }
 
@@ -38,12 +44,22 @@ func (r Record) SetValue(key string, value float64) {
 	r.Values[key] = value
 }
 
+// Open reads the log from reader, the format is given by the filename extension.
+// Logs compressed with gzip (.gz) or zstd (.zst) are decompressed while reading.
 func Open(filename string, reader io.Reader) (Logfile, error) {
 	switch strings.ToLower(path.Ext(filename)) {
+	case ".gz", ".zst":
+		return openCompressed(filename, reader)
 	case ".csv":
-		return NewFromCSVLogfile(reader)
+		return NewIndexedCSVLogfile(reader)
 	case ".t5l", ".t7l", ".t8l":
-		return NewFromTxLogfile(reader)
+		return NewIndexedTxLogfile(reader)
+	case ".txb":
+		return NewFromTXBLogfile(reader)
+	case ".mlg":
+		return NewFromMLGLogfile(reader)
+	case ".msl":
+		return NewFromMSLLogfile(reader)
 	default:
 		return nil, fmt.Errorf("Unsupported filetype")
 	}
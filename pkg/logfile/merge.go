@@ -0,0 +1,325 @@
+package logfile
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+// Interpolation decides how a channel value is calculated between two samples
+type Interpolation int
+
+const (
+	// InterpolateLinear draws a straight line between the surrounding samples
+	InterpolateLinear Interpolation = iota
+	// InterpolateHold keeps the last sample until a new one arrives
+	InterpolateHold
+)
+
+func (i Interpolation) String() string {
+	switch i {
+	case InterpolateLinear:
+		return "Linear"
+	case InterpolateHold:
+		return "Sample and hold"
+	}
+	return "Unknown"
+}
+
+// MergeSource is one logfile taking part in a merge
+type MergeSource struct {
+	Logfile Logfile
+	// Offset is added to every timestamp of the logfile before aligning it
+	Offset time.Duration
+	// Interpolation used for all channels of the logfile unless overridden in Channels
+	Interpolation Interpolation
+	// Channels overrides the interpolation per channel name
+	Channels map[string]Interpolation
+}
+
+var _ Logfile = (*MergedLogfile)(nil)
+
+// MergedLogfile is the result of merging several logfiles on the timeline of the first one. The values are stored
+// column wise like a parsed block of an IndexedLogfile, but the whole log is kept in memory as the sources may be
+// closed once merged
+type MergedLogfile struct {
+	times   []int64 // unix milliseconds of each record
+	names   []string
+	nameIdx map[string]int
+	// columns[channel][record], NaN marks a value missing from the record
+	columns [][]float64
+
+	meta *datalogger.Metadata
+
+	length int
+	pos    int
+	end    int
+}
+
+type mergeChannel struct {
+	times  []int64 // unix milliseconds with offset applied
+	values []float64
+	interp Interpolation
+	cursor int
+}
+
+// Merge aligns the records of all sources by timestamp. The first source is the time base, every
+// record of it is kept and the channels of the other sources are interpolated at its timestamps.
+// Values outside the time span of a source are left out. Channels with the same name in a later
+// source take precedence. The sources are rewound but not closed.
+func Merge(sources ...MergeSource) (Logfile, error) {
+	if len(sources) < 2 {
+		return nil, errors.New("at least two logfiles are needed to merge")
+	}
+
+	m := &MergedLogfile{
+		nameIdx: make(map[string]int),
+		meta:    mergeMetadata(sources),
+		pos:     -1,
+	}
+
+	base := sources[0]
+	base.Logfile.Seek(-1)
+	for row := 0; ; row++ {
+		rec := base.Logfile.Next()
+		if rec.EOF {
+			break
+		}
+		m.times = append(m.times, rec.Time.Add(base.Offset).UnixMilli())
+		for k, v := range rec.Values {
+			col := m.column(k)
+			for len(m.columns[col]) < row {
+				m.columns[col] = append(m.columns[col], math.NaN())
+			}
+			m.columns[col] = append(m.columns[col], v)
+		}
+	}
+	base.Logfile.Seek(-1)
+
+	m.length = len(m.times)
+	m.end = m.length - 1
+	if m.length == 0 {
+		return nil, errors.New("no records in time base logfile")
+	}
+	for col := range m.columns {
+		for len(m.columns[col]) < m.length {
+			m.columns[col] = append(m.columns[col], math.NaN())
+		}
+	}
+
+	for _, src := range sources[1:] {
+		for name, ch := range readMergeChannels(src) {
+			col := m.column(name)
+			if m.columns[col] == nil {
+				m.columns[col] = newNaNColumn(m.length)
+			}
+			for i, ts := range m.times {
+				if v, ok := ch.valueAt(ts); ok {
+					m.columns[col][i] = v
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *MergedLogfile) column(name string) int {
+	if idx, ok := m.nameIdx[name]; ok {
+		return idx
+	}
+	idx := len(m.names)
+	m.names = append(m.names, name)
+	m.nameIdx[name] = idx
+	m.columns = append(m.columns, nil)
+	return idx
+}
+
+func (m *MergedLogfile) record(i int) Record {
+	if i < 0 || i >= m.length {
+		return Record{EOF: true}
+	}
+	rec := Record{
+		Time:   time.UnixMilli(m.times[i]).UTC(),
+		Values: make(map[string]float64, len(m.columns)),
+	}
+	for col, values := range m.columns {
+		if math.IsNaN(values[i]) {
+			continue
+		}
+		rec.Values[m.names[col]] = values[i]
+	}
+	if i < m.end {
+		rec.DelayTillNext = m.times[i+1] - m.times[i]
+	}
+	return rec
+}
+
+func (m *MergedLogfile) Get() Record {
+	return m.record(max(m.pos, 0))
+}
+
+// Next returns the current record and advances the position to the next record.
+func (m *MergedLogfile) Next() Record {
+	m.pos++
+	if m.pos > m.end {
+		m.pos = m.end
+		return Record{
+			EOF: true,
+		}
+	}
+	return m.record(m.pos)
+}
+
+// Prev moves the position to the previous record and returns the record.
+func (m *MergedLogfile) Prev() Record {
+	m.pos--
+	if m.pos < 0 {
+		m.pos = 0
+	}
+	if m.pos > m.end {
+		m.pos = m.end
+	}
+	return m.record(m.pos)
+}
+
+func (m *MergedLogfile) Seek(pos int) {
+	m.pos = pos
+	if m.pos >= m.end {
+		m.pos = m.end
+	}
+	if m.pos < 0 {
+		m.pos = -1
+	}
+}
+
+func (m *MergedLogfile) Pos() int {
+	return max(m.pos, 0)
+}
+
+func (m *MergedLogfile) Cursor() int {
+	return m.pos
+}
+
+func (m *MergedLogfile) Len() int {
+	return m.length
+}
+
+func (m *MergedLogfile) Start() time.Time {
+	if m.length > 0 {
+		return time.UnixMilli(m.times[0]).UTC()
+	}
+	return time.Time{}
+}
+
+func (m *MergedLogfile) End() time.Time {
+	if m.length > 0 {
+		return time.UnixMilli(m.times[m.end]).UTC()
+	}
+	return time.Time{}
+}
+
+func (m *MergedLogfile) Metadata() *datalogger.Metadata {
+	return m.meta
+}
+
+func (m *MergedLogfile) Close() {
+	m.times = nil
+	m.names = nil
+	m.nameIdx = make(map[string]int)
+	m.columns = nil
+	m.length = 0
+	m.end = -1
+	m.pos = -1
+}
+
+// mergeMetadata returns the metadata of the time base with the channels of all sources
+func mergeMetadata(sources []MergeSource) *datalogger.Metadata {
+	meta := *sources[0].Logfile.Metadata()
+	meta.Channels = slices.Clone(meta.Channels)
+	for _, src := range sources[1:] {
+		for _, ch := range src.Logfile.Metadata().Channels {
+			idx := slices.IndexFunc(meta.Channels, func(c datalogger.ChannelMetadata) bool { return c.Name == ch.Name })
+			if idx >= 0 {
+				meta.Channels[idx] = ch
+				continue
+			}
+			meta.Channels = append(meta.Channels, ch)
+		}
+	}
+	return &meta
+}
+
+func readMergeChannels(src MergeSource) map[string]*mergeChannel {
+	channels := make(map[string]*mergeChannel)
+	src.Logfile.Seek(-1)
+	for {
+		rec := src.Logfile.Next()
+		if rec.EOF {
+			break
+		}
+		ts := rec.Time.Add(src.Offset).UnixMilli()
+		for k, v := range rec.Values {
+			ch, ok := channels[k]
+			if !ok {
+				interp := src.Interpolation
+				if ci, found := src.Channels[k]; found {
+					interp = ci
+				}
+				ch = &mergeChannel{interp: interp}
+				channels[k] = ch
+			}
+			ch.times = append(ch.times, ts)
+			ch.values = append(ch.values, v)
+		}
+	}
+	src.Logfile.Seek(-1)
+	return channels
+}
+
+// valueAt returns the channel value at ts, timestamps must be asked for in increasing order
+func (c *mergeChannel) valueAt(ts int64) (float64, bool) {
+	n := len(c.times)
+	if n == 0 || ts < c.times[0] || ts > c.times[n-1] {
+		return 0, false
+	}
+	// move to the last sample at or before ts
+	for c.cursor < n-1 && c.times[c.cursor+1] <= ts {
+		c.cursor++
+	}
+	i := c.cursor
+	if c.interp == InterpolateHold || c.times[i] == ts || i == n-1 {
+		return c.values[i], true
+	}
+	t0, t1 := c.times[i], c.times[i+1]
+	v0, v1 := c.values[i], c.values[i+1]
+	if t1 == t0 || math.IsNaN(v0) || math.IsNaN(v1) {
+		return v0, true
+	}
+	return v0 + (v1-v0)*float64(ts-t0)/float64(t1-t0), true
+}
+
+// Channels returns the sorted names of all channels found in the logfile
+func Channels(lf Logfile) []string {
+	seen := make(map[string]struct{})
+	lf.Seek(-1)
+	for {
+		rec := lf.Next()
+		if rec.EOF {
+			break
+		}
+		for k := range rec.Values {
+			seen[k] = struct{}{}
+		}
+	}
+	lf.Seek(-1)
+	names := make([]string, 0, len(seen))
+	for k := range seen {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,253 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+// MLGField describes one field of a MegaLogViewer binary log
+type MLGField struct {
+	Type      uint8
+	Name      string
+	Units     string
+	Style     uint8
+	Scale     float64
+	Transform float64
+	Digits    int
+	Category  string
+
+	offset int
+}
+
+type mlgFormat struct {
+	Version int
+	Start   time.Time
+	Fields  []MLGField
+
+	dataBegin    int64
+	recordLength int
+	timeField    int
+}
+
+// NewFromMLGLogfile opens a MegaLogViewer binary log, format version 1 or 2
+func NewFromMLGLogfile(reader io.Reader) (Logfile, error) {
+	src, closer, err := newSource(reader)
+	if err != nil {
+		return nil, err
+	}
+	format, err := readMLGHeader(src)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+
+	l := &IndexedLogfile{
+		src:     src,
+		closer:  closer,
+		format:  format,
+		nameIdx: make(map[string]int),
+		blocks:  make(map[int]*recordBlock),
+		meta:    &datalogger.Metadata{},
+		pos:     -1,
+	}
+	for _, f := range format.Fields {
+		l.column(f.Name)
+		l.meta.Channels = append(l.meta.Channels, datalogger.ChannelMetadata{
+			Name:             f.Name,
+			Unit:             f.Units,
+			Correctionfactor: 1,
+		})
+	}
+	if err := format.buildIndex(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func readMLGHeader(src sourceReader) (*mlgFormat, error) {
+	hdr := make([]byte, datalogger.MLGHeaderSize(2))
+	if _, err := src.ReadAt(hdr, 0); err != nil {
+		return nil, fmt.Errorf("failed to read MLG header: %w", err)
+	}
+	if string(hdr[:6]) != datalogger.MLGFileFormat {
+		return nil, errors.New("not a MLG logfile")
+	}
+
+	f := &mlgFormat{
+		Version:   int(binary.BigEndian.Uint16(hdr[6:])),
+		timeField: -1,
+	}
+	f.Start = wallClock(time.Unix(int64(binary.BigEndian.Uint32(hdr[8:])), 0))
+
+	var numFields int
+	switch f.Version {
+	case 1:
+		f.dataBegin = int64(binary.BigEndian.Uint32(hdr[14:]))
+		f.recordLength = int(binary.BigEndian.Uint16(hdr[18:]))
+		numFields = int(binary.BigEndian.Uint16(hdr[20:]))
+	case 2:
+		f.dataBegin = int64(binary.BigEndian.Uint32(hdr[16:]))
+		f.recordLength = int(binary.BigEndian.Uint16(hdr[20:]))
+		numFields = int(binary.BigEndian.Uint16(hdr[22:]))
+	default:
+		return nil, fmt.Errorf("unsupported MLG version %d", f.Version)
+	}
+
+	fieldSize := datalogger.MLGFieldSize(f.Version)
+	fields := make([]byte, numFields*fieldSize)
+	if _, err := src.ReadAt(fields, int64(datalogger.MLGHeaderSize(f.Version))); err != nil {
+		return nil, fmt.Errorf("failed to read MLG fields: %w", err)
+	}
+
+	var offset int
+	for i := range numFields {
+		b := fields[i*fieldSize:]
+		field := MLGField{
+			Type:      b[0],
+			Name:      fixedString(b[1 : 1+datalogger.MLGFieldNameSize]),
+			Units:     fixedString(b[35 : 35+datalogger.MLGFieldUnitsSize]),
+			Style:     b[45],
+			Scale:     float32ToFloat64(math.Float32frombits(binary.BigEndian.Uint32(b[46:]))),
+			Transform: float32ToFloat64(math.Float32frombits(binary.BigEndian.Uint32(b[50:]))),
+			Digits:    int(int8(b[54])),
+			offset:    offset,
+		}
+		if f.Version != 1 {
+			field.Category = fixedString(b[55 : 55+datalogger.MLGFieldCategorySize])
+		}
+		size := datalogger.MLGTypeSize(field.Type)
+		if size == 0 {
+			return nil, fmt.Errorf("unknown MLG field type %d for %s", field.Type, field.Name)
+		}
+		if f.timeField < 0 && strings.EqualFold(field.Name, "Time") {
+			f.timeField = i
+		}
+		offset += size
+		f.Fields = append(f.Fields, field)
+	}
+	if offset > f.recordLength {
+		return nil, errors.New("MLG record length does not match fields")
+	}
+	return f, nil
+}
+
+func fixedString(b []byte) string {
+	if idx := bytes.IndexByte(b, 0); idx >= 0 {
+		b = b[:idx]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// buildIndex walks all blocks, markers are skipped
+func (f *mlgFormat) buildIndex(l *IndexedLogfile) error {
+	r := bufio.NewReaderSize(io.NewSectionReader(l.src, f.dataBegin, l.src.Size()-f.dataBegin), 64*1024)
+
+	dataBlockSize := 4 + f.recordLength + 1
+	block := make([]byte, max(dataBlockSize, 4+datalogger.MLGMarkerSize))
+	offset := f.dataBegin
+
+	var elapsed time.Duration
+	var lastStamp uint16
+	for {
+		if _, err := io.ReadFull(r, block[:4]); err != nil {
+			break
+		}
+		var size int
+		switch block[0] {
+		case datalogger.MLGBlockData:
+			size = dataBlockSize
+		case datalogger.MLGBlockMarker:
+			size = 4 + datalogger.MLGMarkerSize
+		default:
+			return fmt.Errorf("unknown MLG block type %d at offset %d", block[0], offset)
+		}
+		if _, err := io.ReadFull(r, block[4:size]); err != nil {
+			break // partially written block
+		}
+
+		if block[0] == datalogger.MLGBlockData {
+			stamp := binary.BigEndian.Uint16(block[2:])
+			if len(l.offsets) > 0 {
+				elapsed += time.Duration(stamp-lastStamp) * 10 * time.Microsecond
+			}
+			lastStamp = stamp
+
+			ts := f.Start.Add(elapsed)
+			if f.timeField >= 0 {
+				if t, err := f.parseTime(block[:size]); err == nil {
+					ts = t
+				}
+			}
+			l.offsets = append(l.offsets, offset)
+			l.times = append(l.times, ts.UnixMilli())
+		}
+		offset += int64(size)
+	}
+
+	l.length = len(l.offsets)
+	l.end = l.length - 1
+	if l.length == 0 {
+		return errors.New("no records in file")
+	}
+	return nil
+}
+
+func (f *mlgFormat) value(rec []byte, field *MLGField) float64 {
+	b := rec[4+field.offset:]
+	var raw float64
+	switch field.Type {
+	case datalogger.MLGTypeU08:
+		raw = float64(b[0])
+	case datalogger.MLGTypeS08:
+		raw = float64(int8(b[0]))
+	case datalogger.MLGTypeU16:
+		raw = float64(binary.BigEndian.Uint16(b))
+	case datalogger.MLGTypeS16:
+		raw = float64(int16(binary.BigEndian.Uint16(b)))
+	case datalogger.MLGTypeU32:
+		raw = float64(binary.BigEndian.Uint32(b))
+	case datalogger.MLGTypeS32:
+		raw = float64(int32(binary.BigEndian.Uint32(b)))
+	case datalogger.MLGTypeS64:
+		raw = float64(int64(binary.BigEndian.Uint64(b)))
+	case datalogger.MLGTypeF32:
+		raw = float32ToFloat64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	}
+	if field.Scale == 1 && field.Transform == 0 {
+		return raw
+	}
+	return (raw + field.Transform) * field.Scale
+}
+
+func (f *mlgFormat) parseTime(rec []byte) (time.Time, error) {
+	if f.timeField < 0 {
+		return time.Time{}, errors.New("no time field")
+	}
+	if len(rec) < 4+f.recordLength || rec[0] != datalogger.MLGBlockData {
+		return time.Time{}, io.ErrUnexpectedEOF
+	}
+	seconds := f.value(rec, &f.Fields[f.timeField])
+	return f.Start.Add(time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)), nil
+}
+
+func (f *mlgFormat) parseValues(rec []byte, set func(string, float64)) error {
+	if len(rec) < 4+f.recordLength || rec[0] != datalogger.MLGBlockData {
+		return io.ErrUnexpectedEOF
+	}
+	for i := range f.Fields {
+		set(f.Fields[i].Name, f.value(rec, &f.Fields[i]))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package logfile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// layouts seen in the "Capture Date" comment of MegaLogViewer text logs
+var mslDateLayouts = []string{
+	"Mon Jan 02 15:04:05 MST 2006",
+	"Mon Jan 2 15:04:05 MST 2006",
+	"2006-01-02 15:04:05",
+	time.RFC1123,
+}
+
+type mslLineFormat struct {
+	header    []string
+	timeField int
+	base      time.Time
+}
+
+// NewFromMSLLogfile opens a tab separated MegaLogViewer text log.
+// Time is taken from the Time column as seconds since the capture date, or since epoch if there is none.
+func NewFromMSLLogfile(reader io.Reader) (Logfile, error) {
+	src, closer, err := newSource(reader)
+	if err != nil {
+		return nil, err
+	}
+	format, err := readMSLHeader(src)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+	return newIndexedLogfile(src, closer, format)
+}
+
+func readMSLHeader(src sourceReader) (*mslLineFormat, error) {
+	scanner := bufio.NewScanner(io.NewSectionReader(src, 0, src.Size()))
+	scanner.Buffer(make([]byte, 64*1024), indexMaxLineSize)
+
+	f := &mslLineFormat{base: time.Unix(0, 0).UTC(), timeField: -1}
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		unquoted := strings.Trim(line, `"`)
+		if after, found := strings.CutPrefix(unquoted, "Capture Date:"); found {
+			after = strings.TrimSpace(after)
+			for _, layout := range mslDateLayouts {
+				if t, err := time.Parse(layout, after); err == nil {
+					f.base = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+					break
+				}
+			}
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		for i, name := range fields {
+			if strings.EqualFold(strings.TrimSpace(name), "Time") {
+				f.timeField = i
+				break
+			}
+		}
+		if f.timeField >= 0 {
+			for _, name := range fields {
+				f.header = append(f.header, strings.TrimSpace(name))
+			}
+			return f, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("no Time column found in MSL log")
+}
+
+func (f *mslLineFormat) parseTime(line []byte) (time.Time, error) {
+	fields := bytes.Split(firstLine(line), []byte{'\t'})
+	if len(fields) <= f.timeField {
+		return time.Time{}, errors.New("no time field")
+	}
+	seconds, err := strconv.ParseFloat(string(bytes.TrimSpace(fields[f.timeField])), 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return f.base.Add(time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)), nil
+}
+
+func (f *mslLineFormat) parseValues(line []byte, set func(string, float64)) error {
+	fields := bytes.Split(firstLine(line), []byte{'\t'})
+	for i := 0; i < len(fields) && i < len(f.header); i++ {
+		val, err := strconv.ParseFloat(string(bytes.TrimSpace(fields[i])), 64)
+		if err != nil {
+			continue
+		}
+		set(f.header[i], val)
+	}
+	return nil
+}
@@ -51,6 +51,9 @@ func (l *TxLogfile) parseTxLogfile(reader io.Reader) error {
 	fileScanner := bufio.NewScanner(reader)
 	fileScanner.Buffer(buffer, bufio.MaxScanTokenSize)
 	for fileScanner.Scan() {
+		if len(lines) == 0 && l.Metadata().ParseLine(fileScanner.Text()) {
+			continue
+		}
 		lines = append(lines, string(fileScanner.Bytes()))
 	}
 
@@ -117,6 +120,9 @@ func getDelayTillNext(line, timeFormat string, currentDelay time.Time) int64 {
 
 func parseCommaValue(valueString string) (string, float64, error) {
 	parts := strings.Split(valueString, "=")
+	if len(parts) != 2 {
+		return "", -1, errors.New("invalid value: " + valueString)
+	}
 	val, err := strconv.ParseFloat(strings.Replace(parts[1], ",", ".", 1), 64)
 	if err != nil {
 		return "", -1, err
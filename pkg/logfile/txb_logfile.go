@@ -0,0 +1,218 @@
+package logfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+// TXBChannel describes one channel stored in a TXB logfile
+type TXBChannel struct {
+	Name             string
+	Unit             string
+	Correctionfactor float64
+	Type             uint8
+}
+
+type txbFormat struct {
+	ECU      string
+	Start    time.Time
+	Channels []TXBChannel
+
+	headerSize int64
+	recordSize int64
+}
+
+// NewFromTXBLogfile opens a binary TXB logfile written by the TXB log writer
+func NewFromTXBLogfile(reader io.Reader) (Logfile, error) {
+	src, closer, err := newSource(reader)
+	if err != nil {
+		return nil, err
+	}
+	format, err := readTXBHeader(src)
+	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
+		return nil, err
+	}
+
+	l := &IndexedLogfile{
+		src:     src,
+		closer:  closer,
+		format:  format,
+		nameIdx: make(map[string]int),
+		blocks:  make(map[int]*recordBlock),
+		meta:    &datalogger.Metadata{ECU: format.ECU},
+		pos:     -1,
+	}
+	for _, ch := range format.Channels {
+		l.column(ch.Name)
+		l.meta.Channels = append(l.meta.Channels, datalogger.ChannelMetadata{
+			Name:             ch.Name,
+			Unit:             ch.Unit,
+			Correctionfactor: ch.Correctionfactor,
+		})
+	}
+
+	if err := format.buildIndex(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+func readTXBHeader(src sourceReader) (*txbFormat, error) {
+	r := &countingReader{r: bufio.NewReader(io.NewSectionReader(src, 0, src.Size()))}
+
+	magic := make([]byte, len(datalogger.TXBMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read TXB header: %w", err)
+	}
+	if string(magic) != datalogger.TXBMagic {
+		return nil, errors.New("not a TXB logfile")
+	}
+
+	f := &txbFormat{}
+	var err error
+	if f.ECU, err = readTXBString(r); err != nil {
+		return nil, err
+	}
+
+	var start int64
+	if err := binary.Read(r, binary.LittleEndian, &start); err != nil {
+		return nil, err
+	}
+	// text logs store local wall clock time, do the same so timestamps display alike
+	f.Start = wallClock(time.UnixMilli(start))
+
+	var count uint16
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, err
+	}
+
+	f.Channels = make([]TXBChannel, count)
+	for i := range f.Channels {
+		ch := &f.Channels[i]
+		if ch.Name, err = readTXBString(r); err != nil {
+			return nil, err
+		}
+		if ch.Unit, err = readTXBString(r); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &ch.Correctionfactor); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.LittleEndian, &ch.Type); err != nil {
+			return nil, err
+		}
+		size := datalogger.TXBTypeSize(ch.Type)
+		if size == 0 {
+			return nil, fmt.Errorf("channel %s has unknown type %d", ch.Name, ch.Type)
+		}
+		f.recordSize += int64(size)
+	}
+
+	f.headerSize = r.n
+	f.recordSize += 4
+	return f, nil
+}
+
+func readTXBString(r io.Reader) (string, error) {
+	var n [1]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return "", fmt.Errorf("failed to read TXB header: %w", err)
+	}
+	b := make([]byte, n[0])
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", fmt.Errorf("failed to read TXB header: %w", err)
+	}
+	return string(b), nil
+}
+
+// buildIndex reads the timestamp of every complete record, a partially written record at the end is ignored
+func (f *txbFormat) buildIndex(l *IndexedLogfile) error {
+	count := (l.src.Size() - f.headerSize) / f.recordSize
+	if count <= 0 {
+		return errors.New("no records in file")
+	}
+
+	l.offsets = make([]int64, 0, count)
+	l.times = make([]int64, 0, count)
+
+	r := bufio.NewReaderSize(io.NewSectionReader(l.src, f.headerSize, count*f.recordSize), 64*1024)
+	rec := make([]byte, f.recordSize)
+	offset := f.headerSize
+	for range count {
+		if _, err := io.ReadFull(r, rec); err != nil {
+			return err
+		}
+		ts, err := f.parseTime(rec)
+		if err != nil {
+			return err
+		}
+		l.offsets = append(l.offsets, offset)
+		l.times = append(l.times, ts.UnixMilli())
+		offset += f.recordSize
+	}
+
+	l.length = len(l.offsets)
+	l.end = l.length - 1
+	return nil
+}
+
+func (f *txbFormat) parseTime(rec []byte) (time.Time, error) {
+	if len(rec) < 4 {
+		return time.Time{}, io.ErrUnexpectedEOF
+	}
+	return f.Start.Add(time.Duration(binary.LittleEndian.Uint32(rec)) * time.Millisecond), nil
+}
+
+func (f *txbFormat) parseValues(rec []byte, set func(string, float64)) error {
+	if int64(len(rec)) < f.recordSize {
+		return io.ErrUnexpectedEOF
+	}
+	pos := 4
+	for _, ch := range f.Channels {
+		if ch.Type == datalogger.TXBTypeF64 {
+			set(ch.Name, math.Float64frombits(binary.LittleEndian.Uint64(rec[pos:])))
+		} else {
+			set(ch.Name, float32ToFloat64(math.Float32frombits(binary.LittleEndian.Uint32(rec[pos:]))))
+		}
+		pos += datalogger.TXBTypeSize(ch.Type)
+	}
+	return nil
+}
+
+// float32ToFloat64 widens v without the binary noise a plain conversion adds, 0.1 stays 0.1
+func float32ToFloat64(v float32) float64 {
+	f, err := strconv.ParseFloat(strconv.FormatFloat(float64(v), 'g', -1, 32), 64)
+	if err != nil {
+		return float64(v)
+	}
+	return f
+}
+
+// wallClock returns the local wall clock time of t in UTC
+func wallClock(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
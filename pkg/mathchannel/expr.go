@@ -0,0 +1,598 @@
+package mathchannel
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+Expression syntax
+
+	numbers          1, 1.013, 14.7e0
+	symbols          Lambda.External, Out.fi_Ignition, "name with spaces"
+	operators        + - * / ^ and comparisons > >= < <= == != returning 1 or 0
+	logic            && || treat any non zero value as true and return 1 or 0
+	functions        min(a, b, ...)   max(a, b, ...)   abs(x)   sqrt(x)
+	                 clamp(x, lo, hi) if(cond, a, b)
+	                 derivative(x)    change of x per second
+	                 avg(x, n)        moving average of the last n samples, n must be a number
+
+derivative and avg keep state between evaluations and expect to see the samples in time order.
+*/
+
+// Expression is a compiled math channel expression
+type Expression struct {
+	src  string
+	root node
+	vars []string
+}
+
+// Compile parses src into an Expression
+func Compile(src string) (*Expression, error) {
+	p := &parser{src: src, varsSeen: make(map[string]bool)}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return &Expression{src: src, root: root, vars: p.vars}, nil
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
+
+// Vars returns the symbol names used in the expression
+func (e *Expression) Vars() []string {
+	return e.vars
+}
+
+// Eval evaluates the expression at ts, get returns the current value of a symbol.
+// The result is not ok if a symbol is missing or the result is not a finite number.
+func (e *Expression) Eval(ts time.Time, get func(name string) (float64, bool)) (float64, bool) {
+	ctx := &evalCtx{ts: ts, get: get}
+	v := e.root.eval(ctx)
+	if ctx.missing || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+// Reset clears the state of derivative and avg
+func (e *Expression) Reset() {
+	e.root.reset()
+}
+
+// History returns how many earlier samples derivative and avg need to give the same result after Reset
+func (e *Expression) History() int {
+	return e.root.history()
+}
+
+type evalCtx struct {
+	ts      time.Time
+	get     func(string) (float64, bool)
+	missing bool
+}
+
+type node interface {
+	eval(ctx *evalCtx) float64
+	reset()
+	history() int
+}
+
+type numberNode float64
+
+func (n numberNode) eval(*evalCtx) float64 { return float64(n) }
+func (n numberNode) reset()                {}
+func (n numberNode) history() int          { return 0 }
+
+type varNode string
+
+func (n varNode) eval(ctx *evalCtx) float64 {
+	v, ok := ctx.get(string(n))
+	if !ok {
+		ctx.missing = true
+		return math.NaN()
+	}
+	return v
+}
+func (n varNode) reset()       {}
+func (n varNode) history() int { return 0 }
+
+type unaryNode struct {
+	x node
+}
+
+func (n *unaryNode) eval(ctx *evalCtx) float64 { return -n.x.eval(ctx) }
+func (n *unaryNode) reset()                    { n.x.reset() }
+func (n *unaryNode) history() int              { return n.x.history() }
+
+type binaryNode struct {
+	op   string
+	a, b node
+}
+
+func (n *binaryNode) eval(ctx *evalCtx) float64 {
+	a, b := n.a.eval(ctx), n.b.eval(ctx)
+	switch n.op {
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	case "/":
+		return a / b
+	case "^":
+		return math.Pow(a, b)
+	case ">":
+		return boolValue(a > b)
+	case ">=":
+		return boolValue(a >= b)
+	case "<":
+		return boolValue(a < b)
+	case "<=":
+		return boolValue(a <= b)
+	case "==":
+		return boolValue(a == b)
+	case "!=":
+		return boolValue(a != b)
+	case "&&":
+		return boolValue(a != 0 && b != 0)
+	case "||":
+		return boolValue(a != 0 || b != 0)
+	}
+	return math.NaN()
+}
+
+func (n *binaryNode) reset() {
+	n.a.reset()
+	n.b.reset()
+}
+
+func (n *binaryNode) history() int {
+	return max(n.a.history(), n.b.history())
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type callNode struct {
+	name string
+	args []node
+	fn   func(ctx *evalCtx, args []float64) float64
+	// state for derivative and avg
+	lastValue float64
+	lastTime  time.Time
+	hasLast   bool
+	window    []float64
+	windowPos int
+	windowLen int
+}
+
+func (n *callNode) eval(ctx *evalCtx) float64 {
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		args[i] = a.eval(ctx)
+	}
+	if ctx.missing {
+		return math.NaN()
+	}
+	switch n.name {
+	case "derivative":
+		return n.derivative(ctx.ts, args[0])
+	case "avg":
+		return n.average(args[0])
+	}
+	return n.fn(ctx, args)
+}
+
+func (n *callNode) derivative(ts time.Time, v float64) float64 {
+	if !n.hasLast || !ts.After(n.lastTime) {
+		if !n.hasLast {
+			n.lastValue, n.lastTime, n.hasLast = v, ts, true
+		}
+		return 0
+	}
+	d := (v - n.lastValue) / ts.Sub(n.lastTime).Seconds()
+	n.lastValue, n.lastTime = v, ts
+	return d
+}
+
+func (n *callNode) average(v float64) float64 {
+	n.window[n.windowPos] = v
+	n.windowPos = (n.windowPos + 1) % len(n.window)
+	if n.windowLen < len(n.window) {
+		n.windowLen++
+	}
+	var sum float64
+	for i := range n.windowLen {
+		sum += n.window[i]
+	}
+	return sum / float64(n.windowLen)
+}
+
+func (n *callNode) reset() {
+	n.hasLast = false
+	n.windowPos, n.windowLen = 0, 0
+	for _, a := range n.args {
+		a.reset()
+	}
+}
+
+func (n *callNode) history() int {
+	var h int
+	for _, a := range n.args {
+		h = max(h, a.history())
+	}
+	switch n.name {
+	case "derivative":
+		h++
+	case "avg":
+		h += len(n.window) - 1
+	}
+	return h
+}
+
+type function struct {
+	minArgs, maxArgs int // maxArgs -1 means unlimited
+	fn               func(ctx *evalCtx, args []float64) float64
+}
+
+var functions = map[string]function{
+	"min": {1, -1, func(_ *evalCtx, args []float64) float64 {
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Min(v, a)
+		}
+		return v
+	}},
+	"max": {1, -1, func(_ *evalCtx, args []float64) float64 {
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Max(v, a)
+		}
+		return v
+	}},
+	"abs":  {1, 1, func(_ *evalCtx, args []float64) float64 { return math.Abs(args[0]) }},
+	"sqrt": {1, 1, func(_ *evalCtx, args []float64) float64 { return math.Sqrt(args[0]) }},
+	"clamp": {3, 3, func(_ *evalCtx, args []float64) float64 {
+		return math.Max(args[1], math.Min(args[2], args[0]))
+	}},
+	"if": {3, 3, func(_ *evalCtx, args []float64) float64 {
+		if args[0] != 0 {
+			return args[1]
+		}
+		return args[2]
+	}},
+	"derivative": {1, 1, nil},
+	"avg":        {2, 2, nil},
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	src      string
+	tokens   []token
+	pos      int
+	vars     []string
+	varsSeen map[string]bool
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return fmt.Errorf("position %d: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+func isIdentRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '!'
+}
+
+func (p *parser) tokenize() error {
+	runes := []rune(p.src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+				j := i + 1
+				if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+					j++
+				}
+				if j < len(runes) && unicode.IsDigit(runes[j]) {
+					i = j
+					for i < len(runes) && unicode.IsDigit(runes[i]) {
+						i++
+					}
+				}
+			}
+			p.tokens = append(p.tokens, token{tokNumber, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && isIdentRune(runes[i]) {
+				// keep a!=b working while still allowing names like Pgm_mod!
+				if runes[i] == '!' && i+1 < len(runes) && runes[i+1] == '=' {
+					break
+				}
+				i++
+			}
+			p.tokens = append(p.tokens, token{tokIdent, string(runes[start:i]), start})
+		case r == '"':
+			start := i
+			end := strings.IndexRune(string(runes[i+1:]), '"')
+			if end < 0 {
+				return fmt.Errorf("position %d: unterminated quoted name", start+1)
+			}
+			name := string(runes[i+1:])[:end]
+			i += len([]rune(name)) + 2
+			p.tokens = append(p.tokens, token{tokIdent, name, start})
+		case r == '(':
+			p.tokens = append(p.tokens, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, token{tokRParen, ")", i})
+			i++
+		case r == ',':
+			p.tokens = append(p.tokens, token{tokComma, ",", i})
+			i++
+		case strings.ContainsRune("+-*/^", r):
+			p.tokens = append(p.tokens, token{tokOp, string(r), i})
+			i++
+		case strings.ContainsRune("<>=!", r):
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				p.tokens = append(p.tokens, token{tokOp, string(runes[i : i+2]), i})
+				i += 2
+				continue
+			}
+			if r == '=' || r == '!' {
+				return fmt.Errorf("position %d: unexpected %q", i+1, r)
+			}
+			p.tokens = append(p.tokens, token{tokOp, string(r), i})
+			i++
+		case r == '&' || r == '|':
+			if i+1 >= len(runes) || runes[i+1] != r {
+				return fmt.Errorf("position %d: unexpected %q", i+1, r)
+			}
+			p.tokens = append(p.tokens, token{tokOp, string(runes[i : i+2]), i})
+			i += 2
+		default:
+			return fmt.Errorf("position %d: unexpected %q", i+1, r)
+		}
+	}
+	p.tokens = append(p.tokens, token{tokEOF, "end of expression", len(runes)})
+	return nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+var comparisons = map[string]bool{"<": true, "<=": true, ">": true, ">=": true, "==": true, "!=": true}
+
+// parseExpr parses ||, the lowest precedence
+func (p *parser) parseExpr() (node, error) {
+	return p.parseLogic("||", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.parseLogic("&&", p.parseComparison)
+}
+
+func (p *parser) parseLogic(op string, operand func() (node, error)) (node, error) {
+	a, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || tok.text != op {
+			return a, nil
+		}
+		p.next()
+		b, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		a = &binaryNode{op: op, a: a, b: b}
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	a, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || !comparisons[tok.text] {
+			return a, nil
+		}
+		p.next()
+		b, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		a = &binaryNode{op: tok.text, a: a, b: b}
+	}
+}
+
+func (p *parser) parseSum() (node, error) {
+	a, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || (tok.text != "+" && tok.text != "-") {
+			return a, nil
+		}
+		p.next()
+		b, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		a = &binaryNode{op: tok.text, a: a, b: b}
+	}
+}
+
+func (p *parser) parseProduct() (node, error) {
+	a, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || (tok.text != "*" && tok.text != "/") {
+			return a, nil
+		}
+		p.next()
+		b, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		a = &binaryNode{op: tok.text, a: a, b: b}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if tok := p.peek(); tok.kind == tokOp && (tok.text == "-" || tok.text == "+") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if tok.text == "+" {
+			return x, nil
+		}
+		return &unaryNode{x: x}, nil
+	}
+	return p.parsePower()
+}
+
+// parsePower is right associative, 2^3^2 is 2^(3^2)
+func (p *parser) parsePower() (node, error) {
+	a, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind == tokOp && tok.text == "^" {
+		p.next()
+		b, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: "^", a: a, b: b}, nil
+	}
+	return a, nil
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+		return numberNode(v), nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+		if !p.varsSeen[tok.text] {
+			p.varsSeen[tok.text] = true
+			p.vars = append(p.vars, tok.text)
+		}
+		return varNode(tok.text), nil
+	case tokLParen:
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "expected ) got %q", closing.text)
+		}
+		return x, nil
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *parser) parseCall(name token) (node, error) {
+	fn, ok := functions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, p.errorf(name, "unknown function %q", name.text)
+	}
+	p.next() // (
+
+	n := &callNode{name: strings.ToLower(name.text), fn: fn.fn}
+	if p.peek().kind != tokRParen {
+		for {
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			n.args = append(n.args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokRParen {
+		return nil, p.errorf(closing, "expected ) got %q", closing.text)
+	}
+
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return nil, p.errorf(name, "wrong number of arguments to %s", n.name)
+	}
+	if n.name == "avg" {
+		size, ok := n.args[1].(numberNode)
+		if !ok || size < 1 || float64(size) != math.Trunc(float64(size)) {
+			return nil, p.errorf(name, "avg sample count must be a positive whole number")
+		}
+		n.window = make([]float64, int(size))
+	}
+	return n, nil
+}
@@ -0,0 +1,193 @@
+package mathchannel
+
+import (
+	"maps"
+	"math"
+	"sync"
+
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+var _ logfile.Logfile = (*Logfile)(nil)
+
+const (
+	// number of records calculated together when a record is first accessed
+	blockSize = 1024
+	// number of calculated blocks kept in memory
+	cachedBlocks = 4
+)
+
+// Logfile adds math channels to the records of another logfile. The values are calculated in blocks when a record
+// is accessed. derivative and avg are replayed over the records before a block first, so they give the same
+// result however the log is browsed.
+type Logfile struct {
+	logfile.Logfile
+	channels []*Channel
+	names    []string
+	// history is how many records before a block are replayed
+	history int
+
+	blocks   map[int][][]float64 // blocks[block][record][channel], NaN when not available
+	blockLRU []int
+
+	mu sync.Mutex
+}
+
+// NewLogfile wraps lf, channels are compiled from defs so they don't share state with anything else.
+// Channels using a symbol that is not in the log are left out, lf is returned as is when none are left.
+func NewLogfile(lf logfile.Logfile, defs []Definition) (logfile.Logfile, error) {
+	channels, err := CompileAll(defs)
+	if err != nil {
+		return nil, err
+	}
+	channels = available(channels, logChannels(lf))
+	if len(channels) == 0 {
+		return lf, nil
+	}
+
+	// a channel using another math channel needs the history of that channel as well
+	history := make(map[string]int, len(channels))
+	var maxHistory int
+	for _, ch := range channels {
+		var uses int
+		for _, name := range ch.Vars() {
+			uses = max(uses, history[name])
+		}
+		h := ch.expr.History() + uses
+		history[ch.Name] = h
+		maxHistory = max(maxHistory, h)
+	}
+
+	return &Logfile{
+		Logfile:  lf,
+		channels: channels,
+		names:    Names(channels),
+		history:  maxHistory,
+		blocks:   make(map[int][][]float64),
+	}, nil
+}
+
+// logChannels returns the channels in the first record of lf and in its metadata
+func logChannels(lf logfile.Logfile) map[string]bool {
+	names := make(map[string]bool)
+	for _, ch := range lf.Metadata().Channels {
+		names[ch.Name] = true
+	}
+	cursor := lf.Cursor()
+	lf.Seek(-1)
+	for name := range lf.Next().Values {
+		names[name] = true
+	}
+	lf.Seek(cursor)
+	return names
+}
+
+// available returns the channels that only use names or channels before them
+func available(channels []*Channel, names map[string]bool) []*Channel {
+	names = maps.Clone(names)
+	var out []*Channel
+outer:
+	for _, ch := range channels {
+		for _, name := range ch.Vars() {
+			if !names[name] {
+				continue outer
+			}
+		}
+		names[ch.Name] = true
+		out = append(out, ch)
+	}
+	return out
+}
+
+// block calculates the math channels of block n, the position of the wrapped logfile is kept
+func (l *Logfile) block(n int) [][]float64 {
+	if b, ok := l.blocks[n]; ok {
+		return b
+	}
+
+	first := n * blockSize
+	last := min(first+blockSize, l.Logfile.Len()) - 1
+	from := max(first-l.history, 0)
+
+	cursor := l.Logfile.Cursor()
+	defer l.Logfile.Seek(cursor)
+
+	for _, ch := range l.channels {
+		ch.Reset()
+	}
+	b := make([][]float64, 0, last-first+1)
+	// the values of the record and the math channels calculated so far
+	calculated := make(map[string]float64)
+	get := func(name string) (float64, bool) {
+		v, ok := calculated[name]
+		return v, ok
+	}
+	l.Logfile.Seek(from - 1)
+	for i := from; i <= last; i++ {
+		rec := l.Logfile.Next()
+		if rec.EOF {
+			break
+		}
+		clear(calculated)
+		for k, v := range rec.Values {
+			calculated[k] = v
+		}
+		row := make([]float64, len(l.channels))
+		for j, ch := range l.channels {
+			v, ok := ch.Eval(rec.Time, get)
+			if !ok {
+				row[j] = math.NaN()
+				continue
+			}
+			calculated[ch.Name] = v
+			row[j] = v
+		}
+		if i >= first {
+			b = append(b, row)
+		}
+	}
+
+	l.blocks[n] = b
+	l.blockLRU = append(l.blockLRU, n)
+	if len(l.blockLRU) > cachedBlocks {
+		delete(l.blocks, l.blockLRU[0])
+		l.blockLRU = l.blockLRU[1:]
+	}
+	return b
+}
+
+func (l *Logfile) add(rec logfile.Record) logfile.Record {
+	if rec.EOF {
+		return rec
+	}
+	pos := l.Logfile.Pos()
+	b := l.block(pos / blockSize)
+	if row := pos % blockSize; row < len(b) {
+		values := maps.Clone(rec.Values)
+		for i, v := range b[row] {
+			if !math.IsNaN(v) {
+				values[l.names[i]] = v
+			}
+		}
+		rec.Values = values
+	}
+	return rec
+}
+
+func (l *Logfile) Get() logfile.Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.add(l.Logfile.Get())
+}
+
+func (l *Logfile) Next() logfile.Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.add(l.Logfile.Next())
+}
+
+func (l *Logfile) Prev() logfile.Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.add(l.Logfile.Prev())
+}
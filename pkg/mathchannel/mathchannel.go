@@ -0,0 +1,154 @@
+package mathchannel
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/roffe/txlogger/pkg/eventbus"
+)
+
+const prefsMathChannels = "mathChannels"
+
+// Definition is a user defined channel calculated from other channels
+type Definition struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Unit       string `json:"unit,omitempty"`
+}
+
+// Channel is a compiled Definition
+type Channel struct {
+	Definition
+	expr *Expression
+}
+
+// Eval calculates the channel value at ts
+func (c *Channel) Eval(ts time.Time, get func(string) (float64, bool)) (float64, bool) {
+	return c.expr.Eval(ts, get)
+}
+
+// Vars returns the channel names the channel depends on
+func (c *Channel) Vars() []string {
+	return c.expr.Vars()
+}
+
+// Reset clears state kept by derivative and avg
+func (c *Channel) Reset() {
+	c.expr.Reset()
+}
+
+// CompileAll compiles all definitions. A channel may only use math channels defined before it,
+// which rules out loops when the channels are published on the event bus.
+func CompileAll(defs []Definition) ([]*Channel, error) {
+	var channels []*Channel
+	names := make(map[string]int)
+	for i, def := range defs {
+		def.Name = strings.TrimSpace(def.Name)
+		if def.Name == "" {
+			return nil, fmt.Errorf("math channel %d has no name", i+1)
+		}
+		if _, found := names[def.Name]; found {
+			return nil, fmt.Errorf("math channel %s is defined twice", def.Name)
+		}
+		expr, err := Compile(def.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("math channel %s: %w", def.Name, err)
+		}
+		for _, v := range expr.Vars() {
+			if v == def.Name {
+				return nil, fmt.Errorf("math channel %s can't use itself", def.Name)
+			}
+		}
+		names[def.Name] = i
+		channels = append(channels, &Channel{Definition: def, expr: expr})
+	}
+	for i, ch := range channels {
+		for _, v := range ch.Vars() {
+			if j, found := names[v]; found && j > i {
+				return nil, fmt.Errorf("math channel %s uses %s which is defined after it", ch.Name, v)
+			}
+		}
+	}
+	return channels, nil
+}
+
+// Defaults returns the math channels a new installation starts out with
+func Defaults(ecu string) []Definition {
+	afr := Definition{Name: "AFR", Expression: "Lambda.External * 14.7", Unit: "AFR"}
+	switch ecu {
+	case "T7":
+		return []Definition{
+			afr,
+			{Name: "BoostBar", Expression: "In.p_AirBefThrottle - 1.013", Unit: "Bar"},
+			{Name: "IgnDelta", Expression: "Out.fi_Ignition - IgnProt.fi_Offset", Unit: "°"},
+		}
+	case "T8":
+		return []Definition{
+			afr,
+			{Name: "BoostBar", Expression: "ActualIn.p_AirBefThrottle - 1.013", Unit: "Bar"},
+			{Name: "IgnDelta", Expression: "Out.fi_Ignition - IgnMastProt.fi_Offset", Unit: "°"},
+		}
+	}
+	return []Definition{afr}
+}
+
+// Load returns the math channel definitions stored for ecu
+func Load(prefs fyne.Preferences, ecu string) ([]Definition, error) {
+	data := prefs.String(prefsMathChannels + ecu)
+	if data == "" {
+		return Defaults(ecu), nil
+	}
+	var defs []Definition
+	if err := json.Unmarshal([]byte(data), &defs); err != nil {
+		return Defaults(ecu), fmt.Errorf("failed to load math channels: %w", err)
+	}
+	return defs, nil
+}
+
+// Save stores the math channel definitions for ecu
+func Save(prefs fyne.Preferences, ecu string, defs []Definition) error {
+	if _, err := CompileAll(defs); err != nil {
+		return err
+	}
+	data, err := json.Marshal(defs)
+	if err != nil {
+		return err
+	}
+	prefs.SetString(prefsMathChannels+ecu, string(data))
+	return nil
+}
+
+// Apply adds the math channel values to values, channels that can't be calculated are left out
+func Apply(channels []*Channel, ts time.Time, values map[string]float64) {
+	get := func(name string) (float64, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+	for _, ch := range channels {
+		if v, ok := ch.Eval(ts, get); ok {
+			values[ch.Name] = v
+		}
+	}
+}
+
+// Aggregators returns event bus aggregators that publish the math channels live.
+// The channels should not be shared with anything else since derivative and avg keep state.
+func Aggregators(channels []*Channel) []*eventbus.EventAggregator {
+	aggs := make([]*eventbus.EventAggregator, 0, len(channels))
+	for _, ch := range channels {
+		aggs = append(aggs, eventbus.ExpressionAggregator(ch.Name, ch))
+	}
+	return aggs
+}
+
+// Names returns the names of the channels
+func Names(channels []*Channel) []string {
+	names := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		names = append(names, ch.Name)
+	}
+	return names
+}
@@ -0,0 +1,220 @@
+// Package metrics serves live ECU values and logger health to Prometheus
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	symbol "github.com/roffe/ecusymbol"
+)
+
+const DefaultAddress = ":9090"
+
+const (
+	contentTypeText        = "text/plain; version=0.0.4; charset=utf-8"
+	contentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// State is updated by the logger and read on every scrape
+type State struct {
+	mu    sync.RWMutex
+	ecu   string
+	units map[string]string
+
+	captures  atomic.Int64
+	errors    atomic.Int64
+	errorRate atomic.Int64
+	fps       atomic.Int64
+}
+
+func NewState() *State {
+	return &State{units: make(map[string]string)}
+}
+
+// SetSymbols sets the values exported while logging, the symbols being logged and channels without a unit that are
+// added to every sample. Other values, like those left from an earlier session, are not exported
+func (s *State) SetSymbols(ecu string, symbols []*symbol.Symbol, channels ...string) {
+	units := make(map[string]string, len(symbols)+len(channels))
+	for _, name := range channels {
+		units[name] = ""
+	}
+	for _, sym := range symbols {
+		units[sym.Name] = sym.Unit
+	}
+	s.mu.Lock()
+	s.ecu = ecu
+	s.units = units
+	s.mu.Unlock()
+}
+
+// Clear stops exporting values when logging ends, the last values would otherwise look live
+func (s *State) Clear() {
+	s.mu.Lock()
+	s.units = make(map[string]string)
+	s.mu.Unlock()
+}
+
+func (s *State) SetCaptures(n int)  { s.captures.Store(int64(n)) }
+func (s *State) SetErrors(n int)    { s.errors.Store(int64(n)) }
+func (s *State) SetErrorRate(n int) { s.errorRate.Store(int64(n)) }
+func (s *State) SetFPS(n int)       { s.fps.Store(int64(n)) }
+
+// Exporter serves /metrics over HTTP
+type Exporter struct {
+	address string
+	state   *State
+	values  func() map[string]float64
+	srv     *http.Server
+}
+
+// New listens on address, values returns the current value of every channel by name
+func New(address string, state *State, values func() map[string]float64, onMessage func(string)) (*Exporter, error) {
+	if address == "" {
+		address = DefaultAddress
+	}
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+	e := &Exporter{
+		address: address,
+		state:   state,
+		values:  values,
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e)
+	e.srv = &http.Server{Handler: mux}
+	go func() {
+		if err := e.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			onMessage("Metrics: " + err.Error())
+		}
+	}()
+	onMessage("Metrics listening on http://" + ln.Addr().String() + "/metrics")
+	return e, nil
+}
+
+// Address is the address the exporter was started with
+func (e *Exporter) Address() string {
+	return e.address
+}
+
+func (e *Exporter) Close() error {
+	return e.srv.Close()
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	if openMetrics {
+		w.Header().Set("Content-Type", contentTypeOpenMetrics)
+	} else {
+		w.Header().Set("Content-Type", contentTypeText)
+	}
+	e.write(w, openMetrics)
+}
+
+type sample struct {
+	symbol string
+	unit   string
+	value  float64
+}
+
+func (e *Exporter) write(w io.Writer, openMetrics bool) {
+	s := e.state
+	writeCounter(w, "txlogger_captures", "Samples captured since logging started", s.captures.Load(), openMetrics)
+	writeCounter(w, "txlogger_errors", "Read errors since logging started", s.errors.Load(), openMetrics)
+	writeGauge(w, "txlogger_errors_per_second", "Read errors during the last second", s.errorRate.Load())
+	writeGauge(w, "txlogger_fps", "Samples captured during the last second", s.fps.Load())
+
+	s.mu.RLock()
+	ecu, units := s.ecu, s.units
+	s.mu.RUnlock()
+
+	// symbols that sanitize to the same name are one family told apart by the symbol label
+	families := make(map[string][]sample)
+	for name, v := range e.values() {
+		unit, ok := units[name]
+		if !ok {
+			continue
+		}
+		metric := MetricName(name)
+		families[metric] = append(families[metric], sample{symbol: name, unit: unit, value: v})
+	}
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		samples := families[name]
+		slices.SortFunc(samples, func(a, b sample) int { return strings.Compare(a.symbol, b.symbol) })
+		fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+		for _, smp := range samples {
+			fmt.Fprintf(w, "%s{symbol=\"%s\",ecu=\"%s\",unit=\"%s\"} %s\n", name, escapeLabel(smp.symbol), escapeLabel(ecu), escapeLabel(smp.unit), formatValue(smp.value))
+		}
+	}
+	if openMetrics {
+		io.WriteString(w, "# EOF\n")
+	}
+}
+
+// OpenMetrics counters end in _total while the family doesn't, the text format uses the full name for both
+func writeCounter(w io.Writer, name, help string, v int64, openMetrics bool) {
+	family := name
+	if !openMetrics {
+		family += "_total"
+	}
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s_total %d\n", family, help, family, name, v)
+}
+
+func writeGauge(w io.Writer, name, help string, v int64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", name, help, name, name, v)
+}
+
+// MetricName turns a symbol name like ActualIn.n_Engine into a valid metric name, ActualIn_n_Engine
+func MetricName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
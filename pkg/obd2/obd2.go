@@ -0,0 +1,115 @@
+// Package obd2 reads standard OBD-II Mode 01 PIDs over ISO 15765-4 CAN
+package obd2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/roffe/gocan"
+)
+
+const (
+	// FunctionalID reaches every emissions related ECU
+	FunctionalID = 0x7DF
+	// the ECUs answer on 0x7E8-0x7EF and are reached physically on 8 less
+	FirstResponseID = 0x7E8
+	LastResponseID  = 0x7EF
+
+	modeCurrentData  = 0x01
+	negativeResponse = 0x7F
+)
+
+var DefaultTimeout = 150 * time.Millisecond
+
+// ResponseIDs lists the identifiers ECUs answer on
+func ResponseIDs() []uint32 {
+	ids := make([]uint32, 0, LastResponseID-FirstResponseID+1)
+	for id := uint32(FirstResponseID); id <= LastResponseID; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type Client struct {
+	c *gocan.Client
+	// requestID and responseID are the ECU that answered first, functional until then
+	requestID  uint32
+	responseID []uint32
+}
+
+func New(c *gocan.Client) *Client {
+	return &Client{
+		c:          c,
+		requestID:  FunctionalID,
+		responseID: ResponseIDs(),
+	}
+}
+
+// request sends a single frame Mode 01 request and returns the data bytes of the answer
+func (cl *Client) request(ctx context.Context, pid byte) ([]byte, error) {
+	frame := gocan.NewFrame(cl.requestID, []byte{0x02, modeCurrentData, pid, 0x00, 0x00, 0x00, 0x00, 0x00}, gocan.ResponseRequired)
+	resp, err := cl.c.SendAndWait(ctx, frame, DefaultTimeout, cl.responseID...)
+	if err != nil {
+		return nil, fmt.Errorf("PID $%02X: %w", pid, err)
+	}
+	d := resp.Data
+	if len(d) < 3 || d[0] < 2 || int(d[0]) >= len(d) {
+		return nil, fmt.Errorf("PID $%02X: invalid response %X", pid, d)
+	}
+	if d[1] == negativeResponse && len(d) > 3 {
+		return nil, fmt.Errorf("PID $%02X: negative response $%02X", pid, d[3])
+	}
+	if d[1] != modeCurrentData|0x40 || d[2] != pid {
+		return nil, fmt.Errorf("PID $%02X: unexpected response %X", pid, d)
+	}
+	if cl.requestID == FunctionalID {
+		cl.requestID = resp.Identifier - 8
+		cl.responseID = []uint32{resp.Identifier}
+	}
+	return d[3 : 1+int(d[0])], nil
+}
+
+// SupportedPIDs asks the ECU which PIDs it supports through the $00, $20 and $40 bitmaps
+func (cl *Client) SupportedPIDs(ctx context.Context) (map[byte]bool, error) {
+	supported := make(map[byte]bool)
+	for base := byte(0x00); base <= 0x40; base += 0x20 {
+		d, err := cl.request(ctx, base)
+		if err != nil {
+			if base == 0x00 {
+				return nil, err
+			}
+			break
+		}
+		if len(d) < 4 {
+			return nil, fmt.Errorf("PID $%02X: expected 4 bytes, got %d", base, len(d))
+		}
+		bitmap := uint32(d[0])<<24 | uint32(d[1])<<16 | uint32(d[2])<<8 | uint32(d[3])
+		for i := range 32 {
+			if bitmap&(1<<(31-i)) != 0 {
+				supported[base+byte(i)+1] = true
+			}
+		}
+		// the last PID of the bitmap says if the next bitmap is there
+		if !supported[base+0x20] {
+			break
+		}
+	}
+	return supported, nil
+}
+
+// ReadPID reads and decodes a PID from the catalogue
+func (cl *Client) ReadPID(ctx context.Context, pid byte) (float64, error) {
+	p, ok := Lookup(pid)
+	if !ok {
+		return 0, fmt.Errorf("PID $%02X is not in the catalogue", pid)
+	}
+	d, err := cl.request(ctx, pid)
+	if err != nil {
+		return 0, err
+	}
+	if len(d) < p.Length {
+		return 0, fmt.Errorf("PID $%02X: expected %d bytes, got %d", pid, p.Length, len(d))
+	}
+	return p.Decode(d), nil
+}
@@ -0,0 +1,124 @@
+package obd2
+
+import (
+	symbol "github.com/roffe/ecusymbol"
+)
+
+// PID is a standard Mode 01 parameter
+type PID struct {
+	PID  byte
+	Name string
+	Unit string
+	// Length is the number of data bytes in the response
+	Length int
+	// Precision is the number of decimals worth showing
+	Precision int
+	Decode    func(d []byte) float64
+}
+
+func ab(d []byte) float64 {
+	return float64(uint16(d[0])<<8 | uint16(d[1]))
+}
+
+func percent(d []byte) float64 {
+	return float64(d[0]) * 100 / 255
+}
+
+func temperature(d []byte) float64 {
+	return float64(d[0]) - 40
+}
+
+func fuelTrim(d []byte) float64 {
+	return float64(d[0])*100/128 - 100
+}
+
+func lambda(d []byte) float64 {
+	return ab(d) * 2 / 65536
+}
+
+// PIDs is the catalogue of Mode 01 PIDs that can be logged, used in place of a symbol table
+var PIDs = []PID{
+	{0x04, "OBD2.EngineLoad", "%", 1, 1, percent},
+	{0x05, "OBD2.CoolantTemp", "°C", 1, 0, temperature},
+	{0x06, "OBD2.ShortFuelTrimB1", "%", 1, 1, fuelTrim},
+	{0x07, "OBD2.LongFuelTrimB1", "%", 1, 1, fuelTrim},
+	{0x08, "OBD2.ShortFuelTrimB2", "%", 1, 1, fuelTrim},
+	{0x09, "OBD2.LongFuelTrimB2", "%", 1, 1, fuelTrim},
+	{0x0A, "OBD2.FuelPressure", "kPa", 1, 0, func(d []byte) float64 { return float64(d[0]) * 3 }},
+	{0x0B, "OBD2.MAP", "kPa", 1, 0, func(d []byte) float64 { return float64(d[0]) }},
+	{0x0C, "OBD2.EngineRPM", "rpm", 2, 0, func(d []byte) float64 { return ab(d) / 4 }},
+	{0x0D, "OBD2.VehicleSpeed", "km/h", 1, 0, func(d []byte) float64 { return float64(d[0]) }},
+	{0x0E, "OBD2.TimingAdvance", "°", 1, 1, func(d []byte) float64 { return float64(d[0])/2 - 64 }},
+	{0x0F, "OBD2.IntakeAirTemp", "°C", 1, 0, temperature},
+	{0x10, "OBD2.MAF", "g/s", 2, 2, func(d []byte) float64 { return ab(d) / 100 }},
+	{0x11, "OBD2.ThrottlePosition", "%", 1, 1, percent},
+	{0x14, "OBD2.O2VoltageB1S1", "V", 2, 3, func(d []byte) float64 { return float64(d[0]) / 200 }},
+	{0x15, "OBD2.O2VoltageB1S2", "V", 2, 3, func(d []byte) float64 { return float64(d[0]) / 200 }},
+	{0x1F, "OBD2.RunTime", "s", 2, 0, ab},
+	{0x21, "OBD2.DistanceWithMIL", "km", 2, 0, ab},
+	{0x22, "OBD2.FuelRailPressureRel", "kPa", 2, 1, func(d []byte) float64 { return ab(d) * 0.079 }},
+	{0x23, "OBD2.FuelRailPressure", "kPa", 2, 0, func(d []byte) float64 { return ab(d) * 10 }},
+	{0x24, "OBD2.LambdaB1S1", "λ", 4, 3, lambda},
+	{0x2C, "OBD2.CommandedEGR", "%", 1, 1, percent},
+	{0x2F, "OBD2.FuelLevel", "%", 1, 1, percent},
+	{0x33, "OBD2.BarometricPressure", "kPa", 1, 0, func(d []byte) float64 { return float64(d[0]) }},
+	{0x34, "OBD2.LambdaCurrentB1S1", "λ", 4, 3, lambda},
+	{0x3C, "OBD2.CatalystTempB1S1", "°C", 2, 0, func(d []byte) float64 { return ab(d)/10 - 40 }},
+	{0x42, "OBD2.ModuleVoltage", "V", 2, 2, func(d []byte) float64 { return ab(d) / 1000 }},
+	{0x43, "OBD2.AbsoluteLoad", "%", 2, 1, func(d []byte) float64 { return ab(d) * 100 / 255 }},
+	{0x44, "OBD2.CommandedLambda", "λ", 2, 3, lambda},
+	{0x45, "OBD2.RelativeThrottle", "%", 1, 1, percent},
+	{0x46, "OBD2.AmbientAirTemp", "°C", 1, 0, temperature},
+	{0x47, "OBD2.AbsoluteThrottleB", "%", 1, 1, percent},
+	{0x49, "OBD2.AcceleratorPedalD", "%", 1, 1, percent},
+	{0x4A, "OBD2.AcceleratorPedalE", "%", 1, 1, percent},
+	{0x4C, "OBD2.CommandedThrottle", "%", 1, 1, percent},
+	{0x52, "OBD2.EthanolPercent", "%", 1, 1, percent},
+	{0x5A, "OBD2.RelativePedal", "%", 1, 1, percent},
+	{0x5C, "OBD2.OilTemp", "°C", 1, 0, temperature},
+	{0x5E, "OBD2.FuelRate", "L/h", 2, 2, func(d []byte) float64 { return ab(d) / 20 }},
+}
+
+// Lookup returns the catalogue entry of pid
+func Lookup(pid byte) (PID, bool) {
+	for _, p := range PIDs {
+		if p.PID == pid {
+			return p, true
+		}
+	}
+	return PID{}, false
+}
+
+// Symbol returns the catalogue entry as a symbol for the symbol list, Number is the PID
+func (p PID) Symbol() *symbol.Symbol {
+	factor := 1.0
+	for range p.Precision {
+		factor /= 10
+	}
+	return &symbol.Symbol{
+		Name:             p.Name,
+		Number:           int(p.PID),
+		Length:           uint16(p.Length),
+		Unit:             p.Unit,
+		Correctionfactor: factor,
+	}
+}
+
+// Symbols returns the catalogue as symbols
+func Symbols() []*symbol.Symbol {
+	out := make([]*symbol.Symbol, len(PIDs))
+	for i, p := range PIDs {
+		out[i] = p.Symbol()
+	}
+	return out
+}
+
+// SymbolByName returns the catalogue entry called name as a symbol, nil if there is none
+func SymbolByName(name string) *symbol.Symbol {
+	for _, p := range PIDs {
+		if p.Name == name {
+			return p.Symbol()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package perf
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+)
+
+const prefsPerfTiming = "perfTiming"
+
+// LoadConfig returns the stored timing settings
+func LoadConfig(prefs fyne.Preferences) (Config, error) {
+	data := prefs.String(prefsPerfTiming)
+	if data == "" {
+		return DefaultConfig(), nil
+	}
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		return DefaultConfig(), fmt.Errorf("failed to load timing settings: %w", err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig stores the timing settings
+func SaveConfig(prefs fyne.Preferences, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	prefs.SetString(prefsPerfTiming, string(data))
+	return nil
+}
@@ -0,0 +1,298 @@
+// Package perf times standard acceleration runs like 0-100 km/h and the quarter mile from vehicle speed
+package perf
+
+import (
+	"errors"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/eventbus"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+const (
+	mph = 1.609344 // km/h
+
+	// maxGap is the longest time between samples a run survives
+	maxGap = time.Second
+	// abortDrop is how far in km/h the speed may drop below the top speed of a run before it's cancelled
+	abortDrop  = 5.0
+	maxRunTime = 2 * time.Minute
+)
+
+// SpeedChannels are the vehicle speed channels in order of preference
+var SpeedChannels = []string{"In.v_Vehicle", "Bil_hast", "OBD2.VehicleSpeed", datalogger.GPSSPEEDSYM}
+
+// Interval is a timed run, from one speed to another or over a distance from standstill
+type Interval struct {
+	Name string
+	// From and To are speeds in km/h, From is 0 for standing starts
+	From, To float64
+	// Distance in meters ends the run instead of To when set
+	Distance float64
+}
+
+func (iv Interval) standing() bool {
+	return iv.From == 0
+}
+
+// Intervals are the runs timed
+var Intervals = []Interval{
+	{Name: "0-100 km/h", To: 100},
+	{Name: "80-120 km/h", From: 80, To: 120},
+	{Name: "100-200 km/h", From: 100, To: 200},
+	{Name: "0-60 mph", To: 60 * mph},
+	{Name: "60-130 mph", From: 60 * mph, To: 130 * mph},
+	{Name: "1/8 mile", Distance: 201.168},
+	{Name: "1/4 mile", Distance: 402.336},
+}
+
+// Lookup returns the interval named name
+func Lookup(name string) (Interval, bool) {
+	for _, iv := range Intervals {
+		if iv.Name == name {
+			return iv, true
+		}
+	}
+	return Interval{}, false
+}
+
+// Config for timing runs
+type Config struct {
+	// Channel is the speed channel in km/h, detected from SpeedChannels when empty
+	Channel string
+	// StartThreshold is the speed in km/h a standing start begins at, it has to be below it before
+	StartThreshold float64
+	// Rollout is the distance in meters moved before the time of a standing start begins,
+	// 0.3 m matches the rollout of a drag strip
+	Rollout float64
+}
+
+func DefaultConfig() Config {
+	return Config{StartThreshold: 1}
+}
+
+func (c Config) Validate() error {
+	if c.StartThreshold < 0 || c.Rollout < 0 {
+		return errors.New("start threshold and rollout can't be negative")
+	}
+	return nil
+}
+
+// Result is a completed run
+type Result struct {
+	Interval string
+	Start    time.Time
+	Duration time.Duration
+	Distance float64 // m
+	// Speed is the speed in km/h at the end, the trap speed of distance runs
+	Speed float64
+	// Pos is the record of the log the run started at, -1 for live runs
+	Pos int
+}
+
+type sample struct {
+	t time.Time
+	v float64
+}
+
+// Timer times every interval from samples of the vehicle speed
+type Timer struct {
+	cfg  Config
+	runs []run
+	last sample
+	have bool
+}
+
+func NewTimer(cfg Config) *Timer {
+	t := &Timer{cfg: cfg}
+	for _, iv := range Intervals {
+		t.runs = append(t.runs, run{iv: iv})
+	}
+	return t
+}
+
+// Update feeds the speed at now, pos is the record of the log it is from. It returns the runs completed by it
+func (t *Timer) Update(now time.Time, speed float64, pos int) []Result {
+	prev, cur := t.last, sample{t: now, v: speed}
+	first := !t.have
+	t.last, t.have = cur, true
+	dt := now.Sub(prev.t)
+	if first || dt > maxGap {
+		for i := range t.runs {
+			t.runs[i] = run{iv: t.runs[i].iv}
+			t.runs[i].arm(cur.v, t.cfg)
+		}
+		return nil
+	}
+	if dt <= 0 {
+		return nil
+	}
+	var out []Result
+	for i := range t.runs {
+		if res, ok := t.runs[i].step(prev, cur, pos, t.cfg); ok {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Aggregator times runs from the live vehicle speed, onResult is called on the event bus goroutine
+func (t *Timer) Aggregator(onResult func(Result)) *eventbus.EventAggregator {
+	topics := SpeedChannels
+	if t.cfg.Channel != "" {
+		topics = []string{t.cfg.Channel}
+	}
+	var channel string
+	return eventbus.NewAggregator(topics, func(_ eventbus.DiffPublisher, name string, value float64) {
+		// the first speed channel heard is used so runs aren't timed from two sources at once
+		if channel == "" {
+			channel = name
+		}
+		if name != channel {
+			return
+		}
+		for _, r := range t.Update(time.Now(), value, -1) {
+			onResult(r)
+		}
+	})
+}
+
+// Scan times the runs in lf, lf is rewound afterwards
+func Scan(lf logfile.Logfile, cfg Config) ([]Result, error) {
+	t := NewTimer(cfg)
+	channel := cfg.Channel
+	var results []Result
+	lf.Seek(-1)
+	defer lf.Seek(-1)
+	for rec := lf.Next(); !rec.EOF; rec = lf.Next() {
+		if channel == "" {
+			for _, c := range SpeedChannels {
+				if _, ok := rec.Values[c]; ok {
+					channel = c
+					break
+				}
+			}
+		}
+		v, ok := rec.Values[channel]
+		if !ok {
+			continue
+		}
+		results = append(results, t.Update(rec.Time, v, lf.Pos())...)
+	}
+	if channel == "" {
+		return nil, errors.New("the log has no vehicle speed")
+	}
+	return results, nil
+}
+
+// run is the state of timing one interval
+type run struct {
+	iv Interval
+	// armed is set when the speed has been below the start of the interval
+	armed  bool
+	moving bool
+	timing bool
+	start  time.Time
+	pos    int
+	// rolled is the distance covered of the rollout, dist that since the time began
+	rolled float64
+	dist   float64
+	top    float64
+}
+
+func (r *run) startSpeed(cfg Config) float64 {
+	if r.iv.standing() {
+		return cfg.StartThreshold
+	}
+	return r.iv.From
+}
+
+// arm resets the run when the speed is below its start, standing starts have to come to a stop
+func (r *run) arm(v float64, cfg Config) bool {
+	from := r.startSpeed(cfg)
+	if v < from || r.iv.standing() && v <= from {
+		*r = run{iv: r.iv, armed: true}
+		return true
+	}
+	return false
+}
+
+func (r *run) step(a, b sample, pos int, cfg Config) (Result, bool) {
+	if r.arm(b.v, cfg) || !r.armed {
+		return Result{}, false
+	}
+	// seg is where the part of the step the car moved in the run begins
+	seg := a
+	if !r.moving {
+		from := r.startSpeed(cfg)
+		seg = interpolate(a, b, fraction(from, a.v, b.v))
+		r.moving = true
+	}
+	r.top = max(r.top, b.v)
+	if !r.timing {
+		var rollout float64
+		if r.iv.standing() {
+			rollout = cfg.Rollout - r.rolled
+		}
+		d := distance(seg, b)
+		if d < rollout {
+			r.rolled += d
+			return Result{}, false
+		}
+		f := 0.0
+		if d > 0 {
+			f = rollout / d
+		}
+		seg = interpolate(seg, b, f)
+		r.timing = true
+		r.start = seg.t
+		r.pos = pos
+	}
+	if b.v < r.top-abortDrop || b.t.Sub(r.start) > maxRunTime {
+		*r = run{iv: r.iv}
+		return Result{}, false
+	}
+
+	d := distance(seg, b)
+	var end sample
+	switch {
+	case r.iv.Distance > 0 && r.dist+d >= r.iv.Distance:
+		end = interpolate(seg, b, (r.iv.Distance-r.dist)/d)
+	case r.iv.Distance == 0 && b.v >= r.iv.To:
+		end = interpolate(seg, b, fraction(r.iv.To, seg.v, b.v))
+	default:
+		r.dist += d
+		return Result{}, false
+	}
+	res := Result{
+		Interval: r.iv.Name,
+		Start:    r.start,
+		Duration: end.t.Sub(r.start),
+		Distance: r.dist + distance(seg, end),
+		Speed:    end.v,
+		Pos:      r.pos,
+	}
+	*r = run{iv: r.iv}
+	return res, true
+}
+
+// fraction returns where between v0 and v1 the speed passes v
+func fraction(v, v0, v1 float64) float64 {
+	if v1 == v0 {
+		return 1
+	}
+	return min(max((v-v0)/(v1-v0), 0), 1)
+}
+
+func interpolate(a, b sample, f float64) sample {
+	return sample{
+		t: a.t.Add(time.Duration(f * float64(b.t.Sub(a.t)))),
+		v: a.v + f*(b.v-a.v),
+	}
+}
+
+// distance in meters covered between a and b accelerating evenly
+func distance(a, b sample) float64 {
+	return (a.v + b.v) / 2 / 3.6 * b.t.Sub(a.t).Seconds()
+}
@@ -0,0 +1,99 @@
+package perf
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
+// Session is the runs of one log or logging session
+type Session struct {
+	Name string
+	// Filename is the log the runs are from, empty when not logged to file
+	Filename string
+	Results  []Result
+}
+
+// Ranked is a result with the session it is from
+type Ranked struct {
+	Session  string
+	Filename string
+	Result
+}
+
+// Store keeps the sessions timed since the start of txlogger, it is safe to use from several goroutines
+type Store struct {
+	mu       sync.Mutex
+	sessions []*Session
+	onChange func()
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+// SetOnChange sets the function called when a session or result is added, nil removes it
+func (s *Store) SetOnChange(fn func()) {
+	s.mu.Lock()
+	s.onChange = fn
+	s.mu.Unlock()
+}
+
+// Start adds a new session, a session for the same log replaces the old one
+func (s *Store) Start(name, filename string, results ...Result) *Session {
+	session := &Session{Name: name, Filename: filename, Results: results}
+	s.mu.Lock()
+	s.sessions = slices.DeleteFunc(s.sessions, func(old *Session) bool {
+		return filename != "" && old.Filename == filename
+	})
+	s.sessions = append(s.sessions, session)
+	fn := s.onChange
+	s.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+	return session
+}
+
+// Add adds the result of a run to session
+func (s *Store) Add(session *Session, r Result) {
+	s.mu.Lock()
+	session.Results = append(session.Results, r)
+	fn := s.onChange
+	s.mu.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
+
+// Sessions returns a copy of the sessions, oldest first
+func (s *Store) Sessions() []Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Session, len(s.sessions))
+	for i, session := range s.sessions {
+		out[i] = *session
+		out[i].Results = slices.Clone(session.Results)
+	}
+	return out
+}
+
+// Best returns the n fastest runs of every interval over all sessions, in the order of Intervals
+func Best(sessions []Session, n int) []Ranked {
+	var out []Ranked
+	for _, iv := range Intervals {
+		var ranked []Ranked
+		for _, session := range sessions {
+			for _, r := range session.Results {
+				if r.Interval == iv.Name {
+					ranked = append(ranked, Ranked{Session: session.Name, Filename: session.Filename, Result: r})
+				}
+			}
+		}
+		slices.SortStableFunc(ranked, func(a, b Ranked) int {
+			return cmp.Compare(a.Duration, b.Duration)
+		})
+		out = append(out, ranked[:min(n, len(ranked))]...)
+	}
+	return out
+}
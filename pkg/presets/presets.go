@@ -8,6 +8,7 @@ import (
 
 	"fyne.io/fyne/v2"
 	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/datalogger"
 )
 
 var Map = map[string]string{
@@ -25,11 +26,11 @@ func Names() []string {
 	return names
 }
 
-func Set(name string, symbols []*symbol.Symbol) error {
-	if strings.EqualFold(name, "T5 Dash") || strings.EqualFold(name, "T7 Dash") || strings.EqualFold(name, "T8 Dash") {
+func Set(name string, symbols []*symbol.Symbol, rates map[string]datalogger.RateClass) error {
+	if strings.EqualFold(name, "T5 Dash") || strings.EqualFold(name, "T7 Dash") || strings.EqualFold(name, "T8 Dash") || strings.EqualFold(name, "OBD2 Dash") {
 		return fmt.Errorf("cannot replace system presets")
 	}
-	data, err := json.Marshal(symbols)
+	data, err := Marshal(symbols, rates)
 	if err != nil {
 		return err
 	}
@@ -38,45 +39,84 @@ func Set(name string, symbols []*symbol.Symbol) error {
 }
 
 func Delete(name string) error {
-	if strings.EqualFold(name, "T5 Dash") || strings.EqualFold(name, "T7 Dash") || strings.EqualFold(name, "T8 Dash") {
+	if strings.EqualFold(name, "T5 Dash") || strings.EqualFold(name, "T7 Dash") || strings.EqualFold(name, "T8 Dash") || strings.EqualFold(name, "OBD2 Dash") {
 		return fmt.Errorf("cannot delete system presets")
 	}
 	delete(Map, name)
 	return nil
 }
 
-func Get(name string) ([]*symbol.Symbol, error) {
+func Get(name string) ([]*symbol.Symbol, map[string]datalogger.RateClass, error) {
 	data, ok := Map[name]
 	if !ok {
-		return nil, fmt.Errorf("preset not found")
+		return nil, nil, fmt.Errorf("preset not found")
 	}
+	return Unmarshal([]byte(data))
+}
 
-	var symbols []*symbol.Symbol
-	err := json.Unmarshal([]byte(data), &symbols)
+// Marshal encodes symbols as a preset, the rate class is stored as an extra field on
+// the symbols that have one so older versions can still read the preset
+func Marshal(symbols []*symbol.Symbol, rates map[string]datalogger.RateClass) ([]byte, error) {
+	data, err := json.Marshal(symbols)
 	if err != nil {
 		return nil, err
 	}
+	if len(rates) == 0 {
+		return data, nil
+	}
+	var entries []map[string]any
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for i, sym := range symbols {
+		if rate, ok := rates[sym.Name]; ok && rate != datalogger.RateNormal {
+			entries[i]["RateClass"] = rate
+		}
+	}
+	return json.Marshal(entries)
+}
 
-	return symbols, nil
+// Unmarshal decodes a preset and the rate classes of its symbols
+func Unmarshal(data []byte) ([]*symbol.Symbol, map[string]datalogger.RateClass, error) {
+	var symbols []*symbol.Symbol
+	if err := json.Unmarshal(data, &symbols); err != nil {
+		return nil, nil, err
+	}
+	var entries []struct {
+		Name      string
+		RateClass datalogger.RateClass
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, nil, err
+	}
+	rates := make(map[string]datalogger.RateClass)
+	for _, e := range entries {
+		if e.RateClass != "" {
+			rates[e.Name] = e.RateClass
+		}
+	}
+	return symbols, rates, nil
 }
 
 func Load(app fyne.App) error {
 	presets := app.Preferences().String("presets")
 	if presets == "" {
-		setDefaults()
+		SetDefaults()
 		return nil
 	}
 	if err := json.Unmarshal([]byte(presets), &Map); err != nil {
 		return err
 	}
-	setDefaults()
+	SetDefaults()
 	return nil
 }
 
-func setDefaults() {
+// SetDefaults adds the system presets, Load does it too
+func SetDefaults() {
 	Map["T5 Dash"] = `[{"Name":"Rpm","Number":86,"SramOffset":4194,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Medeltrot","Number":80,"SramOffset":4150,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Ign_angle","Number":168,"SramOffset":4228,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Lufttemp","Number":75,"SramOffset":4145,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"P_medel","Number":320,"SramOffset":10751,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Max_tryck","Number":312,"SramOffset":10747,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Regl_tryck","Number":315,"SramOffset":10748,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.01},{"Name":"PWM_ut10","Number":318,"SramOffset":10754,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"P_fak","Number":313,"SramOffset":11046,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"I_fak","Number":314,"SramOffset":11044,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"D_fak","Number":311,"SramOffset":11042,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"AD_EGR","Number":9,"SramOffset":4118,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Kyl_temp","Number":72,"SramOffset":4141,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Bil_hast","Number":60,"SramOffset":4123,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Knock_offset1234","Number":131,"SramOffset":4236,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Batt_volt","Number":61,"SramOffset":4122,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Insptid_ms10","Number":64,"SramOffset":4190,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1},{"Name":"Lambdaint","Number":73,"SramOffset":4143,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1}]`
 	Map["T7 Dash"] = `[{"Name":"ActualIn.n_Engine","Number":3461,"SramOffset":15727628,"Address":15788902,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":1},{"Name":"Out.X_AccPedal","Number":3671,"SramOffset":15727628,"Address":15789338,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"},{"Name":"In.v_Vehicle","Number":3408,"SramOffset":15727628,"Address":15788818,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"Km/h"},{"Name":"ActualIn.T_Engine","Number":3468,"SramOffset":15727628,"Address":15788918,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":1},{"Name":"ActualIn.T_AirInlet","Number":3469,"SramOffset":15727628,"Address":15788920,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":1},{"Name":"IgnProt.fi_Offset","Number":3044,"SramOffset":15727628,"Address":15787466,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"Degrees"},{"Name":"Out.fi_Ignition","Number":3685,"SramOffset":15727628,"Address":15789368,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"° BTDC"},{"Name":"Out.PWM_BoostCntrl","Number":3644,"SramOffset":15727628,"Address":15789302,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"},{"Name":"ActualIn.p_AirInlet","Number":3471,"SramOffset":15727628,"Address":15788924,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.001},{"Name":"In.p_AirBefThrottle","Number":3394,"SramOffset":15727628,"Address":15788790,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.001,"Unit":"Bar"},{"Name":"ECMStat.p_Diff","Number":3758,"SramOffset":15727628,"Address":15789468,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.001,"Unit":"Bar"},{"Name":"MAF.m_AirInlet","Number":452,"SramOffset":15727628,"Address":15775884,"Length":2,"Mask":0,"Type":32,"ExtendedType":0,"Correctionfactor":1,"Unit":"Mg/c"},{"Name":"m_Request","Number":59,"SramOffset":15727628,"Address":15775192,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1,"Unit":"Mg/c"},{"Name":"ECMStat.ST_ActiveAirDem","Number":3753,"SramOffset":15727628,"Address":15789450,"Length":1,"Mask":0,"Type":36,"ExtendedType":0,"Correctionfactor":1},{"Name":"DisplProt.LambdaScanner","Number":3315,"SramOffset":15727628,"Address":15788688,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.01},{"Name":"Lambda.LambdaInt","Number":2605,"SramOffset":15727628,"Address":15787100,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.01},{"Name":"AdpFuelProt.MulFuelAdapt","Number":2120,"SramOffset":15727628,"Address":15786296,"Length":2,"Mask":0,"Type":33,"ExtendedType":0,"Correctionfactor":0.01},{"Name":"MAF.m_AirFromp_AirInlet","Number":458,"SramOffset":15727628,"Address":15775896,"Length":2,"Mask":0,"Type":32,"ExtendedType":0,"Correctionfactor":1}]`
 	Map["T8 Dash"] = `[{"Name":"ActualIn.n_Engine","Number":4009,"SramOffset":0,"Address":1067840,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":1},{"Name":"Out.X_AccPos","Number":4533,"SramOffset":0,"Address":1068086,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.1},{"Name":"In.v_Vehicle","Number":3872,"SramOffset":0,"Address":1067620,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"Km/h"},{"Name":"ActualIn.T_Engine","Number":3982,"SramOffset":0,"Address":1067782,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":1},{"Name":"ActualIn.T_AirInlet","Number":3998,"SramOffset":0,"Address":1067816,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":1},{"Name":"IgnMastProt.fi_Offset","Number":608,"SramOffset":0,"Address":1057588,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.1},{"Name":"Out.fi_Ignition","Number":4638,"SramOffset":0,"Address":1068240,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"° BTDC"},{"Name":"Out.PWM_BoostCntrl","Number":4611,"SramOffset":0,"Address":1068200,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"},{"Name":"In.p_AirInlet","Number":3851,"SramOffset":0,"Address":1067576,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.001},{"Name":"ActualIn.p_AirBefThrottle","Number":3986,"SramOffset":0,"Address":1067790,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.001},{"Name":"MAF.m_AirInlet","Number":5147,"SramOffset":0,"Address":1068968,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":1,"Unit":"Mg/c"},{"Name":"AirMassMast.m_Request","Number":82,"SramOffset":0,"Address":1056886,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":1},{"Name":"ECMStat.ST_ActiveAirDem","Number":4751,"SramOffset":0,"Address":1068624,"Length":1,"Mask":0,"Type":4,"ExtendedType":0,"Correctionfactor":1},{"Name":"Lambda.LambdaInt","Number":7188,"SramOffset":0,"Address":1077828,"Length":2,"Mask":0,"Type":1,"ExtendedType":0,"Correctionfactor":0.01}]` // ,{"Name":"LambdaScan.LambdaScanner","Number":1373,"SramOffset":0,"Address":1064914,"Length":2,"Mask":0,"Type":1,"ExtendedType":129,"Correctionfactor":0.01}
+	Map["OBD2 Dash"] = `[{"Name":"OBD2.EngineRPM","Number":12,"SramOffset":0,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1,"Unit":"rpm"},{"Name":"OBD2.VehicleSpeed","Number":13,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1,"Unit":"km/h"},{"Name":"OBD2.ThrottlePosition","Number":17,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"},{"Name":"OBD2.CoolantTemp","Number":5,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1,"Unit":"°C"},{"Name":"OBD2.IntakeAirTemp","Number":15,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1,"Unit":"°C"},{"Name":"OBD2.MAP","Number":11,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":1,"Unit":"kPa"},{"Name":"OBD2.EngineLoad","Number":4,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"},{"Name":"OBD2.TimingAdvance","Number":14,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"°"},{"Name":"OBD2.MAF","Number":16,"SramOffset":0,"Address":0,"Length":2,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.01,"Unit":"g/s"},{"Name":"OBD2.ShortFuelTrimB1","Number":6,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"},{"Name":"OBD2.LongFuelTrimB1","Number":7,"SramOffset":0,"Address":0,"Length":1,"Mask":0,"Type":0,"ExtendedType":0,"Correctionfactor":0.1,"Unit":"%"}]`
 }
 
 func Save(app fyne.App) error {
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"num": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	},
+	"fixed": func(decimals int, v float64) string {
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	},
+	"clock": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05.000")
+	},
+	"dur": func(d time.Duration) string {
+		return d.Round(10 * time.Millisecond).String()
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - session report</title>
+<style>
+body { font-family: sans-serif; margin: 20px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 20px; }
+th, td { border: 1px solid #ccc; padding: 3px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+th { background: #eee; }
+img { display: block; margin-bottom: 10px; border: 1px solid #ccc; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><td>ECU</td><td>{{if .ECU}}{{.ECU}}{{else}}unknown{{end}}</td></tr>
+<tr><td>Start</td><td>{{clock .Start}}</td></tr>
+<tr><td>End</td><td>{{clock .End}}</td></tr>
+<tr><td>Duration</td><td>{{dur .Duration}}</td></tr>
+<tr><td>Records</td><td>{{.Records}}</td></tr>
+</table>
+
+<h2>Highlights</h2>
+<table>
+<tr><th></th><th>Value</th><th>RPM</th><th>Time</th></tr>
+{{with .PeakBoost}}{{if .Found}}<tr><td>Peak boost ({{.Channel}})</td><td>{{fixed 3 .Value}}</td><td>{{fixed 0 .RPM}}</td><td>{{clock .Time}}</td></tr>{{end}}{{end}}
+{{with .LeanestLoad}}{{if .Found}}<tr><td>Leanest {{.Channel}} under load</td><td>{{fixed 3 .Value}}</td><td>{{fixed 0 .RPM}}</td><td>{{clock .Time}}</td></tr>{{end}}{{end}}
+</table>
+{{if .LoadChannel}}<p>Under load means {{.LoadChannel}} &ge; {{num .LoadLimit}}.</p>{{end}}
+{{if .KnockChannel}}<p>Knock events ({{.KnockChannel}}): {{.KnockEvents}}{{if .KnockEvents}}, per cylinder:{{range $i, $n := .KnockPerCyl}} cyl {{inc $i}}: {{$n}}{{end}}{{end}}</p>{{end}}
+{{if .FuelCutChannel}}<p>Fuel cuts ({{.FuelCutChannel}} at zero above {{num .FuelCutMinRPM}} rpm): {{.FuelCuts}}</p>{{end}}
+
+{{if .Limiters}}<h2>Active air demand</h2>
+<table>
+<tr><th>Limiter</th><th>Value</th><th>Time</th><th>%</th></tr>
+{{range .Limiters}}<tr><td>{{.Name}}</td><td>{{num .Value}}</td><td>{{dur .Duration}}</td><td>{{fixed 1 .Percent}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Plots}}<h2>Plots</h2>
+{{range .Plots}}<img src="{{.DataURI}}" alt="{{.Name}}">
+{{end}}{{end}}
+
+<h2>Channels</h2>
+<table>
+<tr><th>Channel</th><th>Samples</th><th>Min</th><th>Max</th><th>Mean</th><th>P5</th><th>P50</th><th>P95</th></tr>
+{{range .Channels}}<tr><td>{{.Name}}</td><td>{{.Samples}}</td><td>{{fixed 3 .Min}}</td><td>{{fixed 3 .Max}}</td><td>{{fixed 3 .Mean}}</td><td>{{fixed 3 .P5}}</td><td>{{fixed 3 .P50}}</td><td>{{fixed 3 .P95}}</td></tr>
+{{end}}</table>
+<p>Generated by txlogger {{clock .Generated}}</p>
+</body>
+</html>
+`))
+
+// WriteHTML writes the report as a self contained HTML page
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, struct {
+		*Report
+		Generated time.Time
+	}{r, time.Now()})
+}
+
+// Filename returns the report filename for the log at logFilename, <log name>_report.html
+// in the same folder as the log
+func Filename(logFilename string) string {
+	name := logFilename
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".zst":
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "_report.html"
+}
+
+// Save writes the report to filename
+func (r *Report) Save(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	if err := r.WriteHTML(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,201 @@
+package report
+
+import (
+	"bytes"
+	"encoding/base64"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	plotWidth   = 1000
+	plotHeight  = 220
+	plotMarginL = 70
+	plotMarginR = 10
+	plotMarginT = 20
+	plotMarginB = 25
+)
+
+var (
+	plotBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	plotGrid       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	plotText       = color.RGBA{0x30, 0x30, 0x30, 0xff}
+	plotLine       = color.RGBA{0x1f, 0x77, 0xb4, 0xff}
+)
+
+// Plot is a PNG line plot of one channel over time
+type Plot struct {
+	Name string
+	PNG  []byte
+}
+
+// DataURI returns the plot as an inline image source
+func (p Plot) DataURI() template.URL {
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(p.PNG))
+}
+
+// plotColumn is what one pixel column of a plot covers
+type plotColumn struct {
+	has         bool
+	lo, hi      float64
+	first, last float64
+	// from is the column the line comes from, -1 after a gap in the channel
+	from int
+}
+
+// plotData is a plot reduced to pixel columns while the log is read, the memory used does not grow with the log
+type plotData struct {
+	name       string
+	start, end time.Time
+	span       float64
+	minV, maxV float64
+	cols       [plotWidth - plotMarginL - plotMarginR + 1]plotColumn
+	prev       int
+}
+
+func newPlotData(name string, start, end time.Time) *plotData {
+	span := end.Sub(start).Seconds()
+	if span <= 0 {
+		span = 1
+	}
+	return &plotData{name: name, start: start, end: end, span: span, minV: math.Inf(1), maxV: math.Inf(-1), prev: -1}
+}
+
+// add a sample at t, ok is false when the channel is missing in the record
+func (p *plotData) add(t time.Time, v float64, ok bool) {
+	if !ok {
+		p.prev = -1
+		return
+	}
+	n := len(p.cols) - 1
+	i := min(max(int(t.Sub(p.start).Seconds()/p.span*float64(n)), 0), n)
+	c := &p.cols[i]
+	if !c.has {
+		*c = plotColumn{has: true, lo: v, hi: v, first: v, from: p.prev}
+	}
+	c.lo = math.Min(c.lo, v)
+	c.hi = math.Max(c.hi, v)
+	c.last = v
+	p.minV = math.Min(p.minV, v)
+	p.maxV = math.Max(p.maxV, v)
+	p.prev = i
+}
+
+func (p *plotData) render() (Plot, error) {
+	img := image.NewRGBA(image.Rect(0, 0, plotWidth, plotHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{plotBackground}, image.Point{}, draw.Src)
+
+	minV, maxV := p.minV, p.maxV
+	if maxV == minV {
+		minV--
+		maxV++
+	}
+
+	left, right := plotMarginL, plotWidth-plotMarginR
+	top, bottom := plotMarginT, plotHeight-plotMarginB
+	yOf := func(v float64) int {
+		return bottom - int((v-minV)/(maxV-minV)*float64(bottom-top))
+	}
+
+	for i := range 5 {
+		y := top + i*(bottom-top)/4
+		hline(img, left, right, y, plotGrid)
+		v := maxV - float64(i)*(maxV-minV)/4
+		drawText(img, 4, y+4, strconv.FormatFloat(v, 'f', precision(maxV-minV), 64))
+	}
+	drawText(img, left, 14, p.name)
+	drawText(img, left, plotHeight-6, p.start.Format("15:04:05"))
+	end := p.end.Format("15:04:05")
+	drawText(img, right-len(end)*7, plotHeight-6, end)
+
+	for i, c := range p.cols {
+		if !c.has {
+			continue
+		}
+		x := left + i
+		if c.from >= 0 {
+			line(img, left+c.from, yOf(p.cols[c.from].last), x, yOf(c.first), plotLine)
+		}
+		line(img, x, yOf(c.lo), x, yOf(c.hi), plotLine)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return Plot{}, err
+	}
+	return Plot{Name: p.name, PNG: buf.Bytes()}, nil
+}
+
+// precision returns the number of decimals needed to tell axis labels over span apart
+func precision(span float64) int {
+	switch {
+	case span >= 100:
+		return 0
+	case span >= 10:
+		return 1
+	case span >= 1:
+		return 2
+	}
+	return 3
+}
+
+func hline(img *image.RGBA, x0, x1, y int, c color.Color) {
+	for x := x0; x <= x1; x++ {
+		img.Set(x, y, c)
+	}
+}
+
+// line draws a line with Bresenham's algorithm
+func line(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func drawText(img *image.RGBA, x, y int, s string) {
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(plotText),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
@@ -0,0 +1,386 @@
+package report
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+// Options selects the channels used for the highlights, empty fields are detected from the log
+type Options struct {
+	RPMChannel    string
+	BoostChannel  string
+	LambdaChannel string
+	LoadChannel   string
+	// LoadThreshold is the load channel value from which the engine counts as loaded
+	LoadThreshold float64
+	// PlotChannels are drawn as plots in the report
+	PlotChannels []string
+}
+
+// channels in order of preference, T7 and T8 first, T5 last
+var (
+	rpmChannels    = []string{"ActualIn.n_Engine", "Rpm"}
+	boostChannels  = []string{"In.p_AirBefThrottle", "ActualIn.p_AirBefThrottle", "P_medel"}
+	lambdaChannels = []string{datalogger.EXTERNALWBLSYM, "DisplProt.LambdaScanner"}
+	loadChannels   = []string{"MAF.m_AirInlet", "P_medel"}
+	knockChannels  = []string{"KnkDet.KnockCyl", "Knock_offset1234"}
+	injectChannels = []string{"Myrtilos.InjectorDutyCycle", "Insptid_ms10"}
+)
+
+const (
+	airDemChannel    = "ECMStat.ST_ActiveAirDem"
+	fuelCutMinRPM    = 1500.0
+	defaultLoadAir   = 500.0 // mg/c
+	defaultLoadBoost = 0.5   // bar
+)
+
+// ChannelStats holds the statistics of one channel
+type ChannelStats struct {
+	Name    string
+	Samples int
+	Min     float64
+	Max     float64
+	Mean    float64
+	P5      float64
+	P50     float64
+	P95     float64
+}
+
+// StateTime is the time spent in one limiter state
+type StateTime struct {
+	Value    float64
+	Name     string
+	Duration time.Duration
+	Percent  float64
+}
+
+// Peak is a notable sample in the log
+type Peak struct {
+	Found   bool
+	Channel string
+	Value   float64
+	RPM     float64
+	Load    float64
+	Time    time.Time
+}
+
+// Report is the summary of a logfile
+type Report struct {
+	Title    string
+	ECU      string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+	Records  int
+
+	Channels []ChannelStats
+	Limiters []StateTime
+
+	KnockChannel   string
+	KnockEvents    int
+	KnockPerCyl    [4]int
+	FuelCutChannel string
+	FuelCuts       int
+	FuelCutMinRPM  float64
+
+	PeakBoost     Peak
+	LeanestLoad   Peak
+	LoadChannel   string
+	LoadLimit     float64
+	LambdaChannel string
+
+	Plots []Plot
+
+	// state while reading the log
+	lastKnock float64
+	fuelCut   bool
+}
+
+// reservoirSize is how many values of a channel the percentiles are taken from, longer channels are sampled
+const reservoirSize = 10000
+
+// channelAcc collects the statistics of one channel while the log is read
+type channelAcc struct {
+	n        int
+	min, max float64
+	sum      float64
+	sample   []float64
+	// a fixed seed per channel gives the same report for the same log
+	rng *rand.Rand
+}
+
+func newChannelAcc() *channelAcc {
+	return &channelAcc{rng: rand.New(rand.NewPCG(1, 2))}
+}
+
+func (a *channelAcc) add(v float64) {
+	if a.n == 0 || v < a.min {
+		a.min = v
+	}
+	if a.n == 0 || v > a.max {
+		a.max = v
+	}
+	a.sum += v
+	a.n++
+	// reservoir sampling keeps a uniform sample of the values seen so far
+	if len(a.sample) < reservoirSize {
+		a.sample = append(a.sample, v)
+	} else if i := a.rng.IntN(a.n); i < reservoirSize {
+		a.sample[i] = v
+	}
+}
+
+func (a *channelAcc) stats(name string) ChannelStats {
+	slices.Sort(a.sample)
+	return ChannelStats{
+		Name:    name,
+		Samples: a.n,
+		Min:     a.min,
+		Max:     a.max,
+		Mean:    a.sum / float64(a.n),
+		P5:      percentile(a.sample, 5),
+		P50:     percentile(a.sample, 50),
+		P95:     percentile(a.sample, 95),
+	}
+}
+
+// Generate reads lf once and builds the report, lf is rewound afterwards. Percentiles are exact for channels of up
+// to reservoirSize samples and estimated from a uniform sample of them for longer ones
+func Generate(title string, lf logfile.Logfile, opts Options) (*Report, error) {
+	r := &Report{Title: title, LoadLimit: opts.LoadThreshold}
+	channels := make(map[string]*channelAcc)
+	durations := make(map[float64]time.Duration)
+	plots := make(map[string]*plotData)
+
+	rpm, boost, lambda, load := opts.RPMChannel, opts.BoostChannel, opts.LambdaChannel, opts.LoadChannel
+	start, end := lf.Start(), lf.End()
+	plotFor := func(name string) {
+		if name != "" && plots[name] == nil {
+			plots[name] = newPlotData(name, start, end)
+		}
+	}
+	for _, name := range opts.PlotChannels {
+		plotFor(name)
+	}
+
+	lf.Seek(-1)
+	for {
+		rec := lf.Next()
+		if rec.EOF {
+			break
+		}
+		values := rec.Values
+		if r.Records == 0 {
+			r.Start = rec.Time
+		}
+		r.End = rec.Time
+		r.Records++
+
+		for k, v := range values {
+			acc, ok := channels[k]
+			if !ok {
+				acc = newChannelAcc()
+				channels[k] = acc
+			}
+			acc.add(v)
+		}
+
+		// channels are picked from the first record having one of them, like the knock channel
+		if rpm == "" {
+			rpm = pick(rpmChannels, values)
+		}
+		if boost == "" {
+			boost = pick(boostChannels, values)
+		}
+		if lambda == "" {
+			lambda = pick(lambdaChannels, values)
+		}
+		if load == "" {
+			load = pick(loadChannels, values)
+		}
+		if len(opts.PlotChannels) == 0 {
+			for _, name := range []string{rpm, boost, load, lambda} {
+				plotFor(name)
+			}
+		}
+		for _, p := range plots {
+			v, ok := values[p.name]
+			p.add(rec.Time, v, ok)
+		}
+
+		if v, ok := values[airDemChannel]; ok {
+			durations[v] += time.Duration(rec.DelayTillNext) * time.Millisecond
+		}
+		r.knockSample(values)
+		r.fuelCutSample(values, rpm)
+
+		if v, ok := values[boost]; ok && boost != "" {
+			if !r.PeakBoost.Found || v > r.PeakBoost.Value {
+				r.PeakBoost = Peak{Found: true, Channel: boost, Value: v, RPM: values[rpm], Load: values[load], Time: rec.Time}
+			}
+		}
+		if v, ok := values[lambda]; ok && lambda != "" && load != "" && values[load] >= r.loadLimit(load) {
+			if !r.LeanestLoad.Found || v > r.LeanestLoad.Value {
+				r.LeanestLoad = Peak{Found: true, Channel: lambda, Value: v, RPM: values[rpm], Load: values[load], Time: rec.Time}
+			}
+		}
+	}
+	lf.Seek(-1)
+
+	if r.Records == 0 {
+		return nil, errors.New("no records in log")
+	}
+
+	r.Duration = r.End.Sub(r.Start)
+	r.ECU = detectECU(channels)
+	r.LoadChannel = load
+	r.LambdaChannel = lambda
+	r.LoadLimit = r.loadLimit(load)
+
+	names := make([]string, 0, len(channels))
+	for k := range channels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.Channels = append(r.Channels, channels[name].stats(name))
+	}
+
+	r.limiterTimes(durations)
+
+	plotChannels := opts.PlotChannels
+	if len(plotChannels) == 0 {
+		plotChannels = []string{rpm, boost, load, lambda}
+	}
+	for _, name := range plotChannels {
+		if name == "" || channels[name] == nil {
+			continue
+		}
+		p, err := plots[name].render()
+		if err != nil {
+			return nil, err
+		}
+		r.Plots = append(r.Plots, p)
+	}
+	return r, nil
+}
+
+// loadLimit is the load from which the engine counts as loaded, the option or a default for the load channel
+func (r *Report) loadLimit(load string) float64 {
+	switch {
+	case r.LoadLimit != 0:
+		return r.LoadLimit
+	case load == "P_medel":
+		return defaultLoadBoost
+	}
+	return defaultLoadAir
+}
+
+func detectECU(channels map[string]*channelAcc) string {
+	switch {
+	case channels["AirMassMast.m_Request"] != nil:
+		return "T8"
+	case channels["m_Request"] != nil:
+		return "T7"
+	case channels["Rpm"] != nil:
+		return "T5"
+	}
+	return ""
+}
+
+// pick returns the first of candidates in values
+func pick(candidates []string, values map[string]float64) string {
+	for _, c := range candidates {
+		if _, ok := values[c]; ok {
+			return c
+		}
+	}
+	return ""
+}
+
+// percentile of sorted values with linear interpolation between the closest ranks
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+func (r *Report) limiterTimes(durations map[float64]time.Duration) {
+	toString := func(v float64) string {
+		switch r.ECU {
+		case "T7":
+			return datalogger.AirDemToStringT7(v)
+		case "T8":
+			return datalogger.AirDemToStringT8(v)
+		}
+		return "Unknown"
+	}
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	for v, d := range durations {
+		st := StateTime{Value: v, Name: toString(v), Duration: d}
+		if total > 0 {
+			st.Percent = float64(d) / float64(total) * 100
+		}
+		r.Limiters = append(r.Limiters, st)
+	}
+	sort.Slice(r.Limiters, func(i, j int) bool {
+		return r.Limiters[i].Duration > r.Limiters[j].Duration
+	})
+}
+
+// knockSample counts every change of the knock channel to a non zero value,
+// the channel holds one byte per cylinder with cylinder 1 in the top byte
+func (r *Report) knockSample(values map[string]float64) {
+	if r.KnockChannel == "" {
+		r.KnockChannel = pick(knockChannels, values)
+	}
+	v, ok := values[r.KnockChannel]
+	if !ok {
+		return
+	}
+	if v != r.lastKnock && v > 0 {
+		r.KnockEvents++
+		k := uint32(v)
+		for cyl := range 4 {
+			if k>>(24-8*cyl)&0xFF > 0 {
+				r.KnockPerCyl[cyl]++
+			}
+		}
+	}
+	r.lastKnock = v
+}
+
+// fuelCutSample counts the times injection drops to zero above fuelCutMinRPM
+func (r *Report) fuelCutSample(values map[string]float64, rpm string) {
+	if rpm == "" {
+		return
+	}
+	r.FuelCutMinRPM = fuelCutMinRPM
+	if r.FuelCutChannel == "" {
+		r.FuelCutChannel = pick(injectChannels, values)
+	}
+	inj, ok := values[r.FuelCutChannel]
+	if !ok {
+		return
+	}
+	cut := inj == 0 && values[rpm] >= fuelCutMinRPM
+	if cut && !r.fuelCut {
+		r.FuelCuts++
+	}
+	r.fuelCut = cut
+}
@@ -1,134 +1,408 @@
+// Package server is a local HTTP+JSON API to remote control a running txlogger from scripts and other tools
 package server
 
 import (
-	"bytes"
-	"context"
-	"encoding/gob"
-	"io"
-	"log"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
-	"golang.org/x/sync/errgroup"
+	symbol "github.com/roffe/ecusymbol"
 )
 
-type Server struct {
-	l           net.Listener
-	connections map[string]*Client
+const DefaultAddress = "127.0.0.1:7777"
+
+const (
+	// DefaultStreamRate is how many times a second values are streamed when the client doesn't ask for a rate
+	DefaultStreamRate = 10
+	maxStreamRate     = 100
+	// maxRAMLength is the most bytes read from RAM in one request
+	maxRAMLength = 0x1000
+)
+
+// Controller is what the API controls, calls come from the goroutines of the HTTP server
+type Controller interface {
+	Status() Status
+	StartLogging() error
+	StopLogging() error
+	LoadBinary(filename string) error
+	// LoadPreset loads a preset by name or from a preset file when filename is set
+	LoadPreset(name, filename string) error
+	LoadLayout(name string) error
+	// Symbols returns the symbols being logged, or every symbol of the loaded binary
+	Symbols(binary bool) ([]*symbol.Symbol, error)
+	// ReadRAM and WriteRAM go through the active datalogger and fail when not logging
+	ReadRAM(address, length uint32) ([]byte, error)
+	WriteRAM(address uint32, data []byte) error
 }
 
-type Client struct {
-	c net.Conn
+type Status struct {
+	Logging bool   `json:"logging"`
+	ECU     string `json:"ecu"`
+	Binary  string `json:"binary,omitempty"`
+	Symbols int    `json:"symbols"`
 }
 
-func New() (*Server, error) {
-	s := &Server{
-		connections: make(map[string]*Client),
+// Symbol is a symbol as listed by the API
+type Symbol struct {
+	Name             string  `json:"name"`
+	Number           int     `json:"number"`
+	Address          uint32  `json:"address"`
+	SramOffset       uint32  `json:"sramOffset"`
+	Length           uint16  `json:"length"`
+	Type             uint8   `json:"type"`
+	Unit             string  `json:"unit,omitempty"`
+	Correctionfactor float64 `json:"correctionFactor"`
+}
+
+// RAM is the body of RAM reads and writes, Data is hex encoded
+type RAM struct {
+	Address uint32 `json:"address"`
+	Data    string `json:"data"`
+}
+
+type Config struct {
+	Address string
+	// Token has to be sent by clients as a bearer token, or as the token query parameter by clients like
+	// EventSource that can't set headers
+	Token string
+}
+
+type Server struct {
+	cfg    Config
+	ctrl   Controller
+	values func() map[string]float64
+	srv    *http.Server
+}
+
+// New listens on the address of cfg, values returns the current value of every channel by name
+func New(cfg Config, ctrl Controller, values func() map[string]float64, onMessage func(string)) (*Server, error) {
+	if cfg.Address == "" {
+		cfg.Address = DefaultAddress
 	}
-	if err := s.listen(); err != nil {
-		return nil, err
+	if cfg.Token == "" {
+		return nil, errors.New("server: no token")
+	}
+	ln, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("server: %w", err)
 	}
-	go s.run()
+	s := &Server{
+		cfg:    cfg,
+		ctrl:   ctrl,
+		values: values,
+	}
+	s.srv = &http.Server{Handler: s.routes()}
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			onMessage("API: " + err.Error())
+		}
+	}()
+	onMessage("API listening on http://" + ln.Addr().String() + "/api")
 	return s, nil
 }
 
-func (s *Server) listen() error {
-	var err error
-	s.l, err = net.Listen("tcp", ":7777")
-	if err != nil {
-		return err
-	}
-	return nil
+// Config is the config the server was started with
+func (s *Server) Config() Config {
+	return s.cfg
 }
 
-func (s *Server) run() {
-	for {
-		c, err :=
-			s.l.Accept()
-		if err != nil {
-			if opErr, ok := err.(*net.OpError); ok && opErr.Timeout() {
-				continue
-			}
-			log.Println("Failed to accept connection:", err.Error())
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/status", s.handleStatus)
+	mux.HandleFunc("POST /api/logging/start", s.handleStart)
+	mux.HandleFunc("POST /api/logging/stop", s.handleStop)
+	mux.HandleFunc("POST /api/binary", s.handleBinary)
+	mux.HandleFunc("POST /api/preset", s.handlePreset)
+	mux.HandleFunc("POST /api/layout", s.handleLayout)
+	mux.HandleFunc("GET /api/symbols", s.handleSymbols)
+	mux.HandleFunc("GET /api/ram", s.handleReadRAM)
+	mux.HandleFunc("POST /api/ram", s.handleWriteRAM)
+	mux.HandleFunc("GET /api/values", s.handleValues)
+	mux.HandleFunc("GET /api/values/stream", s.handleStream)
+	return s.authenticate(mux)
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid token"))
 			return
 		}
-		log.Println("New connection from", c.RemoteAddr())
-		s.handleConnection(c)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.ctrl.Status())
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, _ *http.Request) {
+	if err := s.ctrl.StartLogging(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
 	}
+	writeJSON(w, s.ctrl.Status())
 }
 
-func (s *Server) Close() error {
-	return s.l.Close()
+func (s *Server) handleStop(w http.ResponseWriter, _ *http.Request) {
+	if err := s.ctrl.StopLogging(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, s.ctrl.Status())
 }
 
-func (s *Server) handleConnection(c net.Conn) {
-	if _, found := s.connections[c.RemoteAddr().String()]; found {
-		log.Println("Client already connected")
-		c.Close()
+func (s *Server) handleBinary(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filename string `json:"filename"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Filename == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no filename"))
 		return
 	}
-	client := &Client{c: c}
-	s.connections[c.RemoteAddr().String()] = client
-	go s.handleClient(client)
+	if err := s.ctrl.LoadBinary(req.Filename); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, s.ctrl.Status())
 }
 
-func (s *Server) handleClient(c *Client) {
-	defer func() {
-		log.Println("Client disconnected")
-		c.c.Close()
-		delete(s.connections, c.c.RemoteAddr().String())
-	}()
+func (s *Server) handlePreset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string `json:"name"`
+		Filename string `json:"filename"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" && req.Filename == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no preset name or filename"))
+		return
+	}
+	if err := s.ctrl.LoadPreset(req.Name, req.Filename); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, s.ctrl.Status())
+}
+
+func (s *Server) handleLayout(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, errors.New("no layout name"))
+		return
+	}
+	if err := s.ctrl.LoadLayout(req.Name); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, s.ctrl.Status())
+}
 
-	enc := gob.NewEncoder(c.c)
-	dec := gob.NewDecoder(c.c)
+func (s *Server) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	symbols, err := s.ctrl.Symbols(r.URL.Query().Get("source") == "binary")
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	out := make([]Symbol, len(symbols))
+	for i, sym := range symbols {
+		out[i] = Symbol{
+			Name:             sym.Name,
+			Number:           sym.Number,
+			Address:          sym.Address,
+			SramOffset:       sym.SramOffset,
+			Length:           sym.Length,
+			Type:             sym.Type,
+			Unit:             sym.Unit,
+			Correctionfactor: sym.Correctionfactor,
+		}
+	}
+	writeJSON(w, out)
+}
 
-	// errg, ctx := errgroup.WithContext(context.Background())
+func (s *Server) handleReadRAM(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	address, err := strconv.ParseUint(q.Get("address"), 0, 32)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid address: %w", err))
+		return
+	}
+	length, err := strconv.ParseUint(q.Get("length"), 0, 32)
+	if err != nil || length == 0 || length > maxRAMLength {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("length has to be 1 to %d", maxRAMLength))
+		return
+	}
+	data, err := s.ctrl.ReadRAM(uint32(address), uint32(length))
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, RAM{Address: uint32(address), Data: hex.EncodeToString(data)})
+}
 
-	cctx, cancel := context.WithCancel(context.Background())
+func (s *Server) handleWriteRAM(w http.ResponseWriter, r *http.Request) {
+	var req RAM
+	if !readJSON(w, r, &req) {
+		return
+	}
+	data, err := hex.DecodeString(req.Data)
+	if err != nil || len(data) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("data has to be hex encoded bytes"))
+		return
+	}
+	if err := s.ctrl.WriteRAM(req.Address, data); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, req)
+}
 
-	errg, ctx := errgroup.WithContext(cctx)
+func (s *Server) handleValues(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, filterValues(s.values(), channels(r)))
+}
 
-	errg.Go(func() error {
-		defer cancel()
-		for {
-			var msg *Message
-			err := dec.Decode(&msg)
-			if err != nil {
-				if err == io.EOF {
-					return nil
-				}
-				return err
-			}
-			log.Println("Received message:", msg)
-			if bytes.EqualFold(msg.Data, []byte("quit")) {
-				return nil
-			}
+// handleStream sends the values as server-sent events at the rate asked for, only values that changed are sent
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	rate := DefaultStreamRate
+	if v := r.URL.Query().Get("rate"); v != "" {
+		var err error
+		if rate, err = strconv.Atoi(v); err != nil || rate < 1 || rate > maxStreamRate {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("rate has to be 1 to %d", maxStreamRate))
+			return
 		}
-	})
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+	filter := channels(r)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
 
-	errg.Go(func() error {
-		defer cancel()
-		t := time.NewTicker(3 * time.Second)
-		defer t.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				log.Println("Client context done")
-				return nil
-			case <-t.C:
-				enc.Encode(&Message{Type: MessageTypeRequest, Data: []byte("ping")})
+	t := time.NewTicker(time.Second / time.Duration(rate))
+	defer t.Stop()
+	last := make(map[string]float64)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-t.C:
+		}
+		changed := make(map[string]float64)
+		for name, v := range filterValues(s.values(), filter) {
+			if old, found := last[name]; !found || old != v {
+				changed[name] = v
+				last[name] = v
 			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		b, err := json.Marshal(changed)
+		if err != nil {
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", b); err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
 
+// channels returns the channels asked for with the channels query parameter, nil means all
+func channels(r *http.Request) map[string]bool {
+	v := r.URL.Query().Get("channels")
+	if v == "" {
+		return nil
+	}
+	filter := make(map[string]bool)
+	for c := range strings.SplitSeq(v, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			filter[c] = true
 		}
-	})
+	}
+	return filter
+}
 
-	//errg.Go(func() error {
-	//	for {
-	//
-	//	}
-	//})
+func filterValues(values map[string]float64, filter map[string]bool) map[string]float64 {
+	if filter == nil {
+		return values
+	}
+	for name := range values {
+		if !filter[name] {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// readJSON decodes the body of r into v and answers bad request when it can't
+func readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// LoadToken reads the token from filename, a new one is created when the file doesn't exist
+func LoadToken(filename string) (string, error) {
+	b, err := os.ReadFile(filename)
+	if err == nil {
+		if token := strings.TrimSpace(string(b)); token != "" {
+			return token, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return NewToken(filename)
+}
 
-	if err := errg.Wait(); err != nil {
-		log.Println("Error handling client:", err)
+// NewToken writes a new random token to filename, readable only by the user
+func NewToken(filename string) (string, error) {
+	token := rand.Text()
+	if err := os.WriteFile(filename, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to write token: %w", err)
 	}
+	return token, nil
 }
@@ -1,14 +1,23 @@
 package sound
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/ebitengine/oto/v3"
 )
 
-var octx *oto.Context
+var (
+	octx   *oto.Context
+	initMu sync.Mutex
+)
+
+const sampleRate = 44100
 
 func Init() error {
 	// Prepare an Oto context (this will use your default audio device) that will
@@ -17,7 +26,7 @@ func Init() error {
 	op := &oto.NewContextOptions{}
 
 	// Usually 44100 or 48000. Other values might cause distortions in Oto
-	op.SampleRate = 44100
+	op.SampleRate = sampleRate
 
 	// Number of channels (aka locations) to play sounds from. Either 1 or 2.
 	// 1 is mono sound, and 2 is stereo (most speakers are stereo).
@@ -50,3 +59,43 @@ func NewPlayer(r io.Reader) *oto.Player {
 	}
 	return octx.NewPlayer(r)
 }
+
+// Beep plays a sine tone of freq Hz for d and returns without waiting for it to end
+func Beep(freq float64, d time.Duration) error {
+	initMu.Lock()
+	if octx == nil {
+		if err := Init(); err != nil {
+			initMu.Unlock()
+			return err
+		}
+	}
+	initMu.Unlock()
+	p := octx.NewPlayer(bytes.NewReader(tone(freq, d)))
+	p.Play()
+	go func() {
+		for p.IsPlaying() {
+			time.Sleep(10 * time.Millisecond)
+		}
+		p.Close()
+	}()
+	return nil
+}
+
+// tone returns d of a sine at freq as stereo 16 bit PCM, faded in and out to not click
+func tone(freq float64, d time.Duration) []byte {
+	n := int(d.Seconds() * sampleRate)
+	fade := sampleRate / 100
+	buf := make([]byte, n*4)
+	for i := range n {
+		amp := 0.5
+		if i < fade {
+			amp *= float64(i) / float64(fade)
+		} else if n-i < fade {
+			amp *= float64(n-i) / float64(fade)
+		}
+		v := int16(amp * math.MaxInt16 * math.Sin(2*math.Pi*freq*float64(i)/sampleRate))
+		binary.LittleEndian.PutUint16(buf[i*4:], uint16(v))
+		binary.LittleEndian.PutUint16(buf[i*4+2:], uint16(v))
+	}
+	return buf
+}
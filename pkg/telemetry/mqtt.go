@@ -0,0 +1,214 @@
+package telemetry
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// A minimal MQTT 3.1.1 client, samples are published with QoS 0 so only CONNECT,
+// PUBLISH, PINGREQ and DISCONNECT are needed
+
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttPingReq    = 0xC0
+	mqttDisconnect = 0xE0
+
+	mqttKeepAlive      = 30 * time.Second
+	mqttTimeout        = 5 * time.Second
+	mqttReconnectDelay = 5 * time.Second
+)
+
+type mqttTransport struct {
+	address  string
+	topic    string
+	username string
+	password string
+	clientID string
+
+	mu        sync.Mutex
+	conn      net.Conn
+	stop      chan struct{}
+	lastDial  time.Time
+	lastWrite time.Time
+}
+
+// newMQTT connects to the broker, if the connection drops it is made again on a later sample
+func newMQTT(address, topic, username, password string) (*mqttTransport, error) {
+	if address == "" {
+		address = DefaultMQTTAddress
+	}
+	id := make([]byte, 4)
+	rand.Read(id)
+	m := &mqttTransport{
+		address:  address,
+		topic:    topic,
+		username: username,
+		password: password,
+		clientID: "txlogger-" + hex.EncodeToString(id),
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.connect(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+// appendMQTTPacket appends the fixed header and body of a packet
+func appendMQTTPacket(b []byte, typ byte, body []byte) []byte {
+	b = append(b, typ)
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			break
+		}
+	}
+	return append(b, body...)
+}
+
+// connect must be called with mu held
+func (m *mqttTransport) connect() error {
+	m.lastDial = time.Now()
+	conn, err := net.DialTimeout("tcp", m.address, mqttTimeout)
+	if err != nil {
+		return err
+	}
+
+	flags := byte(0x02) // clean session
+	if m.username != "" {
+		flags |= 0x80
+		if m.password != "" {
+			flags |= 0x40
+		}
+	}
+	body := appendMQTTString(nil, "MQTT")
+	body = append(body, 0x04, flags, byte(mqttKeepAlive/time.Second>>8), byte(mqttKeepAlive/time.Second))
+	body = appendMQTTString(body, m.clientID)
+	if m.username != "" {
+		body = appendMQTTString(body, m.username)
+		if m.password != "" {
+			body = appendMQTTString(body, m.password)
+		}
+	}
+
+	conn.SetDeadline(time.Now().Add(mqttTimeout))
+	if _, err := conn.Write(appendMQTTPacket(nil, mqttConnect, body)); err != nil {
+		conn.Close()
+		return err
+	}
+	ack := make([]byte, 4)
+	if _, err := io.ReadFull(conn, ack); err != nil {
+		conn.Close()
+		return fmt.Errorf("no CONNACK: %w", err)
+	}
+	if ack[0] != mqttConnAck || ack[1] != 0x02 {
+		conn.Close()
+		return fmt.Errorf("unexpected response %X", ack)
+	}
+	if ack[3] != 0 {
+		conn.Close()
+		return fmt.Errorf("connection refused, code %d", ack[3])
+	}
+	conn.SetDeadline(time.Time{})
+
+	m.conn = conn
+	m.lastWrite = time.Now()
+	m.stop = make(chan struct{})
+	go m.keepAlive(conn, m.stop)
+	// nothing is subscribed, what the broker sends is PINGRESP until it hangs up
+	go func() {
+		io.Copy(io.Discard, conn)
+		m.mu.Lock()
+		if m.conn == conn {
+			m.drop()
+		}
+		m.mu.Unlock()
+	}()
+	return nil
+}
+
+func (m *mqttTransport) keepAlive(conn net.Conn, stop chan struct{}) {
+	t := time.NewTicker(mqttKeepAlive / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			m.mu.Lock()
+			if m.conn == conn && time.Since(m.lastWrite) >= mqttKeepAlive/2 {
+				m.write([]byte{mqttPingReq, 0x00})
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// write must be called with mu held, the connection is dropped on errors
+func (m *mqttTransport) write(b []byte) error {
+	m.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+	if _, err := m.conn.Write(b); err != nil {
+		m.drop()
+		return err
+	}
+	m.lastWrite = time.Now()
+	return nil
+}
+
+func (m *mqttTransport) drop() {
+	close(m.stop)
+	m.conn.Close()
+	m.conn = nil
+}
+
+func (m *mqttTransport) send(s *Sample) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		if time.Since(m.lastDial) < mqttReconnectDelay {
+			return errors.New("not connected")
+		}
+		if err := m.connect(); err != nil {
+			return err
+		}
+	}
+	payload, err := encodeJSON(s)
+	if err != nil {
+		return err
+	}
+	body := appendMQTTString(nil, m.topic)
+	body = append(body, payload...)
+	return m.write(appendMQTTPacket(nil, mqttPublish, body))
+}
+
+func (m *mqttTransport) close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.conn == nil {
+		return nil
+	}
+	err := m.write([]byte{mqttDisconnect, 0x00})
+	if m.conn != nil {
+		m.drop()
+	}
+	return err
+}
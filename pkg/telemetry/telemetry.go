@@ -0,0 +1,170 @@
+// Package telemetry streams live samples from the logger over UDP multicast, WebSocket and MQTT
+package telemetry
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/datalogger"
+)
+
+const (
+	DefaultMulticastAddress = "239.255.43.21:4321"
+	DefaultWebSocketAddress = ":8765"
+	DefaultMQTTAddress      = "localhost:1883"
+	DefaultMQTTTopic        = "txlogger"
+)
+
+// TransportConfig configures one transport
+type TransportConfig struct {
+	Enabled bool
+	// Address is the multicast group, the HTTP listen address or the MQTT broker, host:port
+	Address string
+	// Channels limits what is sent, empty sends every channel
+	Channels []string
+	// Rate is the most samples per second sent, 0 sends every sample
+	Rate float64
+}
+
+type Config struct {
+	UDP       TransportConfig
+	WebSocket TransportConfig
+	MQTT      TransportConfig
+	// MQTTTopic is where samples are published, MQTTUsername is optional
+	MQTTTopic    string
+	MQTTUsername string
+	MQTTPassword string
+}
+
+// Sample is the value of every channel at one point in time
+type Sample struct {
+	Time   time.Time
+	Names  []string
+	Values []float64
+}
+
+type transport interface {
+	send(s *Sample) error
+	close() error
+}
+
+// stream filters and rate limits the samples of a transport
+type stream struct {
+	name     string
+	t        transport
+	channels map[string]bool
+	interval time.Duration
+	last     time.Time
+}
+
+func newStream(name string, cfg TransportConfig, t transport) *stream {
+	s := &stream{name: name, t: t}
+	if len(cfg.Channels) > 0 {
+		s.channels = make(map[string]bool, len(cfg.Channels))
+		for _, c := range cfg.Channels {
+			s.channels[c] = true
+		}
+	}
+	if cfg.Rate > 0 {
+		s.interval = time.Duration(float64(time.Second) / cfg.Rate)
+	}
+	return s
+}
+
+func (s *stream) send(sample *Sample) error {
+	if s.interval > 0 && sample.Time.Sub(s.last) < s.interval {
+		return nil
+	}
+	s.last = sample.Time
+	if s.channels != nil {
+		filtered := &Sample{Time: sample.Time}
+		for i, name := range sample.Names {
+			if s.channels[name] {
+				filtered.Names = append(filtered.Names, name)
+				filtered.Values = append(filtered.Values, sample.Values[i])
+			}
+		}
+		sample = filtered
+	}
+	if err := s.t.send(sample); err != nil {
+		return fmt.Errorf("%s: %w", s.name, err)
+	}
+	return nil
+}
+
+// Publisher is a [datalogger.LogWriter] that streams every sample to the enabled transports
+type Publisher struct {
+	streams []*stream
+}
+
+// New starts the enabled transports, onMessage gets messages that don't belong to a sample
+// like WebSocket clients connecting
+func New(cfg Config, onMessage func(string)) (*Publisher, error) {
+	p := &Publisher{}
+	if cfg.UDP.Enabled {
+		t, err := newUDP(cfg.UDP.Address)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("UDP: %w", err)
+		}
+		p.streams = append(p.streams, newStream("UDP", cfg.UDP, t))
+	}
+	if cfg.WebSocket.Enabled {
+		t, err := newWebSocket(cfg.WebSocket.Address, onMessage)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("WebSocket: %w", err)
+		}
+		p.streams = append(p.streams, newStream("WebSocket", cfg.WebSocket, t))
+	}
+	if cfg.MQTT.Enabled {
+		topic := cfg.MQTTTopic
+		if topic == "" {
+			topic = DefaultMQTTTopic
+		}
+		t, err := newMQTT(cfg.MQTT.Address, topic, cfg.MQTTUsername, cfg.MQTTPassword)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("MQTT: %w", err)
+		}
+		p.streams = append(p.streams, newStream("MQTT", cfg.MQTT, t))
+	}
+	if len(p.streams) == 0 {
+		return nil, errors.New("no telemetry transport enabled")
+	}
+	return p, nil
+}
+
+func (p *Publisher) Write(sysvars *datalogger.ThreadSafeMap, sysvarOrder []string, vars []*symbol.Symbol, ts time.Time) error {
+	sample := &Sample{Time: ts}
+	for _, k := range sysvarOrder {
+		sample.Names = append(sample.Names, k)
+		sample.Values = append(sample.Values, sysvars.Get(k))
+	}
+	for _, va := range vars {
+		if va.Number < 0 {
+			continue
+		}
+		sample.Names = append(sample.Names, va.Name)
+		sample.Values = append(sample.Values, va.Float64())
+	}
+	var errs []error
+	for _, s := range p.streams {
+		if err := s.send(sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Publisher) Close() error {
+	var errs []error
+	for _, s := range p.streams {
+		if err := s.t.close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,181 @@
+package telemetry
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"slices"
+	"time"
+)
+
+/*
+UDP packets are big endian and start with the magic "TXT" and a type byte.
+
+	names  'N': table uint16, count uint16, count * (length uint8, name)
+	sample 'S': table uint16, unix µs int64, count uint16, count * value float32
+
+The values of a sample are in the order of the names packet with the same table, names
+are sent when the channels change and every second for listeners that join late.
+*/
+
+var udpMagic = []byte("TXT")
+
+const (
+	udpNames  = 'N'
+	udpSample = 'S'
+
+	udpNamesInterval = time.Second
+	udpMaxPacket     = 65000
+)
+
+type udpTransport struct {
+	conn      *net.UDPConn
+	names     []string
+	table     uint16
+	lastNames time.Time
+	buf       bytes.Buffer
+}
+
+func newUDP(address string) (*udpTransport, error) {
+	if address == "" {
+		address = DefaultMulticastAddress
+	}
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &udpTransport{conn: conn}, nil
+}
+
+func (u *udpTransport) send(s *Sample) error {
+	if !slices.Equal(u.names, s.Names) {
+		u.names = slices.Clone(s.Names)
+		u.table++
+		u.lastNames = time.Time{}
+	}
+	if time.Since(u.lastNames) >= udpNamesInterval {
+		if err := u.write(encodeNames(&u.buf, u.table, u.names)); err != nil {
+			return err
+		}
+		u.lastNames = time.Now()
+	}
+	return u.write(encodeSample(&u.buf, u.table, s))
+}
+
+func (u *udpTransport) write(b []byte) error {
+	if len(b) > udpMaxPacket {
+		return fmt.Errorf("packet of %d bytes is too big, filter the channels", len(b))
+	}
+	_, err := u.conn.Write(b)
+	return err
+}
+
+func (u *udpTransport) close() error {
+	return u.conn.Close()
+}
+
+func encodeNames(buf *bytes.Buffer, table uint16, names []string) []byte {
+	buf.Reset()
+	buf.Write(udpMagic)
+	buf.WriteByte(udpNames)
+	binary.Write(buf, binary.BigEndian, table)
+	binary.Write(buf, binary.BigEndian, uint16(len(names)))
+	for _, name := range names {
+		if len(name) > math.MaxUint8 {
+			name = name[:math.MaxUint8]
+		}
+		buf.WriteByte(byte(len(name)))
+		buf.WriteString(name)
+	}
+	return buf.Bytes()
+}
+
+func encodeSample(buf *bytes.Buffer, table uint16, s *Sample) []byte {
+	buf.Reset()
+	buf.Write(udpMagic)
+	buf.WriteByte(udpSample)
+	binary.Write(buf, binary.BigEndian, table)
+	binary.Write(buf, binary.BigEndian, s.Time.UnixMicro())
+	binary.Write(buf, binary.BigEndian, uint16(len(s.Values)))
+	for _, v := range s.Values {
+		binary.Write(buf, binary.BigEndian, float32(v))
+	}
+	return buf.Bytes()
+}
+
+// ErrNoNames is returned by [Decoder.Decode] for samples that arrive before their names
+var ErrNoNames = errors.New("names of sample not received yet")
+
+// Decoder decodes UDP packets for a listener
+type Decoder struct {
+	tables map[uint16][]string
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{tables: make(map[uint16][]string)}
+}
+
+// Decode returns the sample in a packet, names packets are remembered and return nil
+func (d *Decoder) Decode(b []byte) (*Sample, error) {
+	if len(b) < 6 || !bytes.Equal(b[:3], udpMagic) {
+		return nil, errors.New("not a telemetry packet")
+	}
+	typ := b[3]
+	table := binary.BigEndian.Uint16(b[4:6])
+	r := bytes.NewReader(b[6:])
+	switch typ {
+	case udpNames:
+		var count uint16
+		if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+			return nil, err
+		}
+		names := make([]string, count)
+		for i := range names {
+			n, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			name := make([]byte, n)
+			if _, err := io.ReadFull(r, name); err != nil {
+				return nil, err
+			}
+			names[i] = string(name)
+		}
+		d.tables[table] = names
+		return nil, nil
+	case udpSample:
+		names, ok := d.tables[table]
+		if !ok {
+			return nil, ErrNoNames
+		}
+		var ts int64
+		var count uint16
+		if err := binary.Read(r, binary.BigEndian, &ts); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.BigEndian, &count); err != nil {
+			return nil, err
+		}
+		if int(count) != len(names) {
+			return nil, fmt.Errorf("sample has %d values, names %d", count, len(names))
+		}
+		s := &Sample{Time: time.UnixMicro(ts), Names: names, Values: make([]float64, count)}
+		for i := range s.Values {
+			var v float32
+			if err := binary.Read(r, binary.BigEndian, &v); err != nil {
+				return nil, err
+			}
+			s.Values[i] = float64(v)
+		}
+		return s, nil
+	}
+	return nil, fmt.Errorf("unknown packet type %q", typ)
+}
@@ -0,0 +1,160 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// how many messages a WebSocket client can fall behind before messages are dropped for it
+const wsClientBuffer = 64
+
+// jsonSample is how samples are sent over WebSocket and MQTT, ts is unix milliseconds
+type jsonSample struct {
+	TS     int64              `json:"ts"`
+	Values map[string]float64 `json:"values"`
+}
+
+// encodeJSON leaves out values JSON can't represent
+func encodeJSON(s *Sample) ([]byte, error) {
+	js := jsonSample{
+		TS:     s.Time.UnixMilli(),
+		Values: make(map[string]float64, len(s.Names)),
+	}
+	for i, name := range s.Names {
+		if v := s.Values[i]; !math.IsNaN(v) && !math.IsInf(v, 0) {
+			js.Values[name] = v
+		}
+	}
+	return json.Marshal(js)
+}
+
+type wsTransport struct {
+	srv       *http.Server
+	onMessage func(string)
+
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+}
+
+func newWebSocket(address string, onMessage func(string)) (*wsTransport, error) {
+	if address == "" {
+		address = DefaultWebSocketAddress
+	}
+	ln, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	w := &wsTransport{
+		onMessage: onMessage,
+		clients:   make(map[chan []byte]struct{}),
+	}
+	w.srv = &http.Server{Handler: websocket.Server{Handler: w.handle, Handshake: checkOrigin}}
+	go func() {
+		if err := w.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			onMessage("WebSocket: " + err.Error())
+		}
+	}()
+	onMessage("Telemetry WebSocket listening on " + ln.Addr().String())
+	return w, nil
+}
+
+// checkOrigin lets in clients that don't send an Origin header, like scripts and dashboards outside a browser, and
+// pages served from this host or localhost. Other web pages opened in a browser on the network are turned away.
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	config.Origin = origin
+	if origin == nil {
+		return nil
+	}
+	host := origin.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	reqHost := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		reqHost = h
+	}
+	if strings.EqualFold(host, strings.Trim(reqHost, "[]")) {
+		return nil
+	}
+	return fmt.Errorf("origin %s not allowed", origin)
+}
+
+func (w *wsTransport) handle(conn *websocket.Conn) {
+	defer conn.Close()
+	ch := make(chan []byte, wsClientBuffer)
+	w.mu.Lock()
+	w.clients[ch] = struct{}{}
+	w.mu.Unlock()
+	w.onMessage("Telemetry client connected from " + conn.Request().RemoteAddr)
+	defer func() {
+		w.mu.Lock()
+		delete(w.clients, ch)
+		w.mu.Unlock()
+		w.onMessage("Telemetry client disconnected from " + conn.Request().RemoteAddr)
+	}()
+	// clients only listen, a read ends when they hang up
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := websocket.Message.Send(conn, string(msg)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (w *wsTransport) send(s *Sample) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.clients) == 0 {
+		return nil
+	}
+	b, err := encodeJSON(s)
+	if err != nil {
+		return err
+	}
+	for ch := range w.clients {
+		select {
+		case ch <- b:
+		default:
+		}
+	}
+	return nil
+}
+
+func (w *wsTransport) close() error {
+	w.mu.Lock()
+	for ch := range w.clients {
+		close(ch)
+		delete(w.clients, ch)
+	}
+	w.mu.Unlock()
+	return w.srv.Close()
+}
@@ -0,0 +1,225 @@
+package alarmrules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/alarms"
+	"github.com/roffe/txlogger/pkg/events"
+	"github.com/roffe/txlogger/pkg/layout"
+)
+
+// Widget edits the alarms of one ECU type
+type Widget struct {
+	widget.BaseWidget
+
+	ecu    string
+	onSave func(ecu string, rules []alarms.Rule) error
+
+	rows      []*row
+	list      *fyne.Container
+	status    *widget.Label
+	container *fyne.Container
+}
+
+type row struct {
+	enabled     *widget.Check
+	name        *widget.Entry
+	channel     *widget.Entry
+	condition   *widget.Select
+	threshold   *widget.Entry
+	when        *widget.Entry
+	whenAtLeast *widget.Entry
+	hysteresis  *widget.Entry
+	duration    *widget.Entry
+	sound       *widget.Check
+	obj         fyne.CanvasObject
+}
+
+// New creates an editor for rules, onSave is called with the edited rules
+func New(ecu string, rules []alarms.Rule, onSave func(ecu string, rules []alarms.Rule) error) *Widget {
+	w := &Widget{
+		ecu:    ecu,
+		onSave: onSave,
+		list:   container.NewVBox(),
+		status: widget.NewLabel(""),
+	}
+	w.ExtendBaseWidget(w)
+	for _, r := range rules {
+		w.addRow(r)
+	}
+	w.render()
+	return w
+}
+
+func numberEntry(placeholder string, v float64) *widget.Entry {
+	e := widget.NewEntry()
+	e.SetPlaceHolder(placeholder)
+	e.SetText(strconv.FormatFloat(v, 'f', -1, 64))
+	e.Validator = func(s string) error {
+		_, err := parseNumber(s)
+		return err
+	}
+	return e
+}
+
+func parseNumber(s string) (float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+func (w *Widget) addRow(rule alarms.Rule) {
+	conditions := make([]string, len(events.Conditions))
+	for i, c := range events.Conditions {
+		conditions[i] = string(c)
+	}
+	r := &row{
+		enabled:     widget.NewCheck("", nil),
+		name:        widget.NewEntry(),
+		channel:     widget.NewEntry(),
+		condition:   widget.NewSelect(conditions, nil),
+		threshold:   numberEntry("Threshold", rule.Threshold),
+		when:        widget.NewEntry(),
+		whenAtLeast: numberEntry("Min", rule.WhenAtLeast),
+		hysteresis:  numberEntry("Hysteresis", rule.Hysteresis),
+		duration:    numberEntry("Seconds", rule.Duration),
+		sound:       widget.NewCheck("Sound", nil),
+	}
+	r.enabled.SetChecked(!rule.Disabled)
+	r.name.SetPlaceHolder("Name")
+	r.name.SetText(rule.Name)
+	r.channel.SetPlaceHolder("Channel")
+	r.channel.SetText(rule.Channel)
+	r.condition.SetSelected(string(rule.Condition))
+	if r.condition.Selected == "" {
+		r.condition.SetSelectedIndex(0)
+	}
+	r.when.SetPlaceHolder("Only when channel")
+	r.when.SetText(rule.When)
+	r.sound.SetChecked(!rule.Silent)
+
+	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		for i, rr := range w.rows {
+			if rr == r {
+				w.rows = append(w.rows[:i], w.rows[i+1:]...)
+				break
+			}
+		}
+		w.list.Remove(r.obj)
+	})
+
+	r.obj = container.NewBorder(
+		nil,
+		nil,
+		container.NewHBox(r.enabled, layout.NewFixedWidth(130, r.name)),
+		container.NewHBox(
+			layout.NewFixedWidth(100, r.condition),
+			layout.NewFixedWidth(70, r.threshold),
+			widget.NewLabel("when"),
+			layout.NewFixedWidth(170, r.when),
+			widget.NewLabel(">="),
+			layout.NewFixedWidth(70, r.whenAtLeast),
+			widget.NewLabel("±"),
+			layout.NewFixedWidth(70, r.hysteresis),
+			widget.NewLabel("for"),
+			layout.NewFixedWidth(60, r.duration),
+			widget.NewLabel("s"),
+			r.sound,
+			deleteBtn,
+		),
+		r.channel,
+	)
+	w.rows = append(w.rows, r)
+	w.list.Add(r.obj)
+}
+
+// Rules returns the rules as currently edited
+func (w *Widget) Rules() ([]alarms.Rule, error) {
+	rules := make([]alarms.Rule, 0, len(w.rows))
+	for _, r := range w.rows {
+		threshold, err := parseNumber(r.threshold.Text)
+		if err != nil {
+			return nil, fmt.Errorf("alarm %s: invalid threshold: %w", r.name.Text, err)
+		}
+		whenAtLeast, err := parseNumber(r.whenAtLeast.Text)
+		if err != nil {
+			return nil, fmt.Errorf("alarm %s: invalid minimum: %w", r.name.Text, err)
+		}
+		hysteresis, err := parseNumber(r.hysteresis.Text)
+		if err != nil {
+			return nil, fmt.Errorf("alarm %s: invalid hysteresis: %w", r.name.Text, err)
+		}
+		duration, err := parseNumber(r.duration.Text)
+		if err != nil {
+			return nil, fmt.Errorf("alarm %s: invalid duration: %w", r.name.Text, err)
+		}
+		rule := alarms.Rule{
+			Rule: events.Rule{
+				Name:        strings.TrimSpace(r.name.Text),
+				Channel:     strings.TrimSpace(r.channel.Text),
+				Condition:   events.Condition(r.condition.Selected),
+				Threshold:   threshold,
+				When:        strings.TrimSpace(r.when.Text),
+				WhenAtLeast: whenAtLeast,
+				Disabled:    !r.enabled.Checked,
+			},
+			Hysteresis: hysteresis,
+			Duration:   duration,
+			Silent:     !r.sound.Checked,
+		}
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (w *Widget) render() {
+	addBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		w.addRow(alarms.Rule{Rule: events.Rule{Condition: events.AtLeast}})
+	})
+	defaultsBtn := widget.NewButtonWithIcon("Defaults", theme.ViewRefreshIcon(), func() {
+		w.rows = nil
+		w.list.RemoveAll()
+		for _, r := range alarms.Defaults(w.ecu) {
+			w.addRow(r)
+		}
+	})
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		rules, err := w.Rules()
+		if err != nil {
+			w.status.SetText(err.Error())
+			return
+		}
+		if err := w.onSave(w.ecu, rules); err != nil {
+			w.status.SetText(err.Error())
+			return
+		}
+		w.status.SetText("Saved")
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	w.container = container.NewBorder(
+		widget.NewLabel("Alarms for "+w.ecu+", the channel has to come back past the threshold by ± before an alarm clears and hold for the seconds given before it fires"),
+		container.NewBorder(nil, nil, nil, container.NewHBox(addBtn, defaultsBtn, saveBtn), w.status),
+		nil,
+		nil,
+		container.NewVScroll(w.list),
+	)
+}
+
+func (w *Widget) MinSize() fyne.Size {
+	return fyne.NewSize(1150, 300)
+}
+
+func (w *Widget) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(w.container)
+}
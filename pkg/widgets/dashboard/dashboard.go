@@ -32,6 +32,8 @@ type Dashboard struct {
 	gauges Gauges
 
 	fullscreenBtn *widget.Button
+	// alarmFrame flashes around the dashboard when an alarm fires
+	alarmFrame *canvas.Rectangle
 	//dbgBar *fyne.Container
 
 	logplayer bool
@@ -230,6 +232,12 @@ func NewDashboard(cfg *Config) *Dashboard {
 	}
 	db.ExtendBaseWidget(db)
 
+	db.alarmFrame = &canvas.Rectangle{
+		StrokeColor: color.RGBA{R: 0xFF, A: 0xFF},
+		StrokeWidth: 8,
+	}
+	db.alarmFrame.Hide()
+
 	db.text.cruise.Hide()
 	db.image.checkEngine.Hide()
 	db.image.limpMode.Hide()
@@ -637,6 +645,9 @@ func (dr *DashboardRenderer) Layout(space fyne.Size) {
 
 	// Layout icons
 	dr.db.layoutIcons(dims)
+
+	dr.db.alarmFrame.Resize(space)
+	dr.db.alarmFrame.Move(fyne.NewPos(0, 0))
 }
 
 func (dr *DashboardRenderer) MinSize() fyne.Size {
@@ -671,6 +682,7 @@ func (dr *DashboardRenderer) Objects() []fyne.CanvasObject {
 		dr.db.image.checkEngine,
 		dr.db.text.cruise,
 		dr.db.image.knockIcon,
+		dr.db.alarmFrame,
 		dr.db.fullscreenBtn,
 	}
 
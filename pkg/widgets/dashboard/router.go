@@ -7,6 +7,7 @@ import (
 	symbol "github.com/roffe/ecusymbol"
 	"github.com/roffe/txlogger/pkg/datalogger"
 	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/ecu/z22se"
 )
 
 func (db *Dashboard) createRouter() map[string]func(float64) {
@@ -107,12 +108,33 @@ func (db *Dashboard) createRouter() map[string]func(float64) {
 		"CEL":    showHider(db.image.checkEngine),
 		"LIMP":   showHider(db.image.limpMode),
 
+		datalogger.ALARMSYM: db.alarmSetter(),
+
 		"Knock_offset1234": knkDetSetter(db.image.knockIcon),
 		"KnkDet.KnockCyl":  knkDetSetter(db.image.knockIcon),
 
 		"Myrtilos.InjectorDutyCycle": idcSetter(db.text.idc, "Idc"),   // t7
 		"Insptid_ms10":               idcSetterT5(db.text.idc, "Idc"), // t5
 
+		// OBD-II, MAP is shown as boost in bar like the Trionic pressure sensors
+		"OBD2.VehicleSpeed":     setVehicleSpeed,
+		"OBD2.EngineRPM":        db.gauges.rpm.SetValue,
+		"OBD2.IntakeAirTemp":    db.gauges.iat.SetValue,
+		"OBD2.CoolantTemp":      db.gauges.engineTemp.SetValue,
+		"OBD2.MAP":              func(value float64) { db.gauges.pressure.SetValue(value/100 - 1) },
+		"OBD2.ThrottlePosition": db.gauges.throttle.SetValue,
+		"OBD2.TimingAdvance":    textSetter(db.text.ign, "Ign", "", 1),
+
+		// Z22SE, named by the address list
+		z22se.ChannelVehicleSpeed: setVehicleSpeed,
+		z22se.ChannelEngineSpeed:  db.gauges.rpm.SetValue,
+		z22se.ChannelIntakeTemp:   db.gauges.iat.SetValue,
+		z22se.ChannelCoolantTemp:  db.gauges.engineTemp.SetValue,
+		z22se.ChannelBoost:        db.gauges.pressure.SetValue,
+		z22se.ChannelThrottle:     db.gauges.throttle.SetValue,
+		z22se.ChannelLambda:       db.gauges.wblambda.SetValue,
+		z22se.ChannelIgnition:     textSetter(db.text.ign, "Ign", "", 1),
+
 		ebus.TOPIC_ECU: func(value float64) {
 			switch symbol.ECUType(int(value)) {
 			case symbol.ECU_T5: //T5
@@ -84,6 +84,38 @@ func knkDetSetter(icon *icon.Icon) func(float64) {
 	}
 }
 
+// alarmSetter flashes the alarm frame when the alarm count goes up
+func (db *Dashboard) alarmSetter() func(float64) {
+	var oldValue float64
+	var flashing bool
+	return func(value float64) {
+		if value <= oldValue {
+			oldValue = value
+			return
+		}
+		oldValue = value
+		if flashing {
+			return
+		}
+		flashing = true
+		go func() {
+			for i := range 8 {
+				fyne.Do(func() {
+					if i%2 == 0 {
+						db.alarmFrame.Show()
+					} else {
+						db.alarmFrame.Hide()
+					}
+				})
+				time.Sleep(250 * time.Millisecond)
+			}
+			fyne.Do(func() {
+				flashing = false
+			})
+		}()
+	}
+}
+
 func showHider(obj fyne.CanvasObject) func(float64) {
 	var oldValue float64
 	return func(value float64) {
@@ -11,6 +11,7 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/roffe/txlogger/pkg/capture"
 	"github.com/roffe/txlogger/pkg/eventbus"
+	"github.com/roffe/txlogger/pkg/events"
 	"github.com/roffe/txlogger/pkg/layout"
 	"github.com/roffe/txlogger/pkg/logfile"
 	"github.com/roffe/txlogger/pkg/widgets/plotter"
@@ -28,6 +29,7 @@ type controlMsg struct {
 	Op   Op
 	Pos  int
 	Rate float64
+	Func func(logfile.Logfile)
 }
 
 var _ fyne.Widget = (*Logplayer)(nil)
@@ -53,7 +55,21 @@ type Logplayer struct {
 	logFile  logfile.Logfile
 	playOnce sync.Once
 
+	events []events.Event
+
 	OnMouseDown func()
+	// OnMerge shows a merge button when set
+	OnMerge func()
+	// OnSave shows a save button when set
+	OnSave func()
+	// OnReport shows a report button when set
+	OnReport func()
+	// OnMap shows a map button when set
+	OnMap func()
+	// OnDyno shows a dyno button when set
+	OnDyno func()
+	// OnTiming shows a performance timing button when set
+	OnTiming func()
 
 	focused bool
 	closed  bool
@@ -68,12 +84,23 @@ type logplayerObjects struct {
 	positionSlider    *slider
 	timeLabel         *widget.Label
 	speedSelect       *widget.Select
+	mergeBtn          *widget.Button
+	saveBtn           *widget.Button
+	reportBtn         *widget.Button
+	mapBtn            *widget.Button
+	dynoBtn           *widget.Button
+	timingBtn         *widget.Button
+	infoBtn           *widget.Button
+	eventBar          *eventBar
+	eventLabel        *widget.Label
 }
 
 type Config struct {
 	EBus       *eventbus.Controller
 	Logfile    logfile.Logfile
 	TimeSetter func(time.Time)
+	// Rules finds the events marked on the timeline
+	Rules []events.Rule
 }
 
 func New(cfg *Config) *Logplayer {
@@ -105,6 +132,20 @@ func (l *Logplayer) Close() {
 	})
 }
 
+// WithLogfile runs fn on the playback goroutine so it can read the logfile without
+// disturbing playback, the position is restored afterwards.
+func (l *Logplayer) WithLogfile(fn func(logfile.Logfile)) {
+	if l.closed {
+		return
+	}
+	l.control(&controlMsg{Op: OpFunc, Func: fn})
+}
+
+// Seek moves playback to record pos
+func (l *Logplayer) Seek(pos int) {
+	l.objs.positionSlider.SetValue(float64(pos))
+}
+
 func (l *Logplayer) FocusGained() {
 	l.focused = true
 }
@@ -157,6 +198,10 @@ func (l *Logplayer) TypedKey(ev *fyne.KeyEvent) {
 			pos = 0
 		}
 		l.control(&controlMsg{Op: OpSeek, Pos: pos})
+	case fyne.KeyN:
+		l.nextEvent()
+	case fyne.KeyP:
+		l.prevEvent()
 	case fyne.KeyLeft:
 		l.control(&controlMsg{Op: OpPrev})
 	case fyne.KeyRight:
@@ -227,6 +272,44 @@ func (l *Logplayer) render() {
 		l.control(&controlMsg{Op: OpNext})
 	})
 
+	l.objs.mergeBtn = widget.NewButton("Merge", func() {
+		if l.OnMerge != nil {
+			l.OnMerge()
+		}
+	})
+
+	l.objs.saveBtn = widget.NewButton("Save", func() {
+		if l.OnSave != nil {
+			l.OnSave()
+		}
+	})
+
+	l.objs.reportBtn = widget.NewButton("Report", func() {
+		if l.OnReport != nil {
+			l.OnReport()
+		}
+	})
+
+	l.objs.mapBtn = widget.NewButton("Map", func() {
+		if l.OnMap != nil {
+			l.OnMap()
+		}
+	})
+
+	l.objs.dynoBtn = widget.NewButton("Dyno", func() {
+		if l.OnDyno != nil {
+			l.OnDyno()
+		}
+	})
+
+	l.objs.timingBtn = widget.NewButton("Timing", func() {
+		if l.OnTiming != nil {
+			l.OnTiming()
+		}
+	})
+
+	l.objs.infoBtn = widget.NewButton("Info", l.showMetadata)
+
 	values := make(map[string][]float64)
 	for {
 		if rec := l.logFile.Next(); !rec.EOF {
@@ -242,9 +325,13 @@ func (l *Logplayer) render() {
 	}
 	l.logFile.Seek(-1)
 
+	l.objs.eventLabel = widget.NewLabel("")
+	l.objs.eventBar = newEventBar(l.logFile.Len(), l.seekPos)
+
 	l.objs.plotter = plotter.NewPlotter(
 		values,
 		plotter.WithPlotResolutionFactor(1),
+		plotter.WithOnMarkerTapped(l.seekPos),
 		plotter.WithOnDragged(func(event *fyne.DragEvent) {
 			pos := l.objs.positionSlider.Value - float64(event.Dragged.DX)
 			if pos < 0 {
@@ -257,9 +344,36 @@ func (l *Logplayer) render() {
 			l.control(&controlMsg{Op: OpSeek, Pos: int(pos)})
 		}),
 	)
+
+	l.setEvents(events.Scan(l.logFile, l.cfg.Rules))
 }
 
 func (l *Logplayer) CreateRenderer() fyne.WidgetRenderer {
+	right := container.NewHBox(
+		layout.NewFixedWidth(85, l.objs.timeLabel),
+		layout.NewFixedWidth(75, l.objs.speedSelect),
+	)
+	right.Add(l.objs.eventLabel)
+	if l.OnMerge != nil {
+		right.Add(l.objs.mergeBtn)
+	}
+	if l.OnSave != nil {
+		right.Add(l.objs.saveBtn)
+	}
+	if l.OnReport != nil {
+		right.Add(l.objs.reportBtn)
+	}
+	if l.OnMap != nil {
+		right.Add(l.objs.mapBtn)
+	}
+	if l.OnDyno != nil {
+		right.Add(l.objs.dynoBtn)
+	}
+	if l.OnTiming != nil {
+		right.Add(l.objs.timingBtn)
+	}
+	right.Add(l.objs.infoBtn)
+
 	l.container = container.NewBorder(
 		nil,
 		container.NewBorder(
@@ -276,11 +390,8 @@ func (l *Logplayer) CreateRenderer() fyne.WidgetRenderer {
 				nil,
 				nil,
 				nil,
-				container.NewHBox(
-					layout.NewFixedWidth(85, l.objs.timeLabel),
-					layout.NewFixedWidth(75, l.objs.speedSelect),
-				),
-				l.objs.positionSlider,
+				right,
+				container.NewBorder(l.objs.eventBar, nil, nil, nil, l.objs.positionSlider),
 			),
 		),
 		nil,
@@ -334,6 +445,8 @@ func (o Op) String() string {
 		return "Next"
 	case OpPlaybackSpeed:
 		return "PlaybackSpeed"
+	case OpFunc:
+		return "Func"
 	}
 	return "Unknown"
 }
@@ -346,6 +459,7 @@ const (
 	OpPlaybackSpeed
 	OpPlay
 	OpPause
+	OpFunc
 )
 
 func (l *Logplayer) togglePlayback() {
@@ -450,6 +564,10 @@ func (l *Logplayer) playLog() {
 			case OpPause:
 				l.state = statePaused
 				timer.Stop()
+			case OpFunc:
+				pos := l.logFile.Cursor()
+				op.Func(l.logFile)
+				l.logFile.Seek(pos)
 			}
 		case <-timer.C:
 			if l.state != statePlaying {
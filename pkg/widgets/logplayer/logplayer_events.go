@@ -0,0 +1,178 @@
+package logplayer
+
+import (
+	"image/color"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/events"
+	"github.com/roffe/txlogger/pkg/logfile"
+)
+
+var eventColor = color.RGBA{255, 140, 0, 255}
+
+// SetRules scans the log for events with rules and replaces the current markers
+func (l *Logplayer) SetRules(rules []events.Rule) {
+	l.WithLogfile(func(lf logfile.Logfile) {
+		evs := events.Scan(lf, rules)
+		fyne.Do(func() {
+			l.setEvents(evs)
+		})
+	})
+}
+
+// Events returns the events found in the log
+func (l *Logplayer) Events() []events.Event {
+	return l.events
+}
+
+func (l *Logplayer) setEvents(evs []events.Event) {
+	l.events = evs
+	positions := make([]int, len(evs))
+	for i, ev := range evs {
+		positions[i] = ev.Pos
+	}
+	l.objs.plotter.SetMarkers(positions)
+	l.objs.eventBar.setEvents(evs)
+	l.objs.eventLabel.SetText(strconv.Itoa(len(evs)) + " events")
+}
+
+// nextEvent seeks to the first event after the current position
+func (l *Logplayer) nextEvent() {
+	cur := int(l.objs.positionSlider.Value)
+	for i, ev := range l.events {
+		if ev.Pos > cur {
+			l.seekEvent(i)
+			return
+		}
+	}
+}
+
+// prevEvent seeks to the last event before the current position
+func (l *Logplayer) prevEvent() {
+	cur := int(l.objs.positionSlider.Value)
+	for i := len(l.events) - 1; i >= 0; i-- {
+		if l.events[i].Pos < cur {
+			l.seekEvent(i)
+			return
+		}
+	}
+}
+
+// seekPos seeks to the event at pos
+func (l *Logplayer) seekPos(pos int) {
+	for i, ev := range l.events {
+		if ev.Pos == pos {
+			l.seekEvent(i)
+			return
+		}
+	}
+}
+
+func (l *Logplayer) seekEvent(i int) {
+	ev := l.events[i]
+	l.objs.eventLabel.SetText(ev.Rule + " " + strconv.Itoa(i+1) + "/" + strconv.Itoa(len(l.events)))
+	l.objs.positionSlider.SetValue(float64(ev.Pos))
+}
+
+// eventBar shows the events as markers along the position slider
+type eventBar struct {
+	widget.BaseWidget
+	events   []events.Event
+	length   int
+	onTapped func(pos int)
+}
+
+func newEventBar(length int, onTapped func(pos int)) *eventBar {
+	b := &eventBar{
+		length:   length,
+		onTapped: onTapped,
+	}
+	b.ExtendBaseWidget(b)
+	return b
+}
+
+func (b *eventBar) setEvents(evs []events.Event) {
+	b.events = evs
+	b.Refresh()
+}
+
+// xOf returns the x position of pos lined up with the slider track
+func (b *eventBar) xOf(pos int, width float32) float32 {
+	inset := theme.InnerPadding()
+	if b.length < 2 {
+		return inset
+	}
+	return inset + float32(pos)/float32(b.length-1)*(width-2*inset)
+}
+
+func (b *eventBar) Tapped(ev *fyne.PointEvent) {
+	if b.onTapped == nil {
+		return
+	}
+	width := b.Size().Width
+	best, limit := -1, float32(5)
+	for _, e := range b.events {
+		d := b.xOf(e.Pos, width) - ev.Position.X
+		if d < 0 {
+			d = -d
+		}
+		if d <= limit {
+			best, limit = e.Pos, d
+		}
+	}
+	if best >= 0 {
+		b.onTapped(best)
+	}
+}
+
+func (b *eventBar) CreateRenderer() fyne.WidgetRenderer {
+	r := &eventBarRenderer{b: b}
+	r.build()
+	return r
+}
+
+type eventBarRenderer struct {
+	b       *eventBar
+	markers []fyne.CanvasObject
+}
+
+func (r *eventBarRenderer) Layout(size fyne.Size) {
+	for i, m := range r.markers {
+		if i >= len(r.b.events) {
+			break
+		}
+		m.Move(fyne.NewPos(r.b.xOf(r.b.events[i].Pos, size.Width)-1, 0))
+		m.Resize(fyne.NewSize(2, size.Height))
+	}
+}
+
+func (r *eventBarRenderer) MinSize() fyne.Size {
+	return fyne.NewSize(0, 8)
+}
+
+func (r *eventBarRenderer) build() {
+	if len(r.markers) == len(r.b.events) {
+		return
+	}
+	r.markers = make([]fyne.CanvasObject, len(r.b.events))
+	for i := range r.markers {
+		r.markers[i] = canvas.NewRectangle(eventColor)
+	}
+}
+
+func (r *eventBarRenderer) Refresh() {
+	r.build()
+	r.Layout(r.b.Size())
+	canvas.Refresh(r.b)
+}
+
+func (r *eventBarRenderer) Objects() []fyne.CanvasObject {
+	return r.markers
+}
+
+func (r *eventBarRenderer) Destroy() {
+}
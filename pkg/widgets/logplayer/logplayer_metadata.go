@@ -0,0 +1,53 @@
+package logplayer
+
+import (
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showMetadata shows what the log tells about the binary, ECU and setup it was logged with
+func (l *Logplayer) showMetadata() {
+	c := fyne.CurrentApp().Driver().CanvasForObject(l)
+	if c == nil {
+		return
+	}
+	meta := l.logFile.Metadata()
+
+	info := widget.NewForm()
+	for _, f := range meta.Fields() {
+		info.Append(f[0], widget.NewLabel(f[1]))
+	}
+
+	channels := container.NewGridWithColumns(3,
+		widget.NewLabelWithStyle("Channel", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Unit", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("Factor", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+	for _, ch := range meta.Channels {
+		channels.Add(widget.NewLabel(ch.Name))
+		channels.Add(widget.NewLabel(ch.Unit))
+		channels.Add(widget.NewLabel(strconv.FormatFloat(ch.Correctionfactor, 'g', -1, 64)))
+	}
+
+	var content fyne.CanvasObject
+	switch {
+	case len(info.Items) == 0 && len(meta.Channels) == 0:
+		content = widget.NewLabel("The log has no metadata")
+	case len(meta.Channels) == 0:
+		content = info
+	default:
+		content = container.NewBorder(info, nil, nil, nil, container.NewVScroll(channels))
+	}
+
+	pop := widget.NewPopUp(content, c)
+	size := fyne.NewSize(500, min(c.Size().Height-20, 500))
+	if len(meta.Channels) == 0 {
+		size = content.MinSize()
+	}
+	pop.Resize(size)
+	pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(l.objs.infoBtn)
+	pop.ShowAtPosition(fyne.NewPos(max(pos.X-size.Width, 0), max(pos.Y-size.Height, 0)))
+}
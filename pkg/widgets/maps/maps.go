@@ -2,6 +2,7 @@ package maps
 
 import (
 	"image"
+	"image/color"
 	"math"
 	"net/http"
 	"net/url"
@@ -37,6 +38,15 @@ type Map struct {
 	attributionURL   string // url for attribution (example: "https://openstreetmap.org")
 	hideZoomButtons  bool   // enable zoom buttons
 	hideMoveButtons  bool   // enable move map buttons
+
+	track     []TrackPoint
+	fitTrack  bool
+	cursor    *canvas.Circle
+	cursorPos *[2]float64 // lat, lon
+	drawW     int
+	drawH     int
+	drawScale int
+	pxPerUnit float32
 }
 
 // MapOption configures the provided map with different features.
@@ -91,7 +101,16 @@ func WithHTTPClient(client *http.Client) MapOption {
 
 // NewMap creates a new instance of the map widget.
 func NewMap() *Map {
-	m := &Map{cl: &http.Client{}}
+	m := &Map{
+		cl: &http.Client{},
+		cursor: &canvas.Circle{
+			FillColor:   color.White,
+			StrokeColor: color.Black,
+			StrokeWidth: 2,
+		},
+	}
+	m.cursor.Resize(fyne.NewSize(cursorSize, cursorSize))
+	m.cursor.Hide()
 	WithOsmTiles()(m)
 	m.ExtendBaseWidget(m)
 	return m
@@ -202,39 +221,30 @@ func (m *Map) CreateRenderer() fyne.WidgetRenderer {
 
 	overlay := container.NewBorder(nil, copyright, move, zoom)
 
-	c := container.NewStack(canvas.NewRaster(m.draw), container.NewPadded(overlay))
+	c := container.NewStack(canvas.NewRaster(m.draw), container.NewWithoutLayout(m.cursor), container.NewPadded(overlay))
 	return widget.NewSimpleRenderer(c)
 }
 
 func (m *Map) draw(w, h int) image.Image {
 	scale := 1
-	tileSize := tileSize
 	// TODO use retina tiles once OSM supports it in their server (text scaling issues)...
 	if c := fyne.CurrentApp().Driver().CanvasForObject(m); c != nil {
 		scale = int(c.Scale())
 		if scale < 1 {
 			scale = 1
 		}
-		tileSize = tileSize * scale
+	}
+	if m.fitTrack && w > 0 && h > 0 {
+		m.fitTrack = false
+		m.fit(w, h, scale)
 	}
 
 	if m.w != w || m.h != h {
 		m.pixels = image.NewNRGBA(image.Rect(0, 0, w, h))
 	}
 
-	midTileX := (w - tileSize*2) / 2
-	midTileY := (h - tileSize*2) / 2
-	if m.zoom == 0 {
-		midTileX += tileSize / 2
-		midTileY += tileSize / 2
-	}
-
-	midTileX += (tileSize / 2) - m.offX
-	midTileY += (tileSize / 2) - m.offY
-
+	tileSize, midTileX, midTileY, mx, my := m.tileLayout(w, h, scale)
 	count := 1 << m.zoom
-	mx := m.x + int(float32(count)/2-0.5)
-	my := m.y + int(float32(count)/2-0.5)
 	firstTileX := mx - int(math.Ceil(float64(midTileX)/float64(tileSize)))
 	firstTileY := my - int(math.Ceil(float64(midTileY)/float64(tileSize)))
 	for x := firstTileX; (x-firstTileX)*tileSize <= w+tileSize; x++ {
@@ -259,9 +269,35 @@ func (m *Map) draw(w, h int) image.Image {
 		}
 	}
 
+	m.drawW, m.drawH, m.drawScale = w, h, scale
+	if size := m.Size(); size.Width > 0 {
+		m.pxPerUnit = float32(w) / size.Width
+	}
+	m.drawTrack(scale)
+	m.placeCursor()
+
 	return m.pixels
 }
 
+// tileLayout returns the size of a tile in pixels, where the center tile is drawn and which tile that is
+func (m *Map) tileLayout(w, h, scale int) (size, midTileX, midTileY, mx, my int) {
+	size = tileSize * scale
+	midTileX = (w - size*2) / 2
+	midTileY = (h - size*2) / 2
+	if m.zoom == 0 {
+		midTileX += size / 2
+		midTileY += size / 2
+	}
+
+	midTileX += (size / 2) - m.offX
+	midTileY += (size / 2) - m.offY
+
+	count := 1 << m.zoom
+	mx = m.x + int(float32(count)/2-0.5)
+	my = m.y + int(float32(count)/2-0.5)
+	return
+}
+
 func (m *Map) zoomInStep() {
 	m.zoom++
 	m.x *= 2
@@ -284,6 +320,11 @@ func (m *Map) zoomOutStep() {
 }
 
 func (m *Map) SetCenter(lat, lon float64) {
+	m.center(lat, lon)
+	m.Refresh()
+}
+
+func (m *Map) center(lat, lon float64) {
 	tileX, tileY, _, _, offX, offY := SlippyTile(lat, lon, m.zoom)
 
 	n := 1 << m.zoom
@@ -297,8 +338,6 @@ func (m *Map) SetCenter(lat, lon float64) {
 	m.y = tileY - centerOffset
 	m.offX = offX
 	m.offY = offY
-
-	m.Refresh()
 }
 
 // SlippyTile converts WGS84 latitude/longitude to OSM/Web-Mercator
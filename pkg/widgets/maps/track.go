@@ -0,0 +1,141 @@
+package maps
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	cursorSize = 14
+	trackWidth = 3
+	// maxFitZoom keeps a short track from zooming in further than tiles are useful
+	maxFitZoom = 18
+)
+
+// TrackPoint is a position of a track and the color the track has there
+type TrackPoint struct {
+	Lat, Lon float64
+	Color    color.Color
+}
+
+// SetTrack draws points as a line over the map and zooms to fit them, nil removes the track
+func (m *Map) SetTrack(points []TrackPoint) {
+	m.track = points
+	m.fitTrack = len(points) > 0
+	m.Refresh()
+}
+
+// SetTrackColors recolors the track without moving the map, colors has one entry per point
+func (m *Map) SetTrackColors(colors []color.Color) {
+	for i := range m.track {
+		if i < len(colors) {
+			m.track[i].Color = colors[i]
+		}
+	}
+	m.Refresh()
+}
+
+// SetCursor marks lat, lon on the map
+func (m *Map) SetCursor(lat, lon float64) {
+	m.cursorPos = &[2]float64{lat, lon}
+	m.placeCursor()
+}
+
+// HideCursor removes the mark set by SetCursor
+func (m *Map) HideCursor() {
+	m.cursorPos = nil
+	m.cursor.Hide()
+}
+
+// tileCoords returns lat, lon in tiles of zoom from the map origin
+func tileCoords(lat, lon float64, zoom int) (float64, float64) {
+	const maxLat = 85.05112878
+	lat = math.Max(-maxLat, math.Min(maxLat, lat))
+	n := float64(uint64(1) << uint(zoom))
+	x := (lon + 180.0) / 360.0 * n
+	latRad := lat * math.Pi / 180.0
+	y := (1.0 - math.Log(math.Tan(latRad)+1.0/math.Cos(latRad))/math.Pi) / 2.0 * n
+	return x, y
+}
+
+// project returns where lat, lon is in the pixels of a w by h raster
+func (m *Map) project(lat, lon float64, w, h, scale int) (float64, float64) {
+	size, midTileX, midTileY, mx, my := m.tileLayout(w, h, scale)
+	x, y := tileCoords(lat, lon, m.zoom)
+	return float64(midTileX) + (x-float64(mx))*float64(size), float64(midTileY) + (y-float64(my))*float64(size)
+}
+
+// fit zooms and centers the map on the track
+func (m *Map) fit(w, h, scale int) {
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	for _, p := range m.track {
+		minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+		minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+	}
+	zoom := maxFitZoom
+	for ; zoom > 0; zoom-- {
+		x0, y0 := tileCoords(maxLat, minLon, zoom)
+		x1, y1 := tileCoords(minLat, maxLon, zoom)
+		size := float64(tileSize * scale)
+		if (x1-x0)*size <= float64(w)*0.9 && (y1-y0)*size <= float64(h)*0.9 {
+			break
+		}
+	}
+	m.zoom = zoom
+	m.center((minLat+maxLat)/2, (minLon+maxLon)/2)
+}
+
+func (m *Map) drawTrack(scale int) {
+	if len(m.track) < 2 {
+		return
+	}
+	w, h := m.pixels.Bounds().Dx(), m.pixels.Bounds().Dy()
+	px, py := m.project(m.track[0].Lat, m.track[0].Lon, w, h, scale)
+	for _, p := range m.track[1:] {
+		x, y := m.project(p.Lat, p.Lon, w, h, scale)
+		drawLine(m.pixels, px, py, x, y, p.Color, trackWidth*scale)
+		px, py = x, y
+	}
+}
+
+// drawLine draws a line width pixels wide from x0, y0 to x1, y1
+func drawLine(img *image.NRGBA, x0, y0, x1, y1 float64, c color.Color, width int) {
+	if c == nil {
+		c = color.NRGBA{R: 0xFF, A: 0xFF}
+	}
+	b := img.Bounds()
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0)))
+	if steps > 4*(b.Dx()+b.Dy()) {
+		// both ends far outside the map
+		return
+	}
+	half := width / 2
+	for i := 0; i <= steps; i++ {
+		t := 0.0
+		if steps > 0 {
+			t = float64(i) / float64(steps)
+		}
+		cx := int(x0 + (x1-x0)*t)
+		cy := int(y0 + (y1-y0)*t)
+		for dy := -half; dy <= half; dy++ {
+			for dx := -half; dx <= half; dx++ {
+				if image.Pt(cx+dx, cy+dy).In(b) {
+					img.Set(cx+dx, cy+dy, c)
+				}
+			}
+		}
+	}
+}
+
+func (m *Map) placeCursor() {
+	if m.cursorPos == nil || m.drawW == 0 || m.pxPerUnit == 0 {
+		return
+	}
+	x, y := m.project(m.cursorPos[0], m.cursorPos[1], m.drawW, m.drawH, m.drawScale)
+	m.cursor.Move(fyne.NewPos(float32(x)/m.pxPerUnit-cursorSize/2, float32(y)/m.pxPerUnit-cursorSize/2))
+	m.cursor.Show()
+}
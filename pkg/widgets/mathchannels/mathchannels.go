@@ -0,0 +1,137 @@
+package mathchannels
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/layout"
+	"github.com/roffe/txlogger/pkg/mathchannel"
+)
+
+// Widget edits the math channel definitions of one ECU type
+type Widget struct {
+	widget.BaseWidget
+
+	ecu    string
+	onSave func(ecu string, defs []mathchannel.Definition) error
+
+	rows      []*row
+	list      *fyne.Container
+	status    *widget.Label
+	container *fyne.Container
+}
+
+type row struct {
+	name, expression, unit *widget.Entry
+	obj                    fyne.CanvasObject
+}
+
+// New creates an editor for defs, onSave is called with the edited definitions
+func New(ecu string, defs []mathchannel.Definition, onSave func(ecu string, defs []mathchannel.Definition) error) *Widget {
+	w := &Widget{
+		ecu:    ecu,
+		onSave: onSave,
+		list:   container.NewVBox(),
+		status: widget.NewLabel(""),
+	}
+	w.ExtendBaseWidget(w)
+	for _, def := range defs {
+		w.addRow(def)
+	}
+	w.render()
+	return w
+}
+
+func (w *Widget) addRow(def mathchannel.Definition) {
+	r := &row{
+		name:       widget.NewEntry(),
+		expression: widget.NewEntry(),
+		unit:       widget.NewEntry(),
+	}
+	r.name.SetPlaceHolder("Name")
+	r.name.SetText(def.Name)
+	r.expression.SetPlaceHolder("Lambda.External * 14.7")
+	r.expression.SetText(def.Expression)
+	r.unit.SetPlaceHolder("Unit")
+	r.unit.SetText(def.Unit)
+	r.expression.Validator = func(s string) error {
+		_, err := mathchannel.Compile(s)
+		return err
+	}
+
+	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		for i, rr := range w.rows {
+			if rr == r {
+				w.rows = append(w.rows[:i], w.rows[i+1:]...)
+				break
+			}
+		}
+		w.list.Remove(r.obj)
+	})
+
+	r.obj = container.NewBorder(
+		nil,
+		nil,
+		layout.NewFixedWidth(140, r.name),
+		container.NewHBox(layout.NewFixedWidth(70, r.unit), deleteBtn),
+		r.expression,
+	)
+	w.rows = append(w.rows, r)
+	w.list.Add(r.obj)
+}
+
+// Definitions returns the definitions as currently edited
+func (w *Widget) Definitions() []mathchannel.Definition {
+	defs := make([]mathchannel.Definition, 0, len(w.rows))
+	for _, r := range w.rows {
+		defs = append(defs, mathchannel.Definition{
+			Name:       r.name.Text,
+			Expression: r.expression.Text,
+			Unit:       r.unit.Text,
+		})
+	}
+	return defs
+}
+
+func (w *Widget) render() {
+	addBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		w.addRow(mathchannel.Definition{})
+	})
+	defaultsBtn := widget.NewButtonWithIcon("Defaults", theme.ViewRefreshIcon(), func() {
+		w.rows = nil
+		w.list.RemoveAll()
+		for _, def := range mathchannel.Defaults(w.ecu) {
+			w.addRow(def)
+		}
+	})
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		defs := w.Definitions()
+		if _, err := mathchannel.CompileAll(defs); err != nil {
+			w.status.SetText(err.Error())
+			return
+		}
+		if err := w.onSave(w.ecu, defs); err != nil {
+			w.status.SetText(err.Error())
+			return
+		}
+		w.status.SetText("Saved")
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	w.container = container.NewBorder(
+		widget.NewLabel("Math channels for "+w.ecu+", functions: min max abs sqrt clamp if derivative avg(x, n)"),
+		container.NewBorder(nil, nil, nil, container.NewHBox(addBtn, defaultsBtn, saveBtn), w.status),
+		nil,
+		nil,
+		container.NewVScroll(w.list),
+	)
+}
+
+func (w *Widget) MinSize() fyne.Size {
+	return fyne.NewSize(600, 300)
+}
+
+func (w *Widget) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(w.container)
+}
@@ -17,7 +17,7 @@ import (
 )
 
 // var _ fyne.Focusable = (*Plotter)(nil)
-// var _ fyne.Tappable = (*Plotter)(nil)
+var _ fyne.Tappable = (*Plotter)(nil)
 var _ fyne.Draggable = (*Plotter)(nil)
 var _ fyne.Widget = (*Plotter)(nil)
 
@@ -55,8 +55,12 @@ type Plotter struct {
 
 	hilightLine int
 
+	markers []int // sorted data positions drawn as vertical lines
+
 	OnDragged func(event *fyne.DragEvent)
 	OnTapped  func(event *fyne.PointEvent)
+	// OnMarkerTapped is called with the marker position when a marker is tapped
+	OnMarkerTapped func(pos int)
 }
 
 type PlotterOpt func(*Plotter)
@@ -73,6 +77,12 @@ func WithOnDragged(f func(event *fyne.DragEvent)) PlotterOpt {
 	}
 }
 
+func WithOnMarkerTapped(f func(pos int)) PlotterOpt {
+	return func(p *Plotter) {
+		p.OnMarkerTapped = f
+	}
+}
+
 func WithOrder(order []string) PlotterOpt {
 	return func(p *Plotter) {
 		p.valueOrder = order
@@ -272,6 +282,7 @@ func (p *Plotter) refreshImage(goroutine bool) {
 		p.ts[p.hilightLine].PlotImage(img, p.values, p.plotStartPos, p.dataPointsToShow, 4)
 		// write the text of the current value in the top left corner of the image
 	}
+	p.plotMarkers(img)
 
 	p.canvasImage.Image = img
 	if goroutine {
@@ -282,6 +293,8 @@ func (p *Plotter) refreshImage(goroutine bool) {
 
 }
 
+var markerColor = color.RGBA{255, 140, 0, 255}
+
 type TimeSeries struct {
 	Name       string
 	Min        float64
@@ -372,6 +385,64 @@ func (ts *TimeSeries) PlotImage(img *image.RGBA, values map[string][]float64, st
 
 }
 
+// SetMarkers sets the data positions to mark in the plot, positions must be sorted
+func (p *Plotter) SetMarkers(positions []int) {
+	p.markers = positions
+	p.refreshImage(false)
+}
+
+// visibleRange returns the first and last data position drawn, the same way PlotImage clamps it
+func (p *Plotter) visibleRange() (int, int) {
+	startN := min(max(p.plotStartPos, 0), p.dataLength)
+	endN := min(p.plotStartPos+p.dataPointsToShow, p.dataLength)
+	return startN, endN
+}
+
+func (p *Plotter) plotMarkers(img *image.RGBA) {
+	startN, endN := p.visibleRange()
+	if endN <= startN {
+		return
+	}
+	s := img.Bounds().Size()
+	widthFactor := float64(s.X) / float64(endN-startN)
+	for _, pos := range p.markers {
+		if pos < startN || pos > endN {
+			continue
+		}
+		x := int(float64(pos-startN) * widthFactor)
+		// dashed so the plotted lines stay visible
+		for y := 0; y < s.Y; y++ {
+			if y%6 < 4 {
+				img.Set(x, y, markerColor)
+			}
+		}
+	}
+}
+
+// markerAt returns the marker closest to x in plot coordinates if it is within a few pixels
+func (p *Plotter) markerAt(x float32) (int, bool) {
+	width := p.canvasImage.Size().Width
+	startN, endN := p.visibleRange()
+	if x < 0 || x > width || endN <= startN {
+		return 0, false
+	}
+	pointsPerPixel := float32(endN-startN) / width
+	pos := float32(startN) + x*pointsPerPixel
+	limit := 5 * pointsPerPixel
+	best, found := 0, false
+	for _, m := range p.markers {
+		d := float32(m) - pos
+		if d < 0 {
+			d = -d
+		}
+		if d <= limit {
+			best, found = m, true
+			limit = d
+		}
+	}
+	return best, found
+}
+
 // Updated cursor positioning method
 func (p *Plotter) updateCursor(goroutine bool) {
 	var x float32
@@ -4,12 +4,17 @@ import (
 	"fyne.io/fyne/v2"
 )
 
-// func (p *Plotter) Tapped(event *fyne.PointEvent) {
-// 	log.Println("Tapped")
-// 	if f := p.OnTapped; f != nil {
-// 		f(event)
-// 	}
-// }
+func (p *Plotter) Tapped(event *fyne.PointEvent) {
+	if f := p.OnMarkerTapped; f != nil {
+		if pos, ok := p.markerAt(event.Position.X - p.zoom.Size().Width); ok {
+			f(pos)
+			return
+		}
+	}
+	if f := p.OnTapped; f != nil {
+		f(event)
+	}
+}
 
 func (p *Plotter) Dragged(event *fyne.DragEvent) {
 	//p.sel.SetValue(p.sel.Value - float64(event.Dragged.DX))
@@ -1,13 +1,10 @@
 package settings
 
 import (
-	"context"
 	"errors"
-	"fmt"
 	"log"
 	"slices"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,11 +15,10 @@ import (
 	"fyne.io/fyne/v2/widget"
 	"github.com/roffe/gocan"
 	"github.com/roffe/gocan/proto"
+	"github.com/roffe/txlogger/pkg/canadapter"
 	"github.com/roffe/txlogger/pkg/colors"
 	"github.com/roffe/txlogger/pkg/common"
 	"github.com/roffe/txlogger/pkg/datalogger"
-	"github.com/roffe/txlogger/pkg/mdns"
-	"github.com/roffe/txlogger/pkg/ota"
 	"github.com/roffe/txlogger/pkg/wbl/aem"
 	"github.com/roffe/txlogger/pkg/wbl/ecumaster"
 	"github.com/roffe/txlogger/pkg/wbl/innovate"
@@ -41,6 +37,7 @@ const (
 	prefsMeshView               = "liveMeshView"
 	prefsRealtimeBars           = "realtimeBars"
 	prefsLogFormat              = "logFormat"
+	prefsExtraLogFormats        = "extraLogFormats"
 	prefsLogPath                = "logPath"
 	prefsWblSource              = "wblSource"
 	prefsWidebandSymbolName     = "widebandSymbolName"
@@ -54,12 +51,25 @@ const (
 	prefshighValue              = "highValue"
 	prefsUseADScanner           = "useADScanner"
 	prefsColorBlindMode         = "colorBlindMode"
+	prefsTriggerEnabled         = "triggerEnabled"
+	prefsTriggerExpression      = "triggerExpression"
+	prefsTriggerPreTrigger      = "triggerPreTrigger"
+	prefsTriggerHold            = "triggerHold"
+	prefsLogCompression         = "logCompression"
+	prefsRotateSize             = "rotateSizeMB"
+	prefsRotateInterval         = "rotateIntervalMinutes"
+	prefsAdaptiveRate           = "adaptiveRate"
+	prefsRateMin                = "rateMin"
+	prefsRateMax                = "rateMax"
+	prefsCANCapture             = "canCapture"
+	prefsLogMetadata            = "logMetadata"
 
 	// CAN
 	prefsAdapter = "adapter"
 	prefsPort    = "port"
 	prefsSpeed   = "speed"
 	prefsDebug   = "debug"
+	prefsReplay  = "simulatorReplay"
 
 	//Flash
 	PrefsNvdm = "nvdm"
@@ -80,6 +90,12 @@ type SetText interface {
 type Config struct {
 	Logger          func(string)
 	SelectedEcuFunc func() string
+	// OnMetricsChanged is called when the metrics exporter is turned on or off or gets a new address
+	OnMetricsChanged func()
+	// OnAlarmsChanged is called when the alarms of ecu have been saved
+	OnAlarmsChanged func(ecu string)
+	// OnAPIChanged is called when the remote control API is turned on or off or gets a new address or token
+	OnAPIChanged func()
 }
 
 type Widget struct {
@@ -90,6 +106,9 @@ type Widget struct {
 	//CANSettings           *cansettings.Widget
 	freqSlider            *widget.Slider
 	freqValue             *widget.Label
+	adaptiveRate          *widget.Check
+	rateMin               *widget.Entry
+	rateMax               *widget.Entry
 	autoSave              *widget.Check
 	cursorFollowCrosshair *widget.Check
 	autoLoad              *widget.Check
@@ -97,10 +116,40 @@ type Widget struct {
 	meshView              *widget.Check
 	realtimeBars          *widget.Check
 	logFormat             *widget.Select
+	extraLogFormats       *widget.CheckGroup
 	logPath               *widget.Label
+	logCompression        *widget.Select
+	rotateSize            *widget.Entry
+	rotateInterval        *widget.Entry
+	canCapture            *widget.Check
+	logMetadata           *widget.Check
 	useMPH                *widget.Check
 	swapRPMandSpeed       *widget.Check
 	colorBlindMode        *widget.Select
+	// triggered logging
+	triggerEnabled    *widget.Check
+	triggerExpression *widget.Entry
+	triggerPreTrigger *widget.Entry
+	triggerHold       *widget.Entry
+	// telemetry
+	telemetryUDP          *transportSettings
+	telemetryWebSocket    *transportSettings
+	telemetryMQTT         *transportSettings
+	telemetryMQTTTopic    *widget.Entry
+	telemetryMQTTUsername *widget.Entry
+	telemetryMQTTPassword *widget.Entry
+	metricsEnabled        *widget.Check
+	metricsAddress        *widget.Entry
+	// gps
+	gpsEnabled  *widget.Check
+	gpsPort     *widget.SelectEntry
+	gpsBaudrate *widget.Select
+	// remote control API
+	apiEnabled   *widget.Check
+	apiAddress   *widget.Entry
+	apiTokenPath *widget.Label
+	// alarms of the selected ECU
+	alarms *fyne.Container
 	//can settings
 	debugCheckbox   *widget.Check
 	adapterSelector *widget.Select
@@ -108,6 +157,7 @@ type Widget struct {
 	portSelector    *widget.Select
 	portDescription *widget.Label
 	speedSelector   *widget.Select
+	replayLog       *widget.Label
 
 	adapters map[string]*gocan.AdapterInfo
 
@@ -159,6 +209,9 @@ func New(cfg *Config) *Widget {
 func (sw *Widget) CreateRenderer() fyne.WidgetRenderer {
 	sw.freqSlider = sw.newFreqSlider()
 	sw.freqValue = widget.NewLabel("")
+	sw.adaptiveRate = sw.newAdaptiveRate()
+	sw.rateMin = newFloatEntry(prefsRateMin)
+	sw.rateMax = newFloatEntry(prefsRateMax)
 	sw.autoLoad = sw.newAutoUpdateLoad()
 	sw.autoSave = sw.newAutoUpdateSave()
 	sw.cursorFollowCrosshair = sw.newCursorFollowCrosshair()
@@ -166,12 +219,25 @@ func (sw *Widget) CreateRenderer() fyne.WidgetRenderer {
 	sw.meshView = sw.newMeshView()
 	sw.realtimeBars = sw.newRealtimeBars()
 	sw.logFormat = sw.newLogFormat()
+	sw.extraLogFormats = sw.newExtraLogFormats()
 	sw.logPath = widget.NewLabel("")
 	sw.logPath.Truncation = fyne.TextTruncateEllipsis
+	sw.logCompression = sw.newLogCompression()
+	sw.rotateSize = newFloatEntry(prefsRotateSize)
+	sw.rotateInterval = newFloatEntry(prefsRotateInterval)
+	sw.canCapture = sw.newCANCapture()
+	sw.logMetadata = sw.newLogMetadata()
 	sw.useMPH = sw.newUserMPH()
 	sw.swapRPMandSpeed = sw.newSwapRPMandSpeed()
 	sw.colorBlindMode = sw.newColorBlindMode()
 	sw.wblSelectContainer = sw.newWBLSelector()
+	sw.triggerEnabled = sw.newTriggerEnabled()
+	sw.triggerExpression = sw.newTriggerExpression()
+	sw.triggerPreTrigger = newFloatEntry(prefsTriggerPreTrigger)
+	sw.triggerHold = newFloatEntry(prefsTriggerHold)
+	sw.newTelemetry()
+	sw.newGPS()
+	sw.newAPI()
 
 	// CAN
 	sw.adapterSelector = sw.newAdapterSelector()
@@ -181,6 +247,8 @@ func (sw *Widget) CreateRenderer() fyne.WidgetRenderer {
 	sw.speedSelector = sw.newSpeedSelector()
 	sw.debugCheckbox = sw.newDebugCheckbox()
 	sw.refreshBtn = sw.newPortRefreshButton()
+	sw.replayLog = widget.NewLabel("")
+	sw.replayLog.Truncation = fyne.TextTruncateEllipsis
 
 	names := make([]string, 0, len(sw.adapters))
 	for name := range sw.adapters {
@@ -200,8 +268,18 @@ func (sw *Widget) CreateRenderer() fyne.WidgetRenderer {
 	tabs.Append(sw.loggingTab())
 	tabs.Append(sw.wblTab())
 	tabs.Append(sw.dashboardTab())
+	tabs.Append(sw.telemetryTab())
+	tabs.Append(sw.gpsTab())
+	tabs.Append(sw.apiTab())
+	alarmsTab := sw.alarmsTab()
+	tabs.Append(alarmsTab)
 	tabs.Append(container.NewTabItem("txbridge", txconfigurator.NewConfigurator()))
 	//sw.container = tabs
+	tabs.OnSelected = func(t *container.TabItem) {
+		if t == alarmsTab {
+			sw.reloadAlarms()
+		}
+	}
 
 	for _, adapter := range gocan.ListAdapters() {
 		sw.adapters[adapter.Name] = &adapter
@@ -294,24 +372,9 @@ func (cs *Widget) GetAdapter(ecuType string) (gocan.Adapter, error) {
 }
 
 func (cs *Widget) GetAdapterWithExtraFilters(ecuType string, filters []uint32) (gocan.Adapter, error) {
-	debug := fyne.CurrentApp().Preferences().Bool(prefsDebug)
 	port := fyne.CurrentApp().Preferences().String(prefsPort)
 
-	baudstring := fyne.CurrentApp().Preferences().String(prefsSpeed)
-	switch baudstring {
-	case "1mbit":
-		baudstring = "1000000"
-	case "2mbit":
-		baudstring = "2000000"
-	case "3mbit":
-		baudstring = "3000000"
-	}
-
-	if baudstring == "" {
-		baudstring = "1000000"
-	}
-
-	baudrate, err := strconv.Atoi(baudstring)
+	baudrate, err := canadapter.ParseBaudrate(fyne.CurrentApp().Preferences().String(prefsSpeed))
 	if err != nil {
 		return nil, err
 	}
@@ -327,70 +390,20 @@ func (cs *Widget) GetAdapterWithExtraFilters(ecuType string, filters []uint32) (
 				return nil, errors.New("Select port in setings") //lint:ignore ST1005 This is ok
 
 			}
-			if baudstring == "" {
-				return nil, errors.New("Select port speed in settings") //lint:ignore ST1005 This is ok
-			}
-		}
-	}
-
-	var canFilter []uint32
-	var canRate float64
-
-	switch ecuType {
-	case "T5", "Trionic 5":
-		canFilter = []uint32{0xC}
-		canRate = 615.384
-	case "T7", "Trionic 7":
-		if strings.Contains(adapterName, "ELM327") || strings.Contains(adapterName, "STN") || strings.Contains(adapterName, "OBDLink") || strings.HasSuffix(adapterName, "Wifi") {
-			canFilter = []uint32{0x238, 0x258, 0x270}
-		} else {
-			canFilter = []uint32{0x1A0, 0x238, 0x258, 0x270, 0x280, 0x3A0, 0x664, 0x665}
-		}
-		if fyne.CurrentApp().Preferences().StringWithFallback(prefsWblSource, "None") == "CAN" {
-			canFilter = append(canFilter, 0x180)
 		}
-		canRate = 500
-	case "T8", "Trionic 8", "Trionic 8 MCP", "Z22SE", "Z22SE MCP":
-		if strings.Contains(adapterName, "ELM327") || strings.Contains(adapterName, "STN") || strings.Contains(adapterName, "OBDLink") {
-			canFilter = []uint32{0x5E8, 0x7E8}
-		} else {
-			canFilter = []uint32{0x5E8, 0x7E8, 0x664, 0x665}
-		}
-		if fyne.CurrentApp().Preferences().StringWithFallback(prefsWblSource, "None") == "CAN" {
-			canFilter = append(canFilter, 0x180)
-		}
-		canFilter = append(canFilter, filters...)
-
-		canRate = 500
 	}
 
-	cfg := &gocan.AdapterConfig{
-		Port:         port,
-		PortBaudrate: baudrate,
-		CANRate:      canRate,
-		CANFilter:    canFilter,
-		Debug:        debug,
-		PrintVersion: true,
-	}
-
-	if strings.HasPrefix(adapterName, "J2534") { // || strings.HasPrefix(adapterName, "CANlib") {
-		return gocan.NewGWClient(adapterName, cfg)
-	}
-
-	if adapterName == "txbridge wifi" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		addr, err := mdns.Query(ctx, "txbridge.local")
-		if err != nil {
-			cs.cfg.Logger(fmt.Sprintf("Failed to resolve txbridge address via mDNS: %v", err))
-		} else {
-			cfg.AdditionalConfig = map[string]string{
-				"address":    fmt.Sprintf("%s:%d", addr.String(), 1337),
-				"minversion": ota.MinimumtxbridgeVersion,
-			}
-		}
-	}
-	return gocan.NewAdapter(adapterName, cfg)
+	return canadapter.New(canadapter.Config{
+		Name:        adapterName,
+		Port:        port,
+		Baudrate:    baudrate,
+		ECU:         ecuType,
+		WidebandCAN: fyne.CurrentApp().Preferences().StringWithFallback(prefsWblSource, "None") == "CAN",
+		Replay:      fyne.CurrentApp().Preferences().String(prefsReplay),
+		Debug:       fyne.CurrentApp().Preferences().Bool(prefsDebug),
+		Filters:     filters,
+		OnMessage:   cs.cfg.Logger,
+	})
 }
 
 func (sw *Widget) GetWidebandType() string {
@@ -456,6 +469,14 @@ func (sw *Widget) GetFreq() int {
 	return int(fyne.CurrentApp().Preferences().IntWithFallback(prefsFreq, 25))
 }
 
+// GetRateBounds returns the bounds of the adaptive logging rate, 0 and 0 when the rate is fixed
+func (sw *Widget) GetRateBounds() (int, int) {
+	if !fyne.CurrentApp().Preferences().Bool(prefsAdaptiveRate) {
+		return 0, 0
+	}
+	return int(fyne.CurrentApp().Preferences().FloatWithFallback(prefsRateMin, 5)), int(fyne.CurrentApp().Preferences().FloatWithFallback(prefsRateMax, 100))
+}
+
 func (sw *Widget) GetAutoSave() bool {
 	return fyne.CurrentApp().Preferences().Bool(prefsAutoUpdateSaveEcu)
 }
@@ -480,6 +501,18 @@ func (sw *Widget) GetLogFormat() string {
 	return fyne.CurrentApp().Preferences().String(prefsLogFormat)
 }
 
+// GetLogFormats returns the log format followed by the extra formats written at the same time
+func (sw *Widget) GetLogFormats() []string {
+	format := sw.GetLogFormat()
+	formats := []string{format}
+	for _, f := range fyne.CurrentApp().Preferences().StringList(prefsExtraLogFormats) {
+		if f != format {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
 func (sw *Widget) GetLogPath() string {
 	p := fyne.CurrentApp().Preferences().String(prefsLogPath)
 	if p == "" {
@@ -503,3 +536,43 @@ func (sw *Widget) GetSwapRPMandSpeed() bool {
 func (sw *Widget) GetCursorFollowCrosshair() bool {
 	return fyne.CurrentApp().Preferences().Bool(prefsCursorFollowCrosshair)
 }
+
+func (sw *Widget) GetTriggerEnabled() bool {
+	return fyne.CurrentApp().Preferences().Bool(prefsTriggerEnabled)
+}
+
+func (sw *Widget) GetTriggerExpression() string {
+	return fyne.CurrentApp().Preferences().StringWithFallback(prefsTriggerExpression, defaultTriggerExpression)
+}
+
+func (sw *Widget) GetTriggerPreTrigger() time.Duration {
+	return time.Duration(fyne.CurrentApp().Preferences().FloatWithFallback(prefsTriggerPreTrigger, 5) * float64(time.Second))
+}
+
+func (sw *Widget) GetTriggerHold() time.Duration {
+	return time.Duration(fyne.CurrentApp().Preferences().FloatWithFallback(prefsTriggerHold, 3) * float64(time.Second))
+}
+
+func (sw *Widget) GetLogCompression() string {
+	return fyne.CurrentApp().Preferences().StringWithFallback(prefsLogCompression, "None")
+}
+
+// GetRotateSize returns the log size in bytes that starts a new log, 0 means no limit
+func (sw *Widget) GetRotateSize() int64 {
+	return int64(fyne.CurrentApp().Preferences().Float(prefsRotateSize) * 1024 * 1024)
+}
+
+// GetRotateInterval returns how long to log before starting a new log, 0 means no limit
+func (sw *Widget) GetRotateInterval() time.Duration {
+	return time.Duration(fyne.CurrentApp().Preferences().Float(prefsRotateInterval) * float64(time.Minute))
+}
+
+// GetCANCapture returns if every CAN frame is recorded next to the log
+func (sw *Widget) GetCANCapture() bool {
+	return fyne.CurrentApp().Preferences().Bool(prefsCANCapture)
+}
+
+// GetLogMetadata returns if CSV and TXL logs start with a metadata header
+func (sw *Widget) GetLogMetadata() bool {
+	return fyne.CurrentApp().Preferences().Bool(prefsLogMetadata)
+}
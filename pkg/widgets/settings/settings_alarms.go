@@ -0,0 +1,42 @@
+package settings
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/alarms"
+	"github.com/roffe/txlogger/pkg/widgets/alarmrules"
+)
+
+func (sw *Widget) alarmsTab() *container.TabItem {
+	sw.alarms = container.NewStack()
+	sw.reloadAlarms()
+	return container.NewTabItem("Alarms", sw.alarms)
+}
+
+// reloadAlarms shows the alarms of the selected ECU, called when the tab is shown as the ECU may have changed
+func (sw *Widget) reloadAlarms() {
+	ecu := sw.cfg.SelectedEcuFunc()
+	if ecu == "" {
+		sw.alarms.Objects = []fyne.CanvasObject{widget.NewLabel("Select an ECU to edit its alarms")}
+		sw.alarms.Refresh()
+		return
+	}
+	prefs := fyne.CurrentApp().Preferences()
+	rules, err := alarms.Load(prefs, ecu)
+	if err != nil {
+		sw.cfg.Logger(err.Error())
+	}
+	editor := alarmrules.New(ecu, rules, func(ecu string, rules []alarms.Rule) error {
+		if err := alarms.Save(prefs, ecu, rules); err != nil {
+			return err
+		}
+		if sw.cfg.OnAlarmsChanged != nil {
+			sw.cfg.OnAlarmsChanged(ecu)
+		}
+		return nil
+	})
+	// the editor is wider than the other tabs
+	sw.alarms.Objects = []fyne.CanvasObject{container.NewHScroll(editor)}
+	sw.alarms.Refresh()
+}
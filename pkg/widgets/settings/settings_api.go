@@ -0,0 +1,76 @@
+package settings
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/server"
+)
+
+const (
+	prefsAPIEnabled = "apiEnabled"
+	prefsAPIAddress = "apiAddress"
+)
+
+func (sw *Widget) newAPI() {
+	sw.apiEnabled = widget.NewCheck("Remote control API", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsAPIEnabled, b)
+		sw.apiChanged()
+	})
+	sw.apiAddress = newStringEntry(prefsAPIAddress, server.DefaultAddress)
+	sw.apiAddress.OnSubmitted = func(string) {
+		sw.apiChanged()
+	}
+	sw.apiTokenPath = widget.NewLabel("")
+	sw.apiTokenPath.Truncation = fyne.TextTruncateEllipsis
+	if path, err := common.GetAPITokenPath(); err == nil {
+		sw.apiTokenPath.SetText(path)
+	}
+}
+
+func (sw *Widget) apiChanged() {
+	if sw.cfg.OnAPIChanged != nil {
+		sw.cfg.OnAPIChanged()
+	}
+}
+
+func (sw *Widget) loadAPIPreferences() {
+	loadPrefsCheck(sw.apiEnabled, prefsAPIEnabled, false)
+	loadPrefsText(sw.apiAddress, prefsAPIAddress, "")
+}
+
+func (sw *Widget) apiTab() *container.TabItem {
+	newTokenBtn := widget.NewButtonWithIcon("New token", theme.ViewRefreshIcon(), func() {
+		path, err := common.GetAPITokenPath()
+		if err != nil {
+			sw.cfg.Logger(err.Error())
+			return
+		}
+		if _, err := server.NewToken(path); err != nil {
+			sw.cfg.Logger(err.Error())
+			return
+		}
+		sw.cfg.Logger("Created a new API token, clients using the old one are refused")
+		sw.apiChanged()
+	})
+	return container.NewTabItem("API", container.NewVBox(
+		widget.NewLabel("Start and stop logging, load binaries, presets and layouts, read and write RAM and stream\nlive values over HTTP, press enter to apply a new address"),
+		container.NewBorder(nil, nil, sw.apiEnabled, nil, sw.apiAddress),
+		widget.NewLabel("Clients send the token in this file as a bearer token"),
+		container.NewBorder(nil, nil, nil, newTokenBtn, sw.apiTokenPath),
+	))
+}
+
+// GetAPIConfig returns if the remote control API is enabled and its bind address
+func (sw *Widget) GetAPIConfig() (bool, string) {
+	p := fyne.CurrentApp().Preferences()
+	address := strings.TrimSpace(p.String(prefsAPIAddress))
+	if address == "" {
+		address = server.DefaultAddress
+	}
+	return p.Bool(prefsAPIEnabled), address
+}
@@ -0,0 +1,69 @@
+package settings
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/gps"
+)
+
+const (
+	prefsGPSEnabled  = "gpsEnabled"
+	prefsGPSPort     = "gpsPort"
+	prefsGPSBaudrate = "gpsBaudrate"
+)
+
+var gpsBaudrates = []string{"4800", "9600", "19200", "38400", "57600", "115200"}
+
+func (sw *Widget) newGPS() {
+	sw.gpsEnabled = widget.NewCheck("Log position and speed from an NMEA GPS", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsGPSEnabled, b)
+	})
+	sw.gpsPort = widget.NewSelectEntry(sw.ListPorts())
+	sw.gpsPort.SetPlaceHolder("Serial port or host:port")
+	sw.gpsPort.OnChanged = func(s string) {
+		fyne.CurrentApp().Preferences().SetString(prefsGPSPort, s)
+	}
+	sw.gpsBaudrate = widget.NewSelect(gpsBaudrates, func(s string) {
+		fyne.CurrentApp().Preferences().SetString(prefsGPSBaudrate, s)
+	})
+}
+
+func (sw *Widget) loadGPSPreferences() {
+	loadPrefsCheck(sw.gpsEnabled, prefsGPSEnabled, false)
+	loadPrefsText(sw.gpsPort, prefsGPSPort, "")
+	sw.gpsBaudrate.SetSelected(fyne.CurrentApp().Preferences().StringWithFallback(prefsGPSBaudrate, strconv.Itoa(gps.DefaultBaudrate)))
+}
+
+func (sw *Widget) gpsTab() *container.TabItem {
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() {
+		sw.gpsPort.SetOptions(sw.ListPorts())
+	})
+	return container.NewTabItem("GPS", container.NewVBox(
+		sw.gpsEnabled,
+		widget.NewLabel("Latitude, longitude, speed, heading and fix quality are added to every log as GPS.* channels"),
+		container.NewBorder(nil, nil, widget.NewLabel("Port"), refreshBtn, sw.gpsPort),
+		container.NewBorder(nil, nil, widget.NewLabel("Baudrate"), nil, sw.gpsBaudrate),
+	))
+}
+
+// GetGPSConfig returns the GPS receiver to log from, the port is empty when GPS is turned off
+func (sw *Widget) GetGPSConfig() datalogger.GPSConfig {
+	p := fyne.CurrentApp().Preferences()
+	if !p.Bool(prefsGPSEnabled) {
+		return datalogger.GPSConfig{}
+	}
+	baudrate, err := strconv.Atoi(p.String(prefsGPSBaudrate))
+	if err != nil {
+		baudrate = gps.DefaultBaudrate
+	}
+	return datalogger.GPSConfig{
+		Port:     strings.TrimSpace(p.String(prefsGPSPort)),
+		Baudrate: baudrate,
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/roffe/txlogger/pkg/colors"
 	"github.com/roffe/txlogger/pkg/common"
 	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/mathchannel"
 	"github.com/roffe/txlogger/pkg/wbl/aem"
 	"github.com/roffe/txlogger/pkg/wbl/ecumaster"
 	"github.com/roffe/txlogger/pkg/wbl/innovate"
@@ -61,11 +62,19 @@ func newImageFromResource(name string) *canvas.Image {
 }
 
 func (sw *Widget) newLogFormat() *widget.Select {
-	return widget.NewSelect([]string{"CSV", "TXL"}, func(s string) {
+	return widget.NewSelect([]string{"CSV", "TXL", "TXB", "MLG", "MLG v1"}, func(s string) {
 		fyne.CurrentApp().Preferences().SetString(prefsLogFormat, s)
 	})
 }
 
+func (sw *Widget) newExtraLogFormats() *widget.CheckGroup {
+	cg := widget.NewCheckGroup([]string{"CSV", "TXL", "TXB", "MLG"}, func(s []string) {
+		fyne.CurrentApp().Preferences().SetStringList(prefsExtraLogFormats, s)
+	})
+	cg.Horizontal = true
+	return cg
+}
+
 func (sw *Widget) newWBLSelector() *fyne.Container {
 	sw.wblSource = widget.NewSelect([]string{
 		"None",
@@ -315,6 +324,65 @@ func (sw *Widget) newSwapRPMandSpeed() *widget.Check {
 	})
 }
 
+const defaultTriggerExpression = "ActualIn.n_Engine > 3000 && Out.X_AccPedal > 80"
+
+func (sw *Widget) newAdaptiveRate() *widget.Check {
+	return widget.NewCheck("Adaptive rate, start at the logging rate and adjust to what the adapter keeps up with", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsAdaptiveRate, b)
+	})
+}
+
+func (sw *Widget) newCANCapture() *widget.Check {
+	return widget.NewCheck("Capture raw CAN frames to an ASC file next to the log", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsCANCapture, b)
+	})
+}
+
+func (sw *Widget) newLogMetadata() *widget.Check {
+	return widget.NewCheck("Start CSV and TXL logs with a metadata header, T7Suite and older txlogger versions can't read it", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsLogMetadata, b)
+	})
+}
+
+func (sw *Widget) newTriggerEnabled() *widget.Check {
+	return widget.NewCheck("Triggered logging, only write pulls to disk", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsTriggerEnabled, b)
+	})
+}
+
+func (sw *Widget) newTriggerExpression() *widget.Entry {
+	e := widget.NewEntry()
+	e.SetPlaceHolder(defaultTriggerExpression)
+	e.Validator = func(s string) error {
+		if _, err := mathchannel.Compile(s); err != nil {
+			return err
+		}
+		fyne.CurrentApp().Preferences().SetString(prefsTriggerExpression, s)
+		return nil
+	}
+	return e
+}
+
+func (sw *Widget) newLogCompression() *widget.Select {
+	return widget.NewSelect([]string{"None", "gzip", "zstd"}, func(s string) {
+		fyne.CurrentApp().Preferences().SetString(prefsLogCompression, s)
+	})
+}
+
+// newFloatEntry returns an entry that stores positive numbers in prefKey
+func newFloatEntry(prefKey string) *widget.Entry {
+	e := widget.NewEntry()
+	e.Validator = func(s string) error {
+		val, err := positiveFloatValidator(s)
+		if err != nil {
+			return err
+		}
+		fyne.CurrentApp().Preferences().SetFloat(prefKey, val)
+		return nil
+	}
+	return e
+}
+
 func (sw *Widget) newColorBlindMode() *widget.Select {
 	return widget.NewSelect(colors.SupportedColorBlindModes[:], func(s string) {
 		fyne.CurrentApp().Preferences().SetString(prefsColorBlindMode, s)
@@ -392,6 +460,11 @@ func (sw *Widget) newPortRefreshButton() *widget.Button {
 func (sw *Widget) loadPreferences() {
 	freq := fyne.CurrentApp().Preferences().IntWithFallback(prefsFreq, 25)
 	sw.freqSlider.SetValue(float64(freq))
+	sw.rateMin.SetText(strconv.FormatFloat(fyne.CurrentApp().Preferences().FloatWithFallback(prefsRateMin, 5), 'f', -1, 64))
+	sw.rateMax.SetText(strconv.FormatFloat(fyne.CurrentApp().Preferences().FloatWithFallback(prefsRateMax, 100), 'f', -1, 64))
+	loadPrefsCheck(sw.adaptiveRate, prefsAdaptiveRate, false)
+	loadPrefsCheck(sw.canCapture, prefsCANCapture, false)
+	loadPrefsCheck(sw.logMetadata, prefsLogMetadata, false)
 	loadPrefsCheck(sw.autoLoad, prefsAutoUpdateLoadEcu, true)
 	loadPrefsCheck(sw.autoSave, prefsAutoUpdateSaveEcu, false)
 	loadPrefsCheck(sw.cursorFollowCrosshair, prefsCursorFollowCrosshair, false)
@@ -399,6 +472,7 @@ func (sw *Widget) loadPreferences() {
 	loadPrefsCheck(sw.meshView, prefsMeshView, true)
 	loadPrefsCheck(sw.realtimeBars, prefsRealtimeBars, true)
 	loadPrefsSelect(sw.logFormat, prefsLogFormat, "TXL")
+	sw.extraLogFormats.SetSelected(fyne.CurrentApp().Preferences().StringList(prefsExtraLogFormats))
 	logPath, err := common.GetLogPath()
 	if err != nil {
 		fyne.LogError("Could not get log path", err)
@@ -415,6 +489,16 @@ func (sw *Widget) loadPreferences() {
 	loadPrefsText(sw.lowEntry, prefslowValue, "0.5")
 	loadPrefsText(sw.highEntry, prefshighValue, "1.5")
 	loadPrefsSelect(sw.colorBlindMode, prefsColorBlindMode, "Normal")
+	loadPrefsCheck(sw.triggerEnabled, prefsTriggerEnabled, false)
+	loadPrefsText(sw.triggerExpression, prefsTriggerExpression, defaultTriggerExpression)
+	sw.triggerPreTrigger.SetText(strconv.FormatFloat(sw.GetTriggerPreTrigger().Seconds(), 'f', -1, 64))
+	sw.triggerHold.SetText(strconv.FormatFloat(sw.GetTriggerHold().Seconds(), 'f', -1, 64))
+	loadPrefsSelect(sw.logCompression, prefsLogCompression, "None")
+	sw.rotateSize.SetText(strconv.FormatFloat(fyne.CurrentApp().Preferences().Float(prefsRotateSize), 'f', -1, 64))
+	sw.rotateInterval.SetText(strconv.FormatFloat(fyne.CurrentApp().Preferences().Float(prefsRotateInterval), 'f', -1, 64))
+	sw.loadTelemetryPreferences()
+	sw.loadGPSPreferences()
+	sw.loadAPIPreferences()
 
 	if sw.wblADscanner.Checked {
 		sw.minimumVoltageWidebandLabel.Show()
@@ -437,6 +521,7 @@ func (sw *Widget) loadPreferences() {
 	}
 
 	loadPrefsSelect(sw.adapterSelector, prefsAdapter, "")
+	loadPrefsText(sw.replayLog, prefsReplay, "")
 	loadPrefsSelect(sw.portSelector, prefsPort, "")
 	loadPrefsSelect(sw.speedSelector, prefsSpeed, "115200")
 	loadPrefsCheck(sw.debugCheckbox, prefsDebug, false)
@@ -7,6 +7,7 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/ecusim"
 	xlayout "github.com/roffe/txlogger/pkg/layout"
 	"github.com/roffe/txlogger/pkg/widgets"
 )
@@ -70,6 +71,23 @@ func (sw *Widget) loggingTab() *container.TabItem {
 			sw.freqValue,
 			sw.freqSlider,
 		),
+		sw.adaptiveRate,
+		container.NewGridWithColumns(2,
+			container.NewBorder(
+				nil,
+				nil,
+				widget.NewLabel("Min (Hz)"),
+				nil,
+				sw.rateMin,
+			),
+			container.NewBorder(
+				nil,
+				nil,
+				widget.NewLabel("Max (Hz)"),
+				nil,
+				sw.rateMax,
+			),
+		),
 		widget.NewSeparator(),
 		container.NewBorder(
 			nil,
@@ -78,6 +96,13 @@ func (sw *Widget) loggingTab() *container.TabItem {
 			nil,
 			sw.logFormat,
 		),
+		container.NewBorder(
+			nil,
+			nil,
+			widget.NewLabel("Also write"),
+			nil,
+			sw.extraLogFormats,
+		),
 		container.NewBorder(
 			nil,
 			container.NewGridWithColumns(2,
@@ -101,6 +126,56 @@ func (sw *Widget) loggingTab() *container.TabItem {
 			nil,
 			sw.logPath,
 		),
+		container.NewBorder(
+			nil,
+			nil,
+			widget.NewLabel("Compression (CSV and TXL)"),
+			nil,
+			sw.logCompression,
+		),
+		container.NewGridWithColumns(2,
+			container.NewBorder(
+				nil,
+				nil,
+				widget.NewLabel("New log every (MB)"),
+				nil,
+				sw.rotateSize,
+			),
+			container.NewBorder(
+				nil,
+				nil,
+				widget.NewLabel("New log every (min)"),
+				nil,
+				sw.rotateInterval,
+			),
+		),
+		sw.canCapture,
+		sw.logMetadata,
+		widget.NewSeparator(),
+		sw.triggerEnabled,
+		container.NewBorder(
+			nil,
+			nil,
+			widget.NewLabel("Trigger when"),
+			nil,
+			sw.triggerExpression,
+		),
+		container.NewGridWithColumns(2,
+			container.NewBorder(
+				nil,
+				nil,
+				widget.NewLabel("Pre-trigger (s)"),
+				nil,
+				sw.triggerPreTrigger,
+			),
+			container.NewBorder(
+				nil,
+				nil,
+				widget.NewLabel("Hold (s)"),
+				nil,
+				sw.triggerHold,
+			),
+		),
 	))
 }
 
@@ -280,5 +355,27 @@ func (sw *Widget) canTab() *container.TabItem {
 			nil,
 			sw.speedSelector,
 		),
+		widget.NewSeparator(),
+		container.NewBorder(
+			nil,
+			nil,
+			xlayout.NewFixedWidth(70, widget.NewLabel("Replay")),
+			container.NewGridWithColumns(2,
+				widget.NewButtonWithIcon("Clear", theme.ContentClearIcon(), func() {
+					sw.replayLog.SetText("")
+					fyne.CurrentApp().Preferences().SetString(prefsReplay, "")
+				}),
+				widget.NewButtonWithIcon("Browse", theme.FileIcon(), func() {
+					cb := func(r fyne.URIReadCloser) {
+						defer r.Close()
+						sw.replayLog.SetText(r.URI().Path())
+						fyne.CurrentApp().Preferences().SetString(prefsReplay, r.URI().Path())
+					}
+					widgets.SelectFile(cb, "Log files", "csv", "t5l", "t7l", "t8l", "txb", "mlg", "msl", "gz", "zst")
+				}),
+			),
+			sw.replayLog,
+		),
+		widget.NewLabelWithStyle("Log replayed by the "+ecusim.Name+", empty keeps the values of the binary", fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
 	))
 }
@@ -0,0 +1,167 @@
+package settings
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/metrics"
+	"github.com/roffe/txlogger/pkg/telemetry"
+)
+
+const (
+	prefsTelemetryMQTTTopic    = "telemetryMQTTTopic"
+	prefsTelemetryMQTTUsername = "telemetryMQTTUsername"
+	prefsTelemetryMQTTPassword = "telemetryMQTTPassword"
+	prefsMetricsEnabled        = "metricsEnabled"
+	prefsMetricsAddress        = "metricsAddress"
+)
+
+// transportSettings are the settings of one telemetry transport, stored under prefix
+type transportSettings struct {
+	prefix   string
+	enabled  *widget.Check
+	address  *widget.Entry
+	channels *widget.Entry
+	rate     *widget.Entry
+}
+
+func newTransportSettings(label, prefix, defaultAddress string) *transportSettings {
+	t := &transportSettings{
+		prefix: prefix,
+		enabled: widget.NewCheck(label, func(b bool) {
+			fyne.CurrentApp().Preferences().SetBool(prefix, b)
+		}),
+		address:  newStringEntry(prefix+"Address", defaultAddress),
+		channels: newStringEntry(prefix+"Channels", "Channels, comma separated, all when empty"),
+		rate:     newFloatEntry(prefix + "Rate"),
+	}
+	t.rate.SetPlaceHolder("Max Hz, 0 sends every sample")
+	return t
+}
+
+// newStringEntry returns an entry that stores its text in prefKey
+func newStringEntry(prefKey, placeHolder string) *widget.Entry {
+	e := widget.NewEntry()
+	e.SetPlaceHolder(placeHolder)
+	e.OnChanged = func(s string) {
+		fyne.CurrentApp().Preferences().SetString(prefKey, s)
+	}
+	return e
+}
+
+func (t *transportSettings) load() {
+	loadPrefsCheck(t.enabled, t.prefix, false)
+	loadPrefsText(t.address, t.prefix+"Address", "")
+	loadPrefsText(t.channels, t.prefix+"Channels", "")
+	t.rate.SetText(strconv.FormatFloat(fyne.CurrentApp().Preferences().Float(t.prefix+"Rate"), 'f', -1, 64))
+}
+
+func (t *transportSettings) layout() fyne.CanvasObject {
+	return container.NewBorder(
+		nil,
+		nil,
+		t.enabled,
+		nil,
+		container.NewGridWithColumns(3, t.address, t.channels, t.rate),
+	)
+}
+
+func (t *transportSettings) config() telemetry.TransportConfig {
+	p := fyne.CurrentApp().Preferences()
+	cfg := telemetry.TransportConfig{
+		Enabled: p.Bool(t.prefix),
+		Address: strings.TrimSpace(p.String(t.prefix + "Address")),
+		Rate:    p.Float(t.prefix + "Rate"),
+	}
+	for _, c := range strings.Split(p.String(t.prefix+"Channels"), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cfg.Channels = append(cfg.Channels, c)
+		}
+	}
+	return cfg
+}
+
+func (sw *Widget) newTelemetry() {
+	sw.telemetryUDP = newTransportSettings("UDP multicast", "telemetryUDP", telemetry.DefaultMulticastAddress)
+	sw.telemetryWebSocket = newTransportSettings("WebSocket", "telemetryWebSocket", telemetry.DefaultWebSocketAddress)
+	sw.telemetryMQTT = newTransportSettings("MQTT", "telemetryMQTT", telemetry.DefaultMQTTAddress)
+	sw.telemetryMQTTTopic = newStringEntry(prefsTelemetryMQTTTopic, telemetry.DefaultMQTTTopic)
+	sw.telemetryMQTTUsername = newStringEntry(prefsTelemetryMQTTUsername, "Username")
+	sw.telemetryMQTTPassword = widget.NewPasswordEntry()
+	sw.telemetryMQTTPassword.SetPlaceHolder("Password")
+	sw.telemetryMQTTPassword.OnChanged = func(s string) {
+		fyne.CurrentApp().Preferences().SetString(prefsTelemetryMQTTPassword, s)
+	}
+	sw.metricsEnabled = widget.NewCheck("Prometheus metrics", func(b bool) {
+		fyne.CurrentApp().Preferences().SetBool(prefsMetricsEnabled, b)
+		sw.metricsChanged()
+	})
+	sw.metricsAddress = newStringEntry(prefsMetricsAddress, metrics.DefaultAddress)
+	sw.metricsAddress.OnSubmitted = func(string) {
+		sw.metricsChanged()
+	}
+}
+
+func (sw *Widget) metricsChanged() {
+	if sw.cfg.OnMetricsChanged != nil {
+		sw.cfg.OnMetricsChanged()
+	}
+}
+
+func (sw *Widget) loadTelemetryPreferences() {
+	sw.telemetryUDP.load()
+	sw.telemetryWebSocket.load()
+	sw.telemetryMQTT.load()
+	loadPrefsText(sw.telemetryMQTTTopic, prefsTelemetryMQTTTopic, "")
+	loadPrefsText(sw.telemetryMQTTUsername, prefsTelemetryMQTTUsername, "")
+	loadPrefsText(sw.telemetryMQTTPassword, prefsTelemetryMQTTPassword, "")
+	loadPrefsCheck(sw.metricsEnabled, prefsMetricsEnabled, false)
+	loadPrefsText(sw.metricsAddress, prefsMetricsAddress, "")
+}
+
+func (sw *Widget) telemetryTab() *container.TabItem {
+	return container.NewTabItem("Telemetry", container.NewVBox(
+		widget.NewLabel("Stream live samples while logging"),
+		sw.telemetryUDP.layout(),
+		sw.telemetryWebSocket.layout(),
+		sw.telemetryMQTT.layout(),
+		container.NewGridWithColumns(3,
+			container.NewBorder(nil, nil, widget.NewLabel("MQTT topic"), nil, sw.telemetryMQTTTopic),
+			sw.telemetryMQTTUsername,
+			sw.telemetryMQTTPassword,
+		),
+		widget.NewSeparator(),
+		widget.NewLabel("Serve live values and logger health on /metrics, press enter to apply a new address"),
+		container.NewBorder(nil, nil, sw.metricsEnabled, nil, sw.metricsAddress),
+	))
+}
+
+// GetTelemetryConfig returns the telemetry settings, nil when no transport is enabled
+func (sw *Widget) GetTelemetryConfig() *telemetry.Config {
+	p := fyne.CurrentApp().Preferences()
+	cfg := &telemetry.Config{
+		UDP:          sw.telemetryUDP.config(),
+		WebSocket:    sw.telemetryWebSocket.config(),
+		MQTT:         sw.telemetryMQTT.config(),
+		MQTTTopic:    strings.TrimSpace(p.String(prefsTelemetryMQTTTopic)),
+		MQTTUsername: p.String(prefsTelemetryMQTTUsername),
+		MQTTPassword: p.String(prefsTelemetryMQTTPassword),
+	}
+	if !cfg.UDP.Enabled && !cfg.WebSocket.Enabled && !cfg.MQTT.Enabled {
+		return nil
+	}
+	return cfg
+}
+
+// GetMetricsConfig returns if the metrics exporter is enabled and its bind address
+func (sw *Widget) GetMetricsConfig() (bool, string) {
+	p := fyne.CurrentApp().Preferences()
+	address := strings.TrimSpace(p.String(prefsMetricsAddress))
+	if address == "" {
+		address = metrics.DefaultAddress
+	}
+	return p.Bool(prefsMetricsEnabled), address
+}
@@ -108,6 +108,7 @@ func (s *Widget) SetValue(name string, value float64) {
 func (s *Widget) Disable() {
 	for _, e := range s.entries {
 		//e.symbolCorrectionfactor.Disable()
+		e.rateBTN.Disable()
 		e.deleteBTN.Disable()
 	}
 }
@@ -115,6 +116,7 @@ func (s *Widget) Disable() {
 func (s *Widget) Enable() {
 	for _, e := range s.entries {
 		//e.symbolCorrectionfactor.Enable()
+		e.rateBTN.Enable()
 		e.deleteBTN.Enable()
 	}
 }
@@ -196,11 +198,37 @@ func (s *Widget) Symbols() []*symbol.Symbol {
 	return out
 }
 
+// RateClasses returns the rate class of every symbol that is not read at normal rate
+func (s *Widget) RateClasses() map[string]datalogger.RateClass {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]datalogger.RateClass)
+	for _, e := range s.entries {
+		if e.rateClass != datalogger.RateNormal {
+			out[e.symbol.Name] = e.rateClass
+		}
+	}
+	return out
+}
+
+// SetRateClasses sets the rate class of the listed symbols, the others are read at normal rate
+func (s *Widget) SetRateClasses(rates map[string]datalogger.RateClass) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		rate := rates[e.symbol.Name]
+		if rate != datalogger.RateFast && rate != datalogger.RateSlow {
+			rate = datalogger.RateNormal
+		}
+		e.setRateClass(rate)
+	}
+}
+
 func (s *Widget) MinSize() fyne.Size {
 	return fyne.Size{Width: 280, Height: 221}
 }
 
-var headerSizes = []float64{.70, .20, .10}
+var headerSizes = []float64{.60, .20, .10, .10}
 
 func (s *Widget) CreateRenderer() fyne.WidgetRenderer {
 	name := widget.NewLabel("Name")
@@ -218,9 +246,12 @@ func (s *Widget) CreateRenderer() fyne.WidgetRenderer {
 	//factor := widget.NewLabel("Factor")
 	//factor.TextStyle = fyne.TextStyle{Bold: true}
 
+	rate := widget.NewLabel("Rate")
+	rate.TextStyle = fyne.TextStyle{Bold: true}
+
 	customLayout := xlayout.NewHPortion(headerSizes)
 	//header := container.New(ll, name, value, num /* typ,*/, factor, widget.NewLabel(""))
-	header := container.New(customLayout, name, value, widget.NewLabel(""))
+	header := container.New(customLayout, name, value, rate, widget.NewLabel(""))
 
 	return widget.NewSimpleRenderer(container.NewBorder(
 		header,
@@ -255,6 +286,17 @@ func (s *Widget) newSymbolWidgetEntry(sym *symbol.Symbol, deleteFunc func(*Symbo
 
 	//sw.SetCorrectionFactor(sym.Correctionfactor)
 
+	sw.rateBTN = widget.NewButton("", func() {
+		// cycle fast, normal, slow
+		for i, rate := range datalogger.RateClasses {
+			if rate == sw.rateClass {
+				sw.setRateClass(datalogger.RateClasses[(i+1)%len(datalogger.RateClasses)])
+				return
+			}
+		}
+	})
+	sw.setRateClass(datalogger.RateNormal)
+
 	sw.deleteBTN = widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
 		if sw.deleteFunc != nil {
 			sw.deleteFunc(sw)
@@ -271,6 +313,7 @@ func (s *Widget) newSymbolWidgetEntry(sym *symbol.Symbol, deleteFunc func(*Symbo
 		sw.symbolValue,
 		//sw.symbolNumber,
 		//sw.symbolCorrectionfactor,
+		sw.rateBTN,
 		sw.deleteBTN,
 	)
 	sw.container = container.NewStack(
@@ -291,6 +334,8 @@ type SymbolWidgetEntry struct {
 	symbolValue *widget.Label
 	//symbolNumber           *widget.Label
 	//symbolCorrectionfactor *widget.Entry
+	rateBTN        *widget.Button
+	rateClass      datalogger.RateClass
 	deleteBTN      *widget.Button
 	valueBar       *canvas.Rectangle
 	valueBarFactor float32
@@ -306,6 +351,12 @@ type SymbolWidgetEntry struct {
 	container *fyne.Container
 }
 
+// setRateClass shows the rate class as its first letter, F, N or S
+func (sw *SymbolWidgetEntry) setRateClass(rate datalogger.RateClass) {
+	sw.rateClass = rate
+	sw.rateBTN.SetText(string(rate)[:1])
+}
+
 /*
 func (sw *SymbolWidgetEntry) SetCorrectionFactor(f float64) {
 	sw.symbol.Correctionfactor = f
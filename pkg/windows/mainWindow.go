@@ -1,12 +1,12 @@
 package windows
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -20,11 +20,19 @@ import (
 	symbol "github.com/roffe/ecusymbol"
 	"github.com/roffe/gocan"
 	"github.com/roffe/gocan/proto"
+	"github.com/roffe/txlogger/pkg/alarms"
 	"github.com/roffe/txlogger/pkg/colors"
 	"github.com/roffe/txlogger/pkg/datalogger"
 	"github.com/roffe/txlogger/pkg/debug"
 	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/ecusim"
+	"github.com/roffe/txlogger/pkg/eventbus"
 	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/mathchannel"
+	"github.com/roffe/txlogger/pkg/metrics"
+	"github.com/roffe/txlogger/pkg/perf"
+	"github.com/roffe/txlogger/pkg/presets"
+	"github.com/roffe/txlogger/pkg/server"
 	"github.com/roffe/txlogger/pkg/update"
 	"github.com/roffe/txlogger/pkg/widgets/combinedlogplayer"
 	"github.com/roffe/txlogger/pkg/widgets/dashboard"
@@ -69,6 +77,7 @@ type MainWindow struct {
 	counters        *mainWindowCounters
 	loggingRunning  bool
 	filename        string
+	binary          binaryInfo
 	symbolList      *symbollist.Widget
 	fw              symbol.SymbolCollection
 	dlc             datalogger.IClient
@@ -84,6 +93,27 @@ type MainWindow struct {
 	canLED          *ledicon.Widget
 
 	previewFeatures bool
+
+	mathChannels    []*mathchannel.Channel
+	mathAggregators []*eventbus.EventAggregator
+
+	// alarms counts alarms for the whole session, the aggregators are replaced when the ECU changes
+	alarms           *alarms.Engine
+	alarmAggregators []*eventbus.EventAggregator
+
+	// open logplayers, updated when the event rules change
+	logplayers []*logplayer.Logplayer
+
+	// timed runs of logs and logging sessions, timingAggregator times the live speed while logging
+	timing           *perf.Store
+	timingAggregator *eventbus.EventAggregator
+
+	// metrics exporter, nil when turned off. metricsState lives on so counters can always update it
+	metrics      *metrics.Exporter
+	metricsState *metrics.State
+
+	// remote control API, nil when turned off
+	api *server.Server
 }
 
 type mainWindowSelects struct {
@@ -132,8 +162,12 @@ func NewMainWindow(app fyne.App) *MainWindow {
 		canLED:          ledicon.New("CAN"),
 		statusText:      secrettext.New("Harder, Better, Faster, Stronger"),
 		previewFeatures: app.Preferences().BoolWithFallback("enable_preview_features", false),
+		metricsState:    metrics.NewState(),
+		timing:          perf.NewStore(),
 	}
 
+	mw.alarms = alarms.New(mw.onAlarm)
+
 	mw.statusText.SecretFunc = func() {
 		mw.app.Preferences().SetBool("enable_preview_features", true)
 	}
@@ -155,9 +189,18 @@ func NewMainWindow(app fyne.App) *MainWindow {
 		SelectedEcuFunc: func() string {
 			return mw.selects.ecuSelect.Selected
 		},
+		OnMetricsChanged: mw.applyMetrics,
+		OnAPIChanged:     mw.applyAPI,
+		OnAlarmsChanged: func(ecu string) {
+			if ecu == mw.selects.ecuSelect.Selected {
+				mw.loadAlarms(ecu)
+			}
+		},
 	})
 
 	mw.loadPrefs()
+	mw.applyMetrics()
+	mw.applyAPI()
 
 	symbolListConfig.ColorBlindMode = mw.settings.GetColorBlindMode()
 
@@ -349,6 +392,7 @@ func (mw *MainWindow) LoadLogfileCombined(filename string, reader io.ReadCloser,
 		mw.Error(fmt.Errorf("failed to open log file: %w", err))
 		return
 	}
+	logz = mw.withMathChannels(logz)
 
 	dbcfg := &dashboard.Config{
 		Logplayer:       true,
@@ -439,6 +483,17 @@ func (mw *MainWindow) LoadLogfileCombined(filename string, reader io.ReadCloser,
 	mw.Log("loaded log file " + filename + " in combined logplayer")
 }
 
+// logReader returns the local file behind r when there is one. logfile.Open reads files on demand and has to
+// buffer any other reader in memory, the returned reader is closed by the caller
+func logReader(r fyne.URIReadCloser) io.ReadCloser {
+	if r.URI().Scheme() == "file" {
+		if f, err := os.Open(r.URI().Path()); err == nil {
+			return f
+		}
+	}
+	return r
+}
+
 func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Position) {
 	// Just filename, used for Window title
 	fp := filepath.Base(filename)
@@ -453,13 +508,39 @@ func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Positio
 		mw.Error(fmt.Errorf("failed to open log file: %w", err))
 		return
 	}
+	logz = mw.withMathChannels(logz)
 
 	mw.Log("loaded log file " + filename)
 
+	mw.showLogplayer(filename, logz, pos)
+}
+
+// showLogplayer opens logz in a new logplayer window titled with the base name of filename
+func (mw *MainWindow) showLogplayer(filename string, logz logfile.Logfile, pos fyne.Position) *logplayer.Logplayer {
+	title := filepath.Base(filename)
 	lp := logplayer.New(&logplayer.Config{
 		EBus:    ebus.CONTROLLER,
 		Logfile: logz,
+		Rules:   mw.eventRules(),
 	})
+	lp.OnMerge = func() {
+		mw.mergeWithLogplayer(filename, lp, pos)
+	}
+	lp.OnSave = func() {
+		mw.saveLogplayer(title, lp)
+	}
+	lp.OnReport = func() {
+		mw.reportLogplayer(filename, lp)
+	}
+	lp.OnMap = func() {
+		mw.mapLogplayer(filename, lp)
+	}
+	lp.OnDyno = func() {
+		mw.dynoLogplayer(filename, lp)
+	}
+	lp.OnTiming = func() {
+		mw.timeLogplayer(filename, lp)
+	}
 	/*
 		content := container.NewBorder(
 			container.NewHBox(
@@ -484,15 +565,17 @@ func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Positio
 			lp,
 		)
 	*/
-	iw := multiwindow.NewSystemWindow(fp, lp)
+	iw := multiwindow.NewSystemWindow(title, lp)
 	iw.Icon = theme.MediaPlayIcon()
 
 	lp.OnMouseDown = func() {
 		mw.wm.Raise(iw)
 	}
 	iw.OnClose = func() {
+		mw.logplayers = slices.DeleteFunc(mw.logplayers, func(l *logplayer.Logplayer) bool { return l == lp })
 		lp.Close()
 	}
+	mw.logplayers = append(mw.logplayers, lp)
 	mw.wm.Add(iw)
 	m := iw.MinSize()
 	pos2 := fyne.NewPos(pos.X-m.Width*0.5, pos.Y-m.Height*0.5)
@@ -503,6 +586,7 @@ func (mw *MainWindow) LoadLogfile(filename string, r io.Reader, pos fyne.Positio
 		pos2.Y = 80
 	}
 	iw.Move(pos2)
+	return lp
 }
 
 func (mw *MainWindow) Log(s string) {
@@ -631,6 +715,7 @@ func (mw *MainWindow) LoadSymbolsFromFile(filename string) error {
 	//mw.selects.ecuSelect.SetSelected(ecuType.String())
 	//mw.fw = symbols
 	mw.filename = filename
+	mw.binary = newBinaryInfo(ecuType.String(), data)
 	//mw.SyncSymbols()
 	return nil
 }
@@ -650,6 +735,7 @@ func (mw *MainWindow) LoadSymbolsFromBytes(filename string, data []byte) error {
 func (mw *MainWindow) LoadSymbols(symbols symbol.SymbolCollection, ecuType string) {
 	mw.selects.ecuSelect.SetSelected(ecuType)
 	mw.fw = symbols
+	ecusim.SetSymbols(symbols)
 	mw.SyncSymbols()
 }
 
@@ -658,17 +744,18 @@ func (mw *MainWindow) LoadPreset(r io.Reader) error {
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
-	var cfg []*symbol.Symbol
-	if err := json.Unmarshal(b, &cfg); err != nil {
+	cfg, rates, err := presets.Unmarshal(b)
+	if err != nil {
 		return fmt.Errorf("failed to unmarshal config file: %w", err)
 	}
 	mw.symbolList.LoadSymbols(cfg...)
+	mw.symbolList.SetRateClasses(rates)
 	mw.app.Preferences().SetString(prefsSymbolList, string(b))
 	return nil
 }
 
 func (mw *MainWindow) SavePreset(filename string) error {
-	b, err := json.Marshal(mw.symbolList.Symbols())
+	b, err := presets.Marshal(mw.symbolList.Symbols(), mw.symbolList.RateClasses())
 	if err != nil {
 		return fmt.Errorf("failed to marshal config file: %w", err)
 	}
@@ -0,0 +1,37 @@
+package windows
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/roffe/txlogger/pkg/alarms"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/sound"
+)
+
+// loadAlarms replaces the live alarm rules with the ones defined for ecu
+func (mw *MainWindow) loadAlarms(ecu string) {
+	rules, err := alarms.Load(mw.app.Preferences(), ecu)
+	if err != nil {
+		mw.Error(err)
+	}
+	ebus.CONTROLLER.UnregisterAggregator(mw.alarmAggregators...)
+	mw.alarmAggregators = nil
+	if len(rules) > 0 {
+		mw.alarmAggregators = append(mw.alarmAggregators, mw.alarms.Aggregator(rules))
+	}
+	ebus.CONTROLLER.RegisterAggregator(mw.alarmAggregators...)
+}
+
+// onAlarm is called from the event bus, the dashboard flashes on its own from the published alarm count
+func (mw *MainWindow) onAlarm(a alarms.Alarm) {
+	mw.Log("Alarm: " + a.Rule + " " + strconv.FormatFloat(a.Value, 'f', -1, 64))
+	if a.Silent {
+		return
+	}
+	go func() {
+		if err := sound.Beep(1000, 400*time.Millisecond); err != nil {
+			mw.Log("Alarm sound: " + err.Error())
+		}
+	}()
+}
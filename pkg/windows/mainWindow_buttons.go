@@ -1,10 +1,13 @@
 package windows
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -12,8 +15,11 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/cancapture"
 	"github.com/roffe/txlogger/pkg/datalogger"
 	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/mathchannel"
+	"github.com/roffe/txlogger/pkg/telemetry"
 	"github.com/roffe/txlogger/pkg/widgets"
 	"github.com/roffe/txlogger/pkg/widgets/dashboard"
 	"github.com/roffe/txlogger/pkg/widgets/msglist"
@@ -150,9 +156,11 @@ func (mw *MainWindow) newOpenLogBtn() *widget.Button {
 		cb := func(r fyne.URIReadCloser) {
 			defer r.Close()
 			filename := r.URI().Path()
-			mw.LoadLogfileCombined(filename, r, fyne.Position{}, true)
+			lr := logReader(r)
+			defer lr.Close()
+			mw.LoadLogfileCombined(filename, lr, fyne.Position{}, true)
 		}
-		widgets.SelectFile(cb, "logfile", "t5l", "t7l", "t8l", "csv")
+		widgets.SelectFile(cb, "logfile", logfileExtensions...)
 	})
 }
 
@@ -201,7 +209,7 @@ func (mw *MainWindow) addSymbolBtnFunc() *widget.Button {
 			}
 		*/
 
-		sym := mw.fw.GetByName(mw.selects.symbolLookup.Text)
+		sym := mw.lookupSymbol(mw.selects.symbolLookup.Text)
 		if sym == nil {
 			mw.Error(fmt.Errorf("%q not found", mw.selects.symbolLookup.Text))
 			return
@@ -371,12 +379,20 @@ func (mw *MainWindow) startLogging() {
 		}
 	}
 
-	mw.dlc, _, err = newDataLogger(mw, device)
+	var filename string
+	mw.dlc, filename, err = newDataLogger(mw, device)
 	if err != nil {
 		mw.Error(err)
 		return
 	}
+	mw.startTiming(filename)
+	mw.runDataLogger(deviceName)
+}
 
+// runDataLogger starts mw.dlc and puts the window back when it stops. It is called on the UI thread, loggingRunning
+// and dlc are only changed there since the API reads them from it
+func (mw *MainWindow) runDataLogger(deviceName string) {
+	dlc := mw.dlc
 	mw.loggingRunning = true
 
 	mw.buttons.logBtn.Icon = theme.MediaStopIcon()
@@ -385,13 +401,21 @@ func (mw *MainWindow) startLogging() {
 	mw.canLED.On()
 	go func() {
 		mw.Log("Connecting to " + deviceName)
-		if err := mw.dlc.Start(); err != nil {
-			mw.Error(err)
+		if err := dlc.Start(); err != nil {
+			if errors.Is(err, cancapture.ErrEndOfCapture) {
+				mw.Log("Reached the end of the capture")
+			} else {
+				mw.Error(err)
+			}
 		}
 		mw.Log(deviceName + " disconnected")
-		mw.loggingRunning = false
-		mw.dlc = nil
+		mw.stopTiming()
+		mw.metricsState.SetFPS(0)
+		mw.metricsState.SetErrorRate(0)
+		mw.metricsState.Clear()
 		fyne.Do(func() {
+			mw.loggingRunning = false
+			mw.dlc = nil
 			mw.Enable()
 			mw.buttons.logBtn.Icon = theme.MediaPlayIcon()
 			mw.buttons.logBtn.SetText("Start")
@@ -402,30 +426,62 @@ func (mw *MainWindow) startLogging() {
 }
 
 func newDataLogger(mw *MainWindow, device gocan.Adapter) (datalogger.IClient, string, error) {
-	return datalogger.New(datalogger.Config{
+	trigger, err := newTrigger(mw)
+	if err != nil {
+		return nil, "", err
+	}
+	outputs, err := newOutputs(mw)
+	if err != nil {
+		return nil, "", err
+	}
+	symbols := mw.symbolList.Symbols()
+	gpsCfg := mw.settings.GetGPSConfig()
+	var channels []string
+	if mw.settings.GetWidebandSymbolName() == datalogger.EXTERNALWBLSYM {
+		channels = append(channels, datalogger.EXTERNALWBLSYM)
+	}
+	if gpsCfg.Port != "" {
+		channels = append(channels, datalogger.GPSSymbols...)
+	}
+	mw.metricsState.SetSymbols(mw.selects.ecuSelect.Selected, symbols, channels...)
+	logged := slices.Clone(channels)
+	for _, sym := range symbols {
+		logged = append(logged, sym.Name)
+	}
+	mw.checkMathChannelInputs(logged)
+	rateMin, rateMax := mw.settings.GetRateBounds()
+	dl, filename, err := datalogger.New(datalogger.Config{
 		FilenamePrefix: strings.TrimSuffix(filepath.Base(mw.filename), filepath.Ext(mw.filename)),
 		ECU:            mw.selects.ecuSelect.Selected,
 		Device:         device,
-		Symbols:        mw.symbolList.Symbols(),
+		Symbols:        symbols,
 		Rate:           mw.settings.GetFreq(),
+		RateMin:        rateMin,
+		RateMax:        rateMax,
+		RateClasses:    mw.symbolList.RateClasses(),
 		OnMessage:      mw.Log,
 		CaptureCounter: func(i int) {
+			mw.metricsState.SetCaptures(i)
 			fyne.Do(func() {
 				mw.counters.capturedCounterLabel.SetText("Cap: " + strconv.Itoa(i))
 			})
 		},
 		ErrorCounter: func(i int) {
+			mw.metricsState.SetErrors(i)
 			fyne.Do(func() {
 				mw.counters.errorCounterLabel.SetText("Err: " + strconv.Itoa(i))
 			})
 		},
 		FpsCounter: func(i int) {
+			mw.metricsState.SetFPS(i)
 			fyne.Do(func() {
 				mw.counters.fpsCounterLabel.SetText("Fps: " + strconv.Itoa(i))
 			})
 		},
-		LogFormat: mw.settings.GetLogFormat(),
-		LogPath:   mw.settings.GetLogPath(),
+		ErrorRateCounter: mw.metricsState.SetErrorRate,
+		LogFormat:        mw.settings.GetLogFormat(),
+		LogFormats:       mw.settings.GetLogFormats(),
+		LogPath:          mw.settings.GetLogPath(),
 		WidebandConfig: datalogger.WidebandConfig{
 			Type:                   mw.settings.GetWidebandType(),
 			Port:                   mw.settings.GetWidebandPort(),
@@ -434,7 +490,54 @@ func newDataLogger(mw *MainWindow, device gocan.Adapter) (datalogger.IClient, st
 			Low:                    mw.settings.GetLow(),
 			High:                   mw.settings.GetHigh(),
 		},
+		GPS: gpsCfg,
 		//Remote: mw.selects.remoteSelect.Selected == "Remote",
-		RemoteMode: mw.selects.remoteSelect.SelectedIndex(),
+		RemoteMode:     mw.selects.remoteSelect.SelectedIndex(),
+		Trigger:        trigger,
+		Compression:    mw.settings.GetLogCompression(),
+		RotateSize:     mw.settings.GetRotateSize(),
+		RotateInterval: mw.settings.GetRotateInterval(),
+		CANCapture:     mw.settings.GetCANCapture(),
+		Alarms:         mw.alarms.Count,
+		Metadata:       mw.logMetadata(),
+		Outputs:        outputs,
 	})
+	if err != nil {
+		for _, o := range outputs {
+			o.Close()
+		}
+	}
+	return dl, filename, err
+}
+
+// newOutputs starts the telemetry streams enabled in the settings
+func newOutputs(mw *MainWindow) ([]datalogger.Output, error) {
+	cfg := mw.settings.GetTelemetryConfig()
+	if cfg == nil {
+		return nil, nil
+	}
+	p, err := telemetry.New(*cfg, mw.Log)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start telemetry: %w", err)
+	}
+	return []datalogger.Output{{Name: "Telemetry", LogWriter: p}}, nil
+}
+
+// newTrigger returns the logging trigger from the settings, nil when triggered logging is off
+func newTrigger(mw *MainWindow) (*datalogger.Trigger, error) {
+	if !mw.settings.GetTriggerEnabled() {
+		return nil, nil
+	}
+	expr, err := mathchannel.Compile(mw.settings.GetTriggerExpression())
+	if err != nil {
+		return nil, fmt.Errorf("invalid trigger: %w", err)
+	}
+	return &datalogger.Trigger{
+		Condition: func(ts time.Time, get func(string) (float64, bool)) bool {
+			v, ok := expr.Eval(ts, get)
+			return ok && v != 0
+		},
+		PreTrigger: mw.settings.GetTriggerPreTrigger(),
+		Hold:       mw.settings.GetTriggerHold(),
+	}, nil
 }
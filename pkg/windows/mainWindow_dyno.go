@@ -0,0 +1,331 @@
+package windows
+
+import (
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/dyno"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/widgets"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+// size of the dyno plot, also what it is exported as
+const (
+	dynoWidth  = 1000
+	dynoHeight = 600
+)
+
+var dynoSmoothing = []string{"0", "0.25", "0.5", "1", "2"}
+
+// dynoPull is a pull of a log that can be plotted
+type dynoPull struct {
+	label string
+	log   *dyno.Log
+	pull  dyno.Pull
+}
+
+// dynoLogplayer opens the virtual dyno with the pulls found in the log shown in lp
+func (mw *MainWindow) dynoLogplayer(filename string, lp *logplayer.Logplayer) {
+	title := "Dyno " + filepath.Base(filename)
+	if w := mw.wm.HasWindow(title); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	lp.WithLogfile(func(lf logfile.Logfile) {
+		l, err := dyno.Read(filepath.Base(filename), lf, dyno.Channels{})
+		fyne.Do(func() {
+			if err != nil {
+				mw.Error(err)
+				return
+			}
+			mw.showDyno(title, l)
+		})
+	})
+}
+
+func (mw *MainWindow) showDyno(title string, l *dyno.Log) {
+	prefs := mw.app.Preferences()
+	profiles, err := dyno.LoadProfiles(prefs)
+	if err != nil {
+		mw.Error(err)
+	}
+
+	var (
+		pulls []dynoPull
+		runs  []*dyno.Run
+	)
+
+	plot := canvas.NewImageFromImage(dyno.Render(nil, dynoWidth, dynoHeight))
+	plot.FillMode = canvas.ImageFillContain
+	plot.SetMinSize(fyne.NewSize(500, 300))
+	status := widget.NewLabel("")
+	status.Wrapping = fyne.TextWrapWord
+
+	profileSelect := widget.NewSelect(nil, nil)
+	fromRPM := widget.NewCheck("Speed from rpm", nil)
+	smoothing := widget.NewSelect(dynoSmoothing, nil)
+	pullChecks := widget.NewCheckGroup(nil, nil)
+
+	update := func() {
+		i := profileSelect.SelectedIndex()
+		if i < 0 {
+			return
+		}
+		p := profiles[i]
+		if p.HasGearing() {
+			fromRPM.Enable()
+		} else {
+			fromRPM.Disable()
+		}
+		width, _ := strconv.ParseFloat(smoothing.Selected, 64)
+		opts := dyno.Options{
+			Smoothing:    width,
+			SpeedFromRPM: fromRPM.Checked && p.HasGearing(),
+		}
+		runs = runs[:0]
+		var errs []string
+		for _, dp := range pulls {
+			if !slices.Contains(pullChecks.Selected, dp.label) {
+				continue
+			}
+			run, err := dyno.Calculate(p, dp.log, dp.pull, opts)
+			if err != nil {
+				errs = append(errs, dp.label+": "+err.Error())
+				continue
+			}
+			runs = append(runs, run)
+		}
+		status.SetText(strings.Join(errs, "\n"))
+		plot.Image = dyno.Render(runs, dynoWidth, dynoHeight)
+		plot.Refresh()
+	}
+
+	addLog := func(l *dyno.Log) {
+		found := l.Pulls()
+		if len(found) == 0 {
+			mw.Error(fmt.Errorf("found no pulls in %s", l.Name))
+			return
+		}
+		for _, p := range found {
+			label := l.Label(p)
+			if slices.ContainsFunc(pulls, func(dp dynoPull) bool { return dp.label == label }) {
+				continue
+			}
+			pulls = append(pulls, dynoPull{label: label, log: l, pull: p})
+			pullChecks.Options = append(pullChecks.Options, label)
+		}
+		pullChecks.Refresh()
+		if len(pullChecks.Selected) == 0 {
+			pullChecks.SetSelected([]string{pulls[0].label})
+		}
+	}
+
+	setProfiles := func(selected int) {
+		names := make([]string, len(profiles))
+		for i, p := range profiles {
+			names[i] = p.Name
+		}
+		profileSelect.SetOptions(names)
+		profileSelect.SetSelectedIndex(selected)
+		// the select doesn't tell when the name of the profile stays the same
+		update()
+	}
+	saveProfile := func(i int, p dyno.Profile) {
+		updated := slices.Clone(profiles)
+		if i < 0 {
+			updated = append(updated, p)
+			i = len(updated) - 1
+		} else {
+			updated[i] = p
+		}
+		if err := dyno.SaveProfiles(prefs, updated); err != nil {
+			mw.Error(err)
+			return
+		}
+		profiles = updated
+		setProfiles(i)
+	}
+
+	editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+		if i := profileSelect.SelectedIndex(); i >= 0 {
+			mw.editDynoProfile(profiles[i], func(p dyno.Profile) { saveProfile(i, p) })
+		}
+	})
+	newBtn := widget.NewButtonWithIcon("", theme.ContentAddIcon(), func() {
+		p := dyno.DefaultProfiles()[0]
+		p.Name = ""
+		mw.editDynoProfile(p, func(p dyno.Profile) { saveProfile(-1, p) })
+	})
+	deleteBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		i := profileSelect.SelectedIndex()
+		if i < 0 || len(profiles) < 2 {
+			return
+		}
+		dialog.ShowConfirm("Delete profile", "Delete "+profiles[i].Name+"?", func(ok bool) {
+			if !ok {
+				return
+			}
+			updated := slices.Delete(slices.Clone(profiles), i, i+1)
+			if err := dyno.SaveProfiles(prefs, updated); err != nil {
+				mw.Error(err)
+				return
+			}
+			profiles = updated
+			setProfiles(0)
+		}, mw)
+	})
+
+	addLogBtn := widget.NewButtonWithIcon("Add log", theme.ContentAddIcon(), func() {
+		widgets.SelectFile(func(r fyne.URIReadCloser) {
+			defer r.Close()
+			name := r.URI().Name()
+			lr := logReader(r)
+			defer lr.Close()
+			lf, err := logfile.Open(name, lr)
+			if err != nil {
+				mw.Error(fmt.Errorf("failed to open log file: %w", err))
+				return
+			}
+			defer lf.Close()
+			l, err := dyno.Read(name, lf, dyno.Channels{})
+			if err != nil {
+				mw.Error(err)
+				return
+			}
+			addLog(l)
+		}, "Log file", logfileExtensions...)
+	})
+
+	exportPNG := widget.NewButtonWithIcon("PNG", theme.DocumentSaveIcon(), func() {
+		widgets.SaveFile(func(filename string) {
+			if filepath.Ext(filename) == "" {
+				filename += ".png"
+			}
+			f, err := os.Create(filename)
+			if err != nil {
+				mw.Error(err)
+				return
+			}
+			defer f.Close()
+			if err := png.Encode(f, dyno.Render(runs, dynoWidth, dynoHeight)); err != nil {
+				mw.Error(fmt.Errorf("failed to save %s: %w", filename, err))
+				return
+			}
+			mw.Log("saved dyno plot to " + filename)
+		}, "PNG image", "png")
+	})
+	exportCSV := widget.NewButtonWithIcon("CSV", theme.DocumentSaveIcon(), func() {
+		widgets.SaveFile(func(filename string) {
+			if filepath.Ext(filename) == "" {
+				filename += ".csv"
+			}
+			f, err := os.Create(filename)
+			if err != nil {
+				mw.Error(err)
+				return
+			}
+			defer f.Close()
+			if err := dyno.WriteCSV(f, runs); err != nil {
+				mw.Error(fmt.Errorf("failed to save %s: %w", filename, err))
+				return
+			}
+			mw.Log("saved dyno runs to " + filename)
+		}, "CSV file", "csv")
+	})
+
+	profileSelect.OnChanged = func(string) { update() }
+	fromRPM.OnChanged = func(bool) { update() }
+	smoothing.OnChanged = func(string) { update() }
+	pullChecks.OnChanged = func([]string) { update() }
+
+	smoothing.SetSelected(strconv.FormatFloat(dyno.DefaultSmoothing, 'f', -1, 64))
+	fromRPM.SetChecked(true)
+	setProfiles(0)
+	addLog(l)
+
+	top := container.NewHBox(
+		widget.NewLabel("Profile"),
+		profileSelect,
+		editBtn,
+		newBtn,
+		deleteBtn,
+		fromRPM,
+		widget.NewLabel("Smoothing (s)"),
+		smoothing,
+	)
+	left := container.NewBorder(
+		nil,
+		container.NewVBox(addLogBtn, container.NewGridWithColumns(2, exportPNG, exportCSV)),
+		nil,
+		nil,
+		container.NewVScroll(pullChecks),
+	)
+	content := container.NewBorder(
+		container.NewHScroll(top),
+		status,
+		left,
+		nil,
+		plot,
+	)
+	inner := multiwindow.NewInnerWindow(title, content)
+	inner.Icon = theme.MediaFastForwardIcon()
+	mw.wm.Add(inner)
+	inner.Resize(fyne.NewSize(1000, 600))
+}
+
+// editDynoProfile asks for the values of a car profile
+func (mw *MainWindow) editDynoProfile(p dyno.Profile, onSave func(dyno.Profile)) {
+	name := widget.NewEntry()
+	name.SetText(p.Name)
+	tire := widget.NewEntry()
+	tire.SetText(p.Tire)
+	tire.SetPlaceHolder("225/45R17")
+	mass := numberEntry(p.Mass)
+	gear := numberEntry(p.GearRatio)
+	final := numberEntry(p.FinalDrive)
+	cd := numberEntry(p.DragCoefficient)
+	area := numberEntry(p.FrontalArea)
+	rolling := numberEntry(p.RollingResistance)
+
+	d := dialog.NewForm("Car profile", "Save", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", name),
+		widget.NewFormItem("Mass (kg)", mass),
+		widget.NewFormItem("Gear ratio", gear),
+		widget.NewFormItem("Final drive", final),
+		widget.NewFormItem("Tire size", tire),
+		widget.NewFormItem("Drag coefficient", cd),
+		widget.NewFormItem("Frontal area (m²)", area),
+		widget.NewFormItem("Rolling resistance", rolling),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		p := dyno.Profile{Name: strings.TrimSpace(name.Text), Tire: strings.TrimSpace(tire.Text)}
+		p.Mass, _ = parseNumber(mass.Text)
+		p.GearRatio, _ = parseNumber(gear.Text)
+		p.FinalDrive, _ = parseNumber(final.Text)
+		p.DragCoefficient, _ = parseNumber(cd.Text)
+		p.FrontalArea, _ = parseNumber(area.Text)
+		p.RollingResistance, _ = parseNumber(rolling.Text)
+		if err := p.Validate(); err != nil {
+			mw.Error(err)
+			return
+		}
+		onSave(p)
+	}, mw)
+	d.Resize(fyne.NewSize(400, 450))
+	d.Show()
+}
@@ -0,0 +1,45 @@
+package windows
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"github.com/roffe/txlogger/pkg/events"
+	"github.com/roffe/txlogger/pkg/widgets/eventrules"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+// eventRules returns the event rules of the selected ECU
+func (mw *MainWindow) eventRules() []events.Rule {
+	rules, err := events.Load(mw.app.Preferences(), mw.selects.ecuSelect.Selected)
+	if err != nil {
+		mw.Error(err)
+	}
+	return rules
+}
+
+func (mw *MainWindow) openEventRules() {
+	if w := mw.wm.HasWindow("Event rules"); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	ecu := mw.selects.ecuSelect.Selected
+	rules, err := events.Load(mw.app.Preferences(), ecu)
+	if err != nil {
+		mw.Error(err)
+	}
+	editor := eventrules.New(ecu, rules, func(ecu string, rules []events.Rule) error {
+		if err := events.Save(mw.app.Preferences(), ecu, rules); err != nil {
+			return err
+		}
+		if ecu == mw.selects.ecuSelect.Selected {
+			for _, lp := range mw.logplayers {
+				lp.SetRules(rules)
+			}
+		}
+		return nil
+	})
+	inner := multiwindow.NewInnerWindow("Event rules", editor)
+	inner.Icon = theme.WarningIcon()
+	mw.wm.Add(inner)
+	inner.Resize(fyne.NewSize(950, 350))
+}
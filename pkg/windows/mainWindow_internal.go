@@ -14,8 +14,11 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/theme"
 	xwidget "fyne.io/x/fyne/widget"
+	symbol "github.com/roffe/ecusymbol"
 	"github.com/roffe/gocan/proto"
 	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/metrics"
+	"github.com/roffe/txlogger/pkg/obd2"
 	"github.com/roffe/txlogger/pkg/widgets/ebusmonitor"
 	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
 )
@@ -47,6 +50,28 @@ func newOtoContext() *oto.Context {
 }
 */
 
+// applyMetrics starts, stops or restarts the metrics exporter to match the settings
+func (mw *MainWindow) applyMetrics() {
+	enabled, address := mw.settings.GetMetricsConfig()
+	if mw.metrics != nil {
+		if enabled && mw.metrics.Address() == address {
+			return
+		}
+		mw.metrics.Close()
+		mw.metrics = nil
+		mw.Log("Metrics stopped")
+	}
+	if !enabled {
+		return
+	}
+	m, err := metrics.New(address, mw.metricsState, ebus.Values, mw.Log)
+	if err != nil {
+		mw.Error(err)
+		return
+	}
+	mw.metrics = m
+}
+
 func (mw *MainWindow) Close() {
 	if mw.dlc != nil {
 		mw.Log("Closing datalogger client")
@@ -76,7 +101,7 @@ func (mw *MainWindow) onDropped(p fyne.Position, uris []fyne.URI) {
 			if err := mw.LoadSymbolsFromFile(filename); err != nil {
 				mw.Error(err)
 			}
-		case ".t5l", ".t7l", ".t8l", ".csv":
+		case ".t5l", ".t7l", ".t8l", ".csv", ".txb", ".mlg", ".msl", ".gz", ".zst":
 			// Check if we dropped it on the open log button
 			// log.Println(mw.buttons.openLogBtn.Position(), mw.buttons.openLogBtn.Size())
 			if p.X >= mw.buttons.openLogBtn.Position().X && p.X <= mw.buttons.openLogBtn.Position().X+mw.buttons.openLogBtn.Size().Width &&
@@ -180,11 +205,34 @@ func (mw *MainWindow) loadPrefs() {
 
 }
 
+// lookupSymbols returns the symbols that can be added to the symbol list, the PID catalogue
+// when logging OBD-II and the symbols of the loaded binary otherwise
+func (mw *MainWindow) lookupSymbols() []*symbol.Symbol {
+	if mw.selects.ecuSelect.Selected == "OBD2" {
+		return obd2.Symbols()
+	}
+	if mw.fw == nil {
+		return nil
+	}
+	return mw.fw.Symbols()
+}
+
+func (mw *MainWindow) lookupSymbol(name string) *symbol.Symbol {
+	if mw.selects.ecuSelect.Selected == "OBD2" {
+		return obd2.SymbolByName(name)
+	}
+	if mw.fw == nil {
+		return nil
+	}
+	return mw.fw.GetByName(name)
+}
+
 func (mw *MainWindow) newSymbolnameTypeahead() {
 	mw.selects.symbolLookup = xwidget.NewCompletionEntry([]string{})
 	mw.selects.symbolLookup.PlaceHolder = "Search for symbol"
 	mw.selects.symbolLookup.OnChanged = func(s string) {
-		if mw.fw == nil {
+		symbols := mw.lookupSymbols()
+		if symbols == nil {
 			return
 		}
 		// completion start for text length >= 3
@@ -195,7 +243,7 @@ func (mw *MainWindow) newSymbolnameTypeahead() {
 		// Get the list of possible completion
 		//results := []string{"ADC1", "ADC2", "ADC3", "ADC4", "ADC5"}
 		var results []string
-		for _, sym := range mw.fw.Symbols() {
+		for _, sym := range symbols {
 			if sym.Length > 8 {
 				continue
 			}
@@ -0,0 +1,153 @@
+package windows
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/colors"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
+	"github.com/roffe/txlogger/pkg/widgets/maps"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+// gpsTrack is the positions of a log with a fix and the value of every channel at each of them
+type gpsTrack struct {
+	lat, lon []float64
+	values   map[string][]float64
+}
+
+func readTrack(lf logfile.Logfile) *gpsTrack {
+	t := &gpsTrack{values: make(map[string][]float64)}
+	lf.Seek(-1)
+	for rec := lf.Next(); !rec.EOF; rec = lf.Next() {
+		lat, ok := rec.Values[datalogger.GPSLATITUDESYM]
+		if !ok {
+			continue
+		}
+		lon := rec.Values[datalogger.GPSLONGITUDESYM]
+		if fix, ok := rec.Values[datalogger.GPSFIXSYM]; ok && fix == 0 || lat == 0 && lon == 0 {
+			continue
+		}
+		n := len(t.lat)
+		t.lat = append(t.lat, lat)
+		t.lon = append(t.lon, lon)
+		for k, v := range rec.Values {
+			col, ok := t.values[k]
+			if !ok {
+				col = make([]float64, n)
+			}
+			t.values[k] = append(col, v)
+		}
+		// channels missing from this record keep their last value
+		for k, col := range t.values {
+			if len(col) == n {
+				last := 0.0
+				if n > 0 {
+					last = col[n-1]
+				}
+				t.values[k] = append(col, last)
+			}
+		}
+	}
+	lf.Seek(-1)
+	return t
+}
+
+// colors returns the color of every position from the value of channel
+func (t *gpsTrack) colors(channel string, mode colors.ColorBlindMode) []color.Color {
+	values := t.values[channel]
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	out := make([]color.Color, len(t.lat))
+	for i := range out {
+		if i < len(values) && hi > lo {
+			out[i] = colors.GetColorInterpolation(lo, hi, values[i], mode)
+		} else {
+			out[i] = colors.GetColorInterpolation(0, 1, 0, mode)
+		}
+	}
+	return out
+}
+
+// mapLogplayer shows the GPS track of the log in lp, the cursor follows playback
+func (mw *MainWindow) mapLogplayer(filename string, lp *logplayer.Logplayer) {
+	title := "Map " + filepath.Base(filename)
+	if w := mw.wm.HasWindow(title); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	lp.WithLogfile(func(lf logfile.Logfile) {
+		track := readTrack(lf)
+		fyne.Do(func() {
+			if len(track.lat) == 0 {
+				mw.Error(fmt.Errorf("%s has no GPS positions", filepath.Base(filename)))
+				return
+			}
+			mw.showTrack(title, track)
+		})
+	})
+}
+
+func (mw *MainWindow) showTrack(title string, track *gpsTrack) {
+	mode := mw.settings.GetColorBlindMode()
+	m := maps.NewMap()
+
+	channels := make([]string, 0, len(track.values))
+	for k := range track.values {
+		channels = append(channels, k)
+	}
+	slices.Sort(channels)
+	channelSelect := widget.NewSelect(channels, func(s string) {
+		m.SetTrackColors(track.colors(s, mode))
+	})
+
+	points := make([]maps.TrackPoint, len(track.lat))
+	for i := range points {
+		points[i] = maps.TrackPoint{Lat: track.lat[i], Lon: track.lon[i]}
+	}
+	m.SetTrack(points)
+	channelSelect.SetSelected(datalogger.GPSSPEEDSYM)
+
+	var lat, lon float64
+	setCursor := func() {
+		if lat != 0 || lon != 0 {
+			m.SetCursor(lat, lon)
+		}
+	}
+	cancelLat := ebus.SubscribeFunc(datalogger.GPSLATITUDESYM, func(v float64) {
+		lat = v
+		setCursor()
+	})
+	cancelLon := ebus.SubscribeFunc(datalogger.GPSLONGITUDESYM, func(v float64) {
+		lon = v
+		setCursor()
+	})
+
+	content := container.NewBorder(
+		container.NewBorder(nil, nil, widget.NewLabel("Color by"), nil, channelSelect),
+		nil,
+		nil,
+		nil,
+		m,
+	)
+	inner := multiwindow.NewInnerWindow(title, content)
+	inner.Icon = theme.NavigateNextIcon()
+	inner.OnClose = func() {
+		cancelLat()
+		cancelLon()
+	}
+	mw.wm.Add(inner)
+	inner.Resize(fyne.NewSize(600, 500))
+}
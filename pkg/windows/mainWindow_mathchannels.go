@@ -0,0 +1,87 @@
+package windows
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/mathchannel"
+	"github.com/roffe/txlogger/pkg/widgets/mathchannels"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+// loadMathChannels replaces the live math channel aggregators with the ones defined for ecu
+func (mw *MainWindow) loadMathChannels(ecu string) {
+	defs, err := mathchannel.Load(mw.app.Preferences(), ecu)
+	if err != nil {
+		mw.Error(err)
+	}
+	channels, err := mathchannel.CompileAll(defs)
+	if err != nil {
+		mw.Error(err)
+		return
+	}
+	ebus.CONTROLLER.UnregisterAggregator(mw.mathAggregators...)
+	mw.mathChannels = channels
+	mw.mathAggregators = mathchannel.Aggregators(channels)
+	ebus.CONTROLLER.RegisterAggregator(mw.mathAggregators...)
+}
+
+// checkMathChannelInputs tells about math channels that stay empty while logging since an input is not logged
+func (mw *MainWindow) checkMathChannelInputs(logged []string) {
+	known := make(map[string]bool, len(logged)+len(mw.mathChannels))
+	for _, name := range logged {
+		known[name] = true
+	}
+	for _, ch := range mw.mathChannels {
+		known[ch.Name] = true
+	}
+	for _, ch := range mw.mathChannels {
+		for _, name := range ch.Vars() {
+			if !known[name] {
+				mw.Log(fmt.Sprintf("Math channel %s uses %s which is not logged", ch.Name, name))
+			}
+		}
+	}
+}
+
+// withMathChannels adds the math channels of the selected ECU to lf, channels using symbols not in lf are left out
+func (mw *MainWindow) withMathChannels(lf logfile.Logfile) logfile.Logfile {
+	defs, err := mathchannel.Load(mw.app.Preferences(), mw.selects.ecuSelect.Selected)
+	if err != nil {
+		mw.Error(err)
+	}
+	mlf, err := mathchannel.NewLogfile(lf, defs)
+	if err != nil {
+		mw.Error(err)
+		return lf
+	}
+	return mlf
+}
+
+func (mw *MainWindow) openMathChannels() {
+	if w := mw.wm.HasWindow("Math channels"); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	ecu := mw.selects.ecuSelect.Selected
+	defs, err := mathchannel.Load(mw.app.Preferences(), ecu)
+	if err != nil {
+		mw.Error(err)
+	}
+	editor := mathchannels.New(ecu, defs, func(ecu string, defs []mathchannel.Definition) error {
+		if err := mathchannel.Save(mw.app.Preferences(), ecu, defs); err != nil {
+			return err
+		}
+		if ecu == mw.selects.ecuSelect.Selected {
+			mw.loadMathChannels(ecu)
+		}
+		return nil
+	})
+	inner := multiwindow.NewInnerWindow("Math channels", editor)
+	inner.Icon = theme.ListIcon()
+	mw.wm.Add(inner)
+	inner.Resize(fyne.NewSize(700, 350))
+}
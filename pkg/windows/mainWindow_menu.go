@@ -7,6 +7,7 @@ import (
 	"log"
 	"math"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
@@ -16,9 +17,11 @@ import (
 	"fyne.io/fyne/v2/theme"
 	symbol "github.com/roffe/ecusymbol"
 	"github.com/roffe/gocan"
+	"github.com/roffe/txlogger/pkg/cancapture"
 	"github.com/roffe/txlogger/pkg/colors"
 	"github.com/roffe/txlogger/pkg/ebus"
 	"github.com/roffe/txlogger/pkg/ecu/t8/t8file"
+	"github.com/roffe/txlogger/pkg/ecu/z22se"
 	"github.com/roffe/txlogger/pkg/update"
 	"github.com/roffe/txlogger/pkg/widgets"
 	"github.com/roffe/txlogger/pkg/widgets/dtcreader"
@@ -189,17 +192,22 @@ func (mw *MainWindow) setupMenu() {
 				mw.wm.Add(inner)
 			}),
 			fyne.NewMenuItemWithIcon("Open binary", theme.DocumentIcon(), mw.loadBinary),
+			fyne.NewMenuItemWithIcon("Open Z22SE address list", theme.DocumentIcon(), mw.loadAddressList),
 			fyne.NewMenuItemWithIcon("Open log", theme.DocumentIcon(), func() {
 				cb := func(r fyne.URIReadCloser) {
 					defer r.Close()
-					filename := r.URI().Name()
+					filename := r.URI().Path()
 					mw.Log("opening logfile " + filename)
 					sz := mw.Window.Content().Size()
 					p := fyne.NewPos(sz.Width/2, sz.Height/2)
-					mw.LoadLogfile(filename, r, p)
+					lr := logReader(r)
+					defer lr.Close()
+					mw.LoadLogfile(filename, lr, p)
 				}
-				widgets.SelectFile(cb, "Log file", "csv", "t5l", "t7l", "t8l")
+				widgets.SelectFile(cb, "Log file", logfileExtensions...)
 			}),
+			fyne.NewMenuItemWithIcon("Open log, merge with…", theme.ContentAddIcon(), mw.mergeLogs),
+			fyne.NewMenuItemWithIcon("Re-decode CAN capture", theme.MediaReplayIcon(), mw.redecodeCapture),
 			fyne.NewMenuItemWithIcon("Open log folder", theme.FolderIcon(), func() {
 				var cmd *exec.Cmd
 				switch runtime.GOOS {
@@ -217,6 +225,9 @@ func (mw *MainWindow) setupMenu() {
 			fyne.NewMenuItemWithIcon("Settings", theme.SettingsIcon(), func() {
 				mw.openSettings()
 			}),
+			fyne.NewMenuItemWithIcon("Math channels", theme.ListIcon(), mw.openMathChannels),
+			fyne.NewMenuItemWithIcon("Event rules", theme.WarningIcon(), mw.openEventRules),
+			fyne.NewMenuItemWithIcon("Performance timing", theme.HistoryIcon(), mw.openTiming),
 			fyne.NewMenuItemWithIcon("What's new", theme.InfoIcon(), func() {
 				mw.showWhatsNew()
 			}),
@@ -262,6 +273,64 @@ func (mw *MainWindow) loadBinary() {
 	widgets.SelectFile(cb, "Binary file", "bin")
 }
 
+// loadAddressList loads the RAM addresses to log on a Z22SE, the binary has no symbol table
+func (mw *MainWindow) loadAddressList() {
+	if mw.dlc != nil {
+		mw.Error(errors.New("stop logging before loading a new address list"))
+		return
+	}
+	cb := func(r fyne.URIReadCloser) {
+		defer r.Close()
+		symbols, err := z22se.LoadAddressList(r)
+		if err != nil {
+			mw.Error(fmt.Errorf("error loading address list: %w", err))
+			return
+		}
+		mw.SetTitle(filepath.Base(r.URI().Path()))
+		mw.LoadSymbols(symbol.NewCollection(symbols...), "Z22SE")
+		mw.Log(fmt.Sprintf("Loaded %d symbols from address list", len(symbols)))
+	}
+	widgets.SelectFile(cb, "Address list", "csv", "txt")
+}
+
+// redecodeCapture replays a CAN capture through the logger of the selected ECU to write its log again,
+// like after fixing a correction factor. The symbols must be the ones logged when the capture was made
+func (mw *MainWindow) redecodeCapture() {
+	if mw.dlc != nil {
+		mw.Error(errors.New("stop logging before re-decoding a capture"))
+		return
+	}
+	if mw.symbolList.Count() == 0 {
+		mw.Error(errors.New("select the symbols that were logged in the capture"))
+		return
+	}
+	if mw.selects.remoteSelect.SelectedIndex() == 2 {
+		mw.Error(errors.New("captures can't be re-decoded in remote mode"))
+		return
+	}
+	cb := func(r fyne.URIReadCloser) {
+		filename := r.URI().Path()
+		r.Close()
+		replay, err := cancapture.OpenReplay(filename)
+		if err != nil {
+			mw.Error(err)
+			return
+		}
+		if ecu := replay.ECU(); ecu != "" && ecu != mw.selects.ecuSelect.Selected {
+			mw.Error(fmt.Errorf("the capture is from a %s, select it and load its symbols first", ecu))
+			return
+		}
+		mw.dlc, _, err = newDataLogger(mw, replay)
+		if err != nil {
+			mw.Error(err)
+			return
+		}
+		mw.Log("Re-decoding " + filepath.Base(filename) + ", it plays back at the logging rate")
+		mw.runDataLogger(filepath.Base(filename))
+	}
+	widgets.SelectFile(cb, "CAN capture", "asc")
+}
+
 var openMapLock sync.Mutex
 
 func (mw *MainWindow) openMap(typ symbol.ECUType, title string, mapName string) {
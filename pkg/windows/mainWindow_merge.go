@@ -0,0 +1,143 @@
+package windows
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/widgets"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
+)
+
+var logfileExtensions = []string{"csv", "t5l", "t7l", "t8l", "txb", "mlg", "msl", "gz", "zst"}
+
+// mergeLogs opens a logfile in a logplayer and asks for another logfile to merge it with
+func (mw *MainWindow) mergeLogs() {
+	widgets.SelectFile(func(r fyne.URIReadCloser) {
+		defer r.Close()
+		filename := r.URI().Path()
+		lr := logReader(r)
+		defer lr.Close()
+		base, err := logfile.Open(filename, lr)
+		if err != nil {
+			mw.Error(fmt.Errorf("failed to open log file: %w", err))
+			return
+		}
+		base = mw.withMathChannels(base)
+		mw.Log("loaded log file " + filename)
+		lp := mw.showLogplayer(filename, base, mw.centerPos())
+		mw.mergeWithLogplayer(filename, lp, mw.centerPos())
+	}, "Log file", logfileExtensions...)
+}
+
+// mergeWithLogplayer merges the log shown in lp with another logfile and opens the result in a new logplayer
+func (mw *MainWindow) mergeWithLogplayer(filename string, lp *logplayer.Logplayer, pos fyne.Position) {
+	title := filepath.Base(filename)
+	mw.selectMergeLog(title, func(otherName string, other logfile.Logfile, offset time.Duration, interp logfile.Interpolation) {
+		lp.WithLogfile(func(base logfile.Logfile) {
+			defer other.Close()
+			merged, err := mergeLogfiles(base, other, offset, interp)
+			if err != nil {
+				mw.Error(err)
+				return
+			}
+			fyne.Do(func() {
+				// math channels may use channels from both logs
+				merged := mw.withMathChannels(merged)
+				mw.Log("merged log file " + title + " with " + otherName)
+				mw.showLogplayer(filepath.Join(filepath.Dir(filename), title+" + "+otherName), merged, pos.AddXY(40, 40))
+			})
+		})
+	})
+}
+
+// selectMergeLog asks for the logfile to merge with and the merge settings
+func (mw *MainWindow) selectMergeLog(baseName string, cb func(name string, other logfile.Logfile, offset time.Duration, interp logfile.Interpolation)) {
+	widgets.SelectFile(func(r fyne.URIReadCloser) {
+		defer r.Close()
+		name := r.URI().Name()
+		lr := logReader(r)
+		defer lr.Close()
+		other, err := logfile.Open(name, lr)
+		if err != nil {
+			mw.Error(fmt.Errorf("failed to open log file: %w", err))
+			return
+		}
+
+		offsetEntry := widget.NewEntry()
+		offsetEntry.SetText("0")
+		offsetEntry.Validator = func(s string) error {
+			_, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", "."), 64)
+			return err
+		}
+		interpSelect := widget.NewSelect([]string{logfile.InterpolateLinear.String(), logfile.InterpolateHold.String()}, nil)
+		interpSelect.SetSelectedIndex(0)
+
+		d := dialog.NewForm("Merge "+baseName+" with "+name, "Merge", "Cancel", []*widget.FormItem{
+			widget.NewFormItem("Time offset (s)", offsetEntry),
+			widget.NewFormItem("Interpolation", interpSelect),
+		}, func(ok bool) {
+			if !ok {
+				other.Close()
+				return
+			}
+			seconds, _ := strconv.ParseFloat(strings.ReplaceAll(offsetEntry.Text, ",", "."), 64)
+			offset := time.Duration(seconds * float64(time.Second))
+			interp := logfile.InterpolateLinear
+			if interpSelect.SelectedIndex() == 1 {
+				interp = logfile.InterpolateHold
+			}
+			cb(name, other, offset, interp)
+		}, mw)
+		d.Resize(fyne.NewSize(450, 200))
+		d.Show()
+	}, "Log file", logfileExtensions...)
+}
+
+// mergeLogfiles merges other onto the timeline of base, offset is applied to other
+func mergeLogfiles(base, other logfile.Logfile, offset time.Duration, interp logfile.Interpolation) (logfile.Logfile, error) {
+	merged, err := logfile.Merge(
+		logfile.MergeSource{Logfile: base, Interpolation: interp},
+		logfile.MergeSource{Logfile: other, Offset: offset, Interpolation: interp},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge log files: %w", err)
+	}
+	return merged, nil
+}
+
+// saveLogplayer writes the log shown in lp to a new file, the format is taken from the file extension
+func (mw *MainWindow) saveLogplayer(title string, lp *logplayer.Logplayer) {
+	ecu := mw.selects.ecuSelect.Selected
+	widgets.SaveFile(func(filename string) {
+		if filepath.Ext(filename) == "" {
+			filename += ".csv"
+		}
+		lp.WithLogfile(func(lf logfile.Logfile) {
+			w, err := datalogger.NewFileWriter(filename, ecu)
+			if err != nil {
+				mw.Error(err)
+				return
+			}
+			if err := logfile.Export(lf, w, mw.settings.GetLogMetadata()); err != nil {
+				mw.Error(fmt.Errorf("failed to save %s: %w", title, err))
+				return
+			}
+			fyne.Do(func() {
+				mw.Log("saved " + title + " to " + filename)
+			})
+		})
+	}, "Log file", "csv")
+}
+
+func (mw *MainWindow) centerPos() fyne.Position {
+	sz := mw.Window.Content().Size()
+	return fyne.NewPos(sz.Width/2, sz.Height/2)
+}
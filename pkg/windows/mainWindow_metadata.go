@@ -0,0 +1,70 @@
+package windows
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ecu/t7"
+	"github.com/roffe/txlogger/pkg/ecu/t8/t8file"
+)
+
+// binaryInfo is what the log metadata tells about the loaded binary
+type binaryInfo struct {
+	md5 string
+	vin string
+}
+
+func newBinaryInfo(ecuType string, data []byte) binaryInfo {
+	sum := md5.Sum(data)
+	return binaryInfo{
+		md5: hex.EncodeToString(sum[:]),
+		vin: binaryVIN(ecuType, data),
+	}
+}
+
+// binaryVIN returns the VIN stored in a T7 or T8 binary, empty if there is none
+func binaryVIN(ecuType string, data []byte) string {
+	var vin string
+	switch ecuType {
+	case "T7":
+		if len(data) < 0x1000 {
+			return ""
+		}
+		vin, _ = t7.GetHeaderField(data, 0x90)
+	case "T8":
+		if !bytes.HasPrefix(data, t8file.T8MagicBytes) {
+			return ""
+		}
+		th := new(t8file.T8Header)
+		th.DecodeExtraInfo(data)
+		vin = th.VIN()
+	}
+	vin = strings.TrimSpace(strings.Trim(vin, "\x00"))
+	for _, r := range vin {
+		if r < 0x20 || r > 0x7E {
+			return ""
+		}
+	}
+	return vin
+}
+
+// logMetadata returns the metadata written to new logs, the datalogger adds the ECU, adapter, rate and wideband.
+// It is nil when the metadata header is turned off in the settings
+func (mw *MainWindow) logMetadata() *datalogger.Metadata {
+	if !mw.settings.GetLogMetadata() {
+		return nil
+	}
+	meta := &datalogger.Metadata{
+		Version:   mw.app.Metadata().Version,
+		VIN:       mw.binary.vin,
+		BinaryMD5: mw.binary.md5,
+	}
+	if mw.filename != "" {
+		meta.Binary = filepath.Base(mw.filename)
+	}
+	return meta
+}
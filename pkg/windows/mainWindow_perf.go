@@ -0,0 +1,274 @@
+package windows
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/perf"
+	"github.com/roffe/txlogger/pkg/widgets"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
+	"github.com/roffe/txlogger/pkg/widgets/multiwindow"
+)
+
+const (
+	timingTitle = "Performance timing"
+	timingBest  = "Best runs"
+	// timingBestRuns is how many runs of every interval are listed as the best
+	timingBestRuns = 3
+	timingAuto     = "Auto"
+)
+
+func (mw *MainWindow) timingConfig() perf.Config {
+	cfg, err := perf.LoadConfig(mw.app.Preferences())
+	if err != nil {
+		mw.Error(err)
+	}
+	return cfg
+}
+
+// startTiming times runs from the live vehicle speed while logging to filename
+func (mw *MainWindow) startTiming(filename string) {
+	mw.stopTiming()
+	session := mw.timing.Start("Live "+time.Now().Format("2006-01-02 15:04:05"), filename)
+	mw.timingAggregator = perf.NewTimer(mw.timingConfig()).Aggregator(func(r perf.Result) {
+		mw.timing.Add(session, r)
+		mw.Log(fmt.Sprintf("%s in %.2f s", r.Interval, r.Duration.Seconds()))
+	})
+	ebus.CONTROLLER.RegisterAggregator(mw.timingAggregator)
+}
+
+func (mw *MainWindow) stopTiming() {
+	if mw.timingAggregator != nil {
+		ebus.CONTROLLER.UnregisterAggregator(mw.timingAggregator)
+		mw.timingAggregator = nil
+	}
+}
+
+// timeLogplayer times the runs in the log shown in lp and lists them
+func (mw *MainWindow) timeLogplayer(filename string, lp *logplayer.Logplayer) {
+	title := filepath.Base(filename)
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(mw.settings.GetLogPath(), title)
+	}
+	cfg := mw.timingConfig()
+	lp.WithLogfile(func(lf logfile.Logfile) {
+		results, err := perf.Scan(lf, cfg)
+		fyne.Do(func() {
+			if err != nil {
+				mw.Error(fmt.Errorf("failed to time %s: %w", title, err))
+				return
+			}
+			mw.timing.Start(title, filename, results...)
+			mw.Log(fmt.Sprintf("found %d timed runs in %s", len(results), title))
+			mw.openTiming()
+		})
+	})
+}
+
+// scanLogTiming asks for a logfile and times the runs in it
+func (mw *MainWindow) scanLogTiming() {
+	widgets.SelectFile(func(r fyne.URIReadCloser) {
+		defer r.Close()
+		filename := r.URI().Path()
+		lr := logReader(r)
+		defer lr.Close()
+		lf, err := logfile.Open(filename, lr)
+		if err != nil {
+			mw.Error(fmt.Errorf("failed to open log file: %w", err))
+			return
+		}
+		defer lf.Close()
+		results, err := perf.Scan(lf, mw.timingConfig())
+		if err != nil {
+			mw.Error(fmt.Errorf("failed to time %s: %w", filepath.Base(filename), err))
+			return
+		}
+		mw.timing.Start(filepath.Base(filename), filename, results...)
+		mw.Log(fmt.Sprintf("found %d timed runs in %s", len(results), filepath.Base(filename)))
+	}, "Log file", logfileExtensions...)
+}
+
+// openTimedRun shows the log a run is from with playback at the start of the run
+func (mw *MainWindow) openTimedRun(r perf.Ranked) {
+	if r.Filename == "" {
+		mw.Error(fmt.Errorf("%s was not logged to a file", r.Session))
+		return
+	}
+	seek := func(lp *logplayer.Logplayer) {
+		if r.Pos >= 0 {
+			lp.Seek(r.Pos)
+			return
+		}
+		// live runs know the time they started at
+		lp.WithLogfile(func(lf logfile.Logfile) {
+			pos := 0
+			lf.Seek(-1)
+			for rec := lf.Next(); !rec.EOF && rec.Time.Before(r.Start); rec = lf.Next() {
+				pos = lf.Pos()
+			}
+			fyne.Do(func() {
+				lp.Seek(pos)
+			})
+		})
+	}
+	if w := mw.wm.HasWindow(filepath.Base(r.Filename)); w != nil {
+		if lp, ok := w.Content().(*logplayer.Logplayer); ok {
+			mw.wm.Raise(w)
+			seek(lp)
+			return
+		}
+	}
+	f, err := os.Open(r.Filename)
+	if err != nil {
+		mw.Error(err)
+		return
+	}
+	defer f.Close()
+	lf, err := logfile.Open(r.Filename, f)
+	if err != nil {
+		mw.Error(fmt.Errorf("failed to open log file: %w", err))
+		return
+	}
+	mw.Log("loaded log file " + r.Filename)
+	seek(mw.showLogplayer(r.Filename, mw.withMathChannels(lf), mw.centerPos()))
+}
+
+func (mw *MainWindow) openTiming() {
+	if w := mw.wm.HasWindow(timingTitle); w != nil {
+		mw.wm.Raise(w)
+		return
+	}
+	prefs := mw.app.Preferences()
+	cfg := mw.timingConfig()
+
+	var rows []perf.Ranked
+	list := widget.NewList(
+		func() int { return len(rows) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(describeRun(rows[id]))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		list.Unselect(id)
+		mw.openTimedRun(rows[id])
+	}
+
+	sessionSelect := widget.NewSelect(nil, nil)
+	refresh := func() {
+		sessions := mw.timing.Sessions()
+		options := []string{timingBest}
+		for _, s := range sessions {
+			options = append(options, s.Name)
+		}
+		sessionSelect.SetOptions(options)
+		if sessionSelect.SelectedIndex() < 0 {
+			sessionSelect.SetSelectedIndex(0)
+		}
+		rows = rows[:0]
+		if sessionSelect.Selected == timingBest {
+			rows = perf.Best(sessions, timingBestRuns)
+		}
+		for _, s := range sessions {
+			if s.Name != sessionSelect.Selected {
+				continue
+			}
+			for _, r := range s.Results {
+				rows = append(rows, perf.Ranked{Session: s.Name, Filename: s.Filename, Result: r})
+			}
+		}
+		list.Refresh()
+	}
+	sessionSelect.OnChanged = func(string) { refresh() }
+
+	channelSelect := widget.NewSelect(append([]string{timingAuto}, perf.SpeedChannels...), nil)
+	channelSelect.SetSelected(timingAuto)
+	if cfg.Channel != "" {
+		channelSelect.SetSelected(cfg.Channel)
+	}
+	threshold := numberEntry(cfg.StartThreshold)
+	rollout := numberEntry(cfg.Rollout)
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		cfg := perf.Config{}
+		if channelSelect.Selected != timingAuto {
+			cfg.Channel = channelSelect.Selected
+		}
+		cfg.StartThreshold, _ = parseNumber(threshold.Text)
+		cfg.Rollout, _ = parseNumber(rollout.Text)
+		if err := perf.SaveConfig(prefs, cfg); err != nil {
+			mw.Error(err)
+			return
+		}
+		mw.Log("saved timing settings, they are used for the next log or logging session")
+	})
+	scanBtn := widget.NewButtonWithIcon("Time log", theme.FolderOpenIcon(), mw.scanLogTiming)
+
+	settings := container.NewHBox(
+		widget.NewLabel("Speed"),
+		channelSelect,
+		widget.NewLabel("Start above (km/h)"),
+		container.NewGridWrap(fyne.NewSize(70, channelSelect.MinSize().Height), threshold),
+		widget.NewLabel("Rollout (m)"),
+		container.NewGridWrap(fyne.NewSize(70, channelSelect.MinSize().Height), rollout),
+		saveBtn,
+	)
+	content := container.NewBorder(
+		container.NewVBox(
+			container.NewHScroll(settings),
+			container.NewBorder(nil, nil, widget.NewLabel("Session"), scanBtn, sessionSelect),
+		),
+		widget.NewLabel("Click a run to open its log at the start of the run"),
+		nil,
+		nil,
+		list,
+	)
+
+	mw.timing.SetOnChange(func() {
+		fyne.Do(refresh)
+	})
+	refresh()
+
+	inner := multiwindow.NewInnerWindow(timingTitle, content)
+	inner.Icon = theme.HistoryIcon()
+	inner.OnClose = func() {
+		mw.timing.SetOnChange(nil)
+	}
+	mw.wm.Add(inner)
+	inner.Resize(fyne.NewSize(750, 450))
+}
+
+func describeRun(r perf.Ranked) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-13s %6.2f s", r.Interval, r.Duration.Seconds())
+	if iv, ok := perf.Lookup(r.Interval); ok && iv.Distance > 0 {
+		fmt.Fprintf(&b, " @ %.1f km/h", r.Speed)
+	} else {
+		fmt.Fprintf(&b, " over %.0f m", r.Distance)
+	}
+	fmt.Fprintf(&b, "   %s %s", r.Session, r.Start.Format("15:04:05"))
+	return b.String()
+}
+
+func parseNumber(s string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", "."), 64)
+}
+
+func numberEntry(v float64) *widget.Entry {
+	e := widget.NewEntry()
+	e.SetText(strconv.FormatFloat(v, 'f', -1, 64))
+	e.Validator = func(s string) error {
+		_, err := parseNumber(s)
+		return err
+	}
+	return e
+}
@@ -22,7 +22,7 @@ func (mw *MainWindow) savePreset() {
 		mw.newPreset()
 		return
 	}
-	if err := presets.Set(mw.selects.presetSelect.Selected, mw.symbolList.Symbols()); err != nil {
+	if err := presets.Set(mw.selects.presetSelect.Selected, mw.symbolList.Symbols(), mw.symbolList.RateClasses()); err != nil {
 		mw.Error(err)
 		return
 	}
@@ -43,7 +43,7 @@ func (mw *MainWindow) newPreset() {
 					mw.Error(fmt.Errorf("name can't be empty"))
 					return
 				}
-				if err := presets.Set(presetName.Text, mw.symbolList.Symbols()); err != nil {
+				if err := presets.Set(presetName.Text, mw.symbolList.Symbols(), mw.symbolList.RateClasses()); err != nil {
 					mw.Error(err)
 					return
 				}
@@ -0,0 +1,35 @@
+package windows
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"github.com/roffe/txlogger/pkg/logfile"
+	"github.com/roffe/txlogger/pkg/report"
+	"github.com/roffe/txlogger/pkg/widgets/logplayer"
+)
+
+// reportLogplayer generates a session report for the log shown in lp and saves it next to the log,
+// logs opened without a full path get their report in the log folder
+func (mw *MainWindow) reportLogplayer(filename string, lp *logplayer.Logplayer) {
+	title := filepath.Base(filename)
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(mw.settings.GetLogPath(), title)
+	}
+	lp.WithLogfile(func(lf logfile.Logfile) {
+		r, err := report.Generate(title, lf, report.Options{})
+		if err != nil {
+			mw.Error(fmt.Errorf("failed to generate report for %s: %w", title, err))
+			return
+		}
+		out := report.Filename(filename)
+		if err := r.Save(out); err != nil {
+			mw.Error(err)
+			return
+		}
+		fyne.Do(func() {
+			mw.Log("saved report for " + title + " to " + out)
+		})
+	})
+}
@@ -0,0 +1,203 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"fyne.io/fyne/v2"
+	symbol "github.com/roffe/ecusymbol"
+	"github.com/roffe/txlogger/pkg/common"
+	"github.com/roffe/txlogger/pkg/datalogger"
+	"github.com/roffe/txlogger/pkg/ebus"
+	"github.com/roffe/txlogger/pkg/server"
+)
+
+var (
+	errLogging    = errors.New("logging is running, stop it first")
+	errNotLogging = errors.New("logging is not running")
+)
+
+// applyAPI starts, stops or restarts the remote control API to match the settings
+func (mw *MainWindow) applyAPI() {
+	enabled, address := mw.settings.GetAPIConfig()
+	cfg := server.Config{Address: address}
+	if enabled {
+		path, err := common.GetAPITokenPath()
+		if err == nil {
+			cfg.Token, err = server.LoadToken(path)
+		}
+		if err != nil {
+			mw.Error(fmt.Errorf("API: %w", err))
+			enabled = false
+		}
+	}
+	if mw.api != nil {
+		if enabled && mw.api.Config() == cfg {
+			return
+		}
+		mw.api.Close()
+		mw.api = nil
+		mw.Log("API stopped")
+	}
+	if !enabled {
+		return
+	}
+	s, err := server.New(cfg, &remoteControl{mw: mw}, ebus.Values, mw.Log)
+	if err != nil {
+		mw.Error(err)
+		return
+	}
+	mw.api = s
+}
+
+// remoteControl is the main window as controlled through the API, the window is only touched on the UI thread
+type remoteControl struct {
+	mw *MainWindow
+}
+
+func (rc *remoteControl) Status() server.Status {
+	mw := rc.mw
+	var st server.Status
+	fyne.DoAndWait(func() {
+		st = server.Status{
+			Logging: mw.loggingRunning,
+			ECU:     mw.selects.ecuSelect.Selected,
+			Binary:  mw.filename,
+			Symbols: mw.symbolList.Count(),
+		}
+	})
+	return st
+}
+
+func (rc *remoteControl) StartLogging() error {
+	mw := rc.mw
+	var err error
+	fyne.DoAndWait(func() {
+		if mw.loggingRunning {
+			err = errors.New("logging is already running")
+			return
+		}
+		// the same checks as pressing start, errors are shown in the window
+		mw.buttons.logBtn.OnTapped()
+		if !mw.loggingRunning {
+			err = errors.New("logging did not start, see the txlogger log")
+		}
+	})
+	return err
+}
+
+func (rc *remoteControl) StopLogging() error {
+	mw := rc.mw
+	var err error
+	fyne.DoAndWait(func() {
+		if !mw.loggingRunning || mw.dlc == nil {
+			err = errNotLogging
+			return
+		}
+		mw.dlc.Close()
+	})
+	return err
+}
+
+func (rc *remoteControl) LoadBinary(filename string) error {
+	mw := rc.mw
+	var err error
+	fyne.DoAndWait(func() {
+		if mw.loggingRunning {
+			err = errLogging
+			return
+		}
+		err = mw.LoadSymbolsFromFile(filename)
+	})
+	return err
+}
+
+func (rc *remoteControl) LoadPreset(name, filename string) error {
+	mw := rc.mw
+	var f *os.File
+	if filename != "" {
+		var err error
+		if f, err = os.Open(filename); err != nil {
+			return err
+		}
+		defer f.Close()
+	}
+	var err error
+	fyne.DoAndWait(func() {
+		if mw.loggingRunning {
+			err = errLogging
+			return
+		}
+		if f != nil {
+			err = mw.LoadPreset(f)
+			return
+		}
+		if err = mw.loadPresetByName(name); err != nil {
+			return
+		}
+		// set without OnChanged, the preset is loaded already
+		mw.selects.presetSelect.Selected = name
+		mw.selects.presetSelect.Refresh()
+	})
+	return err
+}
+
+func (rc *remoteControl) LoadLayout(name string) error {
+	var err error
+	fyne.DoAndWait(func() {
+		err = rc.mw.LoadLayout(name)
+	})
+	return err
+}
+
+func (rc *remoteControl) Symbols(binary bool) ([]*symbol.Symbol, error) {
+	mw := rc.mw
+	var (
+		symbols []*symbol.Symbol
+		err     error
+	)
+	fyne.DoAndWait(func() {
+		if !binary {
+			symbols = mw.symbolList.Symbols()
+			return
+		}
+		if mw.fw == nil {
+			err = errors.New("no binary loaded")
+			return
+		}
+		symbols = mw.fw.Symbols()
+	})
+	return symbols, err
+}
+
+// client returns the running datalogger, RAM is read and written outside the UI thread through it
+func (rc *remoteControl) client() (datalogger.IClient, error) {
+	mw := rc.mw
+	var dlc datalogger.IClient
+	fyne.DoAndWait(func() {
+		if mw.loggingRunning {
+			dlc = mw.dlc
+		}
+	})
+	if dlc == nil {
+		return nil, errNotLogging
+	}
+	return dlc, nil
+}
+
+func (rc *remoteControl) ReadRAM(address, length uint32) ([]byte, error) {
+	dlc, err := rc.client()
+	if err != nil {
+		return nil, err
+	}
+	return dlc.GetRAM(address, length)
+}
+
+func (rc *remoteControl) WriteRAM(address uint32, data []byte) error {
+	dlc, err := rc.client()
+	if err != nil {
+		return err
+	}
+	return dlc.SetRAM(address, data)
+}
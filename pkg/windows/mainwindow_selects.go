@@ -33,6 +33,8 @@ func (mw *MainWindow) createSelects() {
 		idx := symbol.ECUTypeFromString(s)
 		ebus.Publish(ebus.TOPIC_ECU, float64(idx))
 		mw.SetMainMenu(mw.menu.GetMenu(s))
+		mw.loadMathChannels(s)
+		mw.loadAlarms(s)
 		pres := mw.app.Preferences().StringWithFallback(s+prefsSelectedPreset, s+" Dash")
 		mw.selects.presetSelect.SetSelected(pres)
 	})
@@ -41,15 +43,9 @@ func (mw *MainWindow) createSelects() {
 		if presetName == "Select preset" {
 			return
 		}
-		preset, err := presets.Get(presetName)
-		if err != nil {
+		if err := mw.loadPresetByName(presetName); err != nil {
 			mw.Error(err)
-			return
 		}
-		mw.symbolList.LoadSymbols(preset...)
-		mw.SyncSymbols()
-		ecu := mw.app.Preferences().String(prefsSelectedECU)
-		mw.app.Preferences().SetString(ecu+prefsSelectedPreset, presetName)
 	})
 	mw.selects.presetSelect.Alignment = fyne.TextAlignLeading
 	mw.selects.presetSelect.PlaceHolder = "Select preset"
@@ -67,3 +63,17 @@ func (mw *MainWindow) createSelects() {
 	})
 	mw.selects.remoteSelect.SetSelected(mw.app.Preferences().StringWithFallback(prefsRemoteMode, "Local"))
 }
+
+// loadPresetByName loads the symbols of a saved preset and remembers it as the preset of the ECU
+func (mw *MainWindow) loadPresetByName(presetName string) error {
+	preset, rates, err := presets.Get(presetName)
+	if err != nil {
+		return err
+	}
+	mw.symbolList.LoadSymbols(preset...)
+	mw.symbolList.SetRateClasses(rates)
+	mw.SyncSymbols()
+	ecu := mw.app.Preferences().String(prefsSelectedECU)
+	mw.app.Preferences().SetString(ecu+prefsSelectedPreset, presetName)
+	return nil
+}